@@ -25,9 +25,19 @@ package logrus
 import (
 	"fmt"
 	"os"
+	"sync"
 )
 
-var handlers = []func(){}
+var (
+	handlers   = []*exitHandler{}
+	handlersMu sync.Mutex
+)
+
+// exitHandler is a registered handler, its address identifies it so that it
+// can be unregistered.
+type exitHandler struct {
+	run func()
+}
 
 func runHandler(handler func()) {
 	defer func() {
@@ -40,15 +50,20 @@ func runHandler(handler func()) {
 }
 
 func runHandlers() {
-	for _, handler := range handlers {
-		runHandler(handler)
+	// Handlers may register other handlers
+	handlersMu.Lock()
+	registered := append([]*exitHandler{}, handlers...)
+	handlersMu.Unlock()
+
+	for _, handler := range registered {
+		runHandler(handler.run)
 	}
 }
 
-// Exit runs all the Logrus atexit handlers and then terminates the program using os.Exit(code)
+// Exit runs all the Logrus atexit handlers and then terminates the program using
+// the ExitFunc of the standard logger, os.Exit(code) by default.
 func Exit(code int) {
-	runHandlers()
-	os.Exit(code)
+	std.Exit(code)
 }
 
 // RegisterExitHandler adds a Logrus Exit handler, call logrus.Exit to invoke
@@ -60,5 +75,28 @@ func Exit(code int) {
 // closing database connections, or sending a alert that the application is
 // closing.
 func RegisterExitHandler(handler func()) {
-	handlers = append(handlers, handler)
+	registerExitHandler(handler)
+}
+
+// registerExitHandler registers handler as RegisterExitHandler does, and
+// returns it for unregisterExitHandler.
+func registerExitHandler(handler func()) *exitHandler {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	registered := &exitHandler{run: handler}
+	handlers = append(handlers, registered)
+	return registered
+}
+
+// unregisterExitHandler removes a handler registered by registerExitHandler,
+// e.g. when the resources it releases are closed.
+func unregisterExitHandler(handler *exitHandler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	for i, registered := range handlers {
+		if registered == handler {
+			handlers = append(handlers[:i], handlers[i+1:]...)
+			return
+		}
+	}
 }
@@ -1,11 +1,14 @@
 package logrus
 
 import (
+	"bytes"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -81,3 +84,34 @@ func main() {
 	logrus.Fatal("Bye bye")
 }
 `)
+
+func TestExitFunc(t *testing.T) {
+	var events []string
+	RegisterExitHandler(func() { events = append(events, "handler") })
+
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.ExitFunc = func(code int) { events = append(events, fmt.Sprintf("exit %d", code)) }
+	logger.SetAsync(16, Block)
+	defer logger.Close()
+
+	logger.Info("queued")
+	logger.WithField("key", "value").Fatalf("fatal %d", 1)
+	if !strings.Contains(buffer.String(), "queued") || !strings.Contains(buffer.String(), "fatal 1") {
+		t.Fatalf("entries must be written before exiting, got %q", buffer.String())
+	}
+	if strings.Join(events, ", ") != "handler, exit 1" {
+		t.Fatalf("handlers must run once before exiting, got %v", events)
+	}
+
+	events = nil
+	logger.Level = PanicLevel
+	logger.Fatalln("disabled")
+	if strings.Contains(buffer.String(), "disabled") {
+		t.Fatal("disabled fatal entries must not be written")
+	}
+	if strings.Join(events, ", ") != "handler, exit 1" {
+		t.Fatalf("disabled fatal entries must exit, got %v", events)
+	}
+}
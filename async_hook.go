@@ -0,0 +1,252 @@
+package logrus
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrHookClosed is returned when firing a closed AsyncHook.
+var ErrHookClosed = errors.New("logrus: fire closed hook")
+
+// BatchHook is implemented by hooks able to handle several entries at once,
+// e.g. to send them in a single request. AsyncHook hands them the entries
+// queued since their previous batch.
+type BatchHook interface {
+	Hook
+	FireBatch(entries []*Entry) error
+}
+
+// AsyncHook fires a hook from a background goroutine, so that a slow hook,
+// typically one shipping entries over the network, doesn't stall the logging
+// goroutines:
+//
+//    hook := logrus.NewAsyncHook(httpHook, 1024, logrus.DropOldest)
+//    defer hook.Close()
+//    logger.AddHook(hook)
+//
+// Copies of the entries are queued in a bounded buffer, applying the policy
+// when it is full. The wrapped hook is fired with the queued entries one by
+// one, or in batches of up to BatchSize entries if it implements BatchHook.
+// It is only ever fired from the background goroutine.
+//
+// Errors of the wrapped hook are reported to the handler set with
+// `SetHookErrorHandler` on the logger of the entry, from another goroutine
+// so that handlers can log. Fatal and panic entries wait for the queue to be
+// flushed, once the logger released its lock, and so does `Exit`.
+type AsyncHook struct {
+	hook   Hook
+	policy OverflowPolicy
+	queue  chan *Entry
+	exit   *exitHandler
+
+	// BatchSize is the maximum number of entries handed at once to a
+	// BatchHook, 100 if not set. It must be set before the hook is fired.
+	BatchSize int
+
+	start sync.Once
+
+	// Guards closed, fires hold it for reading so that the queue isn't
+	// closed during a send.
+	closeMu sync.RWMutex
+	closed  bool
+	done    chan struct{}
+
+	// Number of queued entries not yet fired, for Flush.
+	pendingMu sync.Mutex
+	pending   int
+	flushed   *sync.Cond
+
+	dropped uint64
+}
+
+// NewAsyncHook returns a hook firing hook in the background with a queue of
+// size entries, at least 1, applying policy when the queue is full.
+func NewAsyncHook(hook Hook, size int, policy OverflowPolicy) *AsyncHook {
+	if size < 1 {
+		size = 1
+	}
+	h := &AsyncHook{
+		hook:   hook,
+		policy: policy,
+		queue:  make(chan *Entry, size),
+		done:   make(chan struct{}),
+	}
+	h.flushed = sync.NewCond(&h.pendingMu)
+	h.exit = registerExitHandler(h.Flush)
+	return h
+}
+
+func (hook *AsyncHook) Levels() []Level {
+	return hook.hook.Levels()
+}
+
+func (hook *AsyncHook) Fire(entry *Entry) error {
+	hook.start.Do(func() { go hook.run() })
+
+	// Fatal and panic entries are flushed by Logger.Flush, not here where
+	// the lock of the logger is held
+	return hook.enqueue(entry.Dup())
+}
+
+func (hook *AsyncHook) enqueue(entry *Entry) error {
+	hook.closeMu.RLock()
+	defer hook.closeMu.RUnlock()
+	if hook.closed {
+		return ErrHookClosed
+	}
+
+	hook.addPending(1)
+	switch hook.policy {
+	case DropNewest:
+		select {
+		case hook.queue <- entry:
+		default:
+			hook.drop()
+		}
+	case DropOldest:
+		for {
+			select {
+			case hook.queue <- entry:
+				return nil
+			default:
+			}
+			select {
+			case <-hook.queue:
+				hook.drop()
+			default:
+			}
+		}
+	default:
+		hook.queue <- entry
+	}
+	return nil
+}
+
+// Flush waits until all the entries queued so far are fired.
+func (hook *AsyncHook) Flush() {
+	hook.pendingMu.Lock()
+	defer hook.pendingMu.Unlock()
+	for hook.pending > 0 {
+		hook.flushed.Wait()
+	}
+}
+
+// Close fires the remaining queued entries and stops the background
+// goroutine. The wrapped hook isn't closed.
+func (hook *AsyncHook) Close() error {
+	hook.closeMu.Lock()
+	if hook.closed {
+		hook.closeMu.Unlock()
+		return nil
+	}
+	hook.closed = true
+	close(hook.queue)
+	hook.closeMu.Unlock()
+	unregisterExitHandler(hook.exit)
+
+	started := true
+	hook.start.Do(func() { started = false })
+	if started {
+		<-hook.done
+	}
+	return nil
+}
+
+// Dropped returns the number of entries dropped because the queue was full.
+func (hook *AsyncHook) Dropped() uint64 {
+	return atomic.LoadUint64(&hook.dropped)
+}
+
+// hookError is an error of the wrapped hook, with the entry it was fired
+// with.
+type hookError struct {
+	err   error
+	entry *Entry
+}
+
+func (hook *AsyncHook) run() {
+	// The errors are handled by another goroutine: handlers logging wait
+	// for the lock of the logger, which is held by Fire while it waits for
+	// room in the queue with the Block policy.
+	errs := make(chan hookError, cap(hook.queue))
+	handled := make(chan struct{})
+	go func() {
+		defer close(handled)
+		for e := range errs {
+			hook.handleError(e.err, e.entry)
+		}
+	}()
+	defer func() {
+		close(errs)
+		<-handled
+		close(hook.done)
+	}()
+	report := func(err error, entry *Entry) {
+		select {
+		case errs <- hookError{err, entry}:
+		default:
+			fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+		}
+	}
+
+	batchHook, batching := hook.hook.(BatchHook)
+	size := hook.BatchSize
+	if size < 1 {
+		size = 100
+	}
+
+	for entry := range hook.queue {
+		if !batching {
+			err := fireHook(hook.hook, entry)
+			hook.addPending(-1)
+			if err != nil {
+				report(err, entry)
+			}
+			continue
+		}
+
+		batch := []*Entry{entry}
+	drain:
+		for len(batch) < size {
+			select {
+			case entry, ok := <-hook.queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, entry)
+			default:
+				break drain
+			}
+		}
+		err := batchHook.FireBatch(batch)
+		hook.addPending(-len(batch))
+		if err != nil {
+			report(err, batch[0])
+		}
+	}
+}
+
+func (hook *AsyncHook) handleError(err error, entry *Entry) {
+	if entry.Logger == nil {
+		fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+		return
+	}
+	entry.Logger.handleHookError(err, entry)
+}
+
+func (hook *AsyncHook) drop() {
+	atomic.AddUint64(&hook.dropped, 1)
+	hook.addPending(-1)
+}
+
+func (hook *AsyncHook) addPending(delta int) {
+	hook.pendingMu.Lock()
+	defer hook.pendingMu.Unlock()
+	hook.pending += delta
+	if hook.pending == 0 {
+		hook.flushed.Broadcast()
+	}
+}
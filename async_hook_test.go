@@ -0,0 +1,210 @@
+package logrus
+
+import (
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedHook blocks every fire until the gate is opened, signaling on started
+// when a fire begins.
+type gatedHook struct {
+	mu       sync.Mutex
+	messages []string
+	batches  []int
+	started  chan struct{}
+	gate     chan struct{}
+	batch    bool
+	err      error
+}
+
+func newGatedHook() *gatedHook {
+	return &gatedHook{started: make(chan struct{}, 100), gate: make(chan struct{})}
+}
+
+func (hook *gatedHook) Levels() []Level {
+	return AllLevels
+}
+
+func (hook *gatedHook) Fire(entry *Entry) error {
+	hook.started <- struct{}{}
+	<-hook.gate
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	hook.messages = append(hook.messages, entry.Message)
+	return hook.err
+}
+
+// batchingHook is a gatedHook implementing BatchHook.
+type batchingHook struct {
+	*gatedHook
+}
+
+func (hook batchingHook) FireBatch(entries []*Entry) error {
+	hook.started <- struct{}{}
+	<-hook.gate
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	for _, entry := range entries {
+		hook.messages = append(hook.messages, entry.Message)
+	}
+	hook.batches = append(hook.batches, len(entries))
+	return hook.err
+}
+
+func TestAsyncHookFiresInBackground(t *testing.T) {
+	inner := newGatedHook()
+	hook := NewAsyncHook(inner, 16, Block)
+
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+	logger.WithField("key", "value").Info("first")
+	logger.Info("second")
+
+	// The logging goroutine isn't held up by the hook
+	<-inner.started
+	close(inner.gate)
+	hook.Flush()
+
+	assert.Equal(t, []string{"first", "second"}, inner.messages)
+	assert.Nil(t, hook.Close())
+	assert.Equal(t, ErrHookClosed, hook.Fire(logger.WithField("key", "value")))
+}
+
+func TestAsyncHookBatches(t *testing.T) {
+	inner := batchingHook{newGatedHook()}
+	hook := NewAsyncHook(inner, 16, Block)
+	hook.BatchSize = 3
+
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+	logger.Info("1")
+	<-inner.started
+	for _, msg := range []string{"2", "3", "4", "5"} {
+		logger.Info(msg)
+	}
+	close(inner.gate)
+	assert.Nil(t, hook.Close())
+
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, inner.messages)
+	assert.Equal(t, []int{1, 3, 1}, inner.batches)
+}
+
+func TestAsyncHookOverflow(t *testing.T) {
+	testCases := []struct {
+		policy   OverflowPolicy
+		expected []string
+	}{
+		{DropNewest, []string{"1", "2", "3"}},
+		{DropOldest, []string{"1", "4", "5"}},
+	}
+
+	for _, tc := range testCases {
+		inner := newGatedHook()
+		hook := NewAsyncHook(inner, 2, tc.policy)
+		logger := New()
+		logger.Out = ioutil.Discard
+		logger.AddHook(hook)
+
+		logger.Info("1")
+		<-inner.started
+		for _, msg := range []string{"2", "3", "4", "5"} {
+			logger.Info(msg)
+		}
+		close(inner.gate)
+		assert.Nil(t, hook.Close())
+
+		assert.Equal(t, tc.expected, inner.messages)
+		assert.Equal(t, uint64(2), hook.Dropped())
+	}
+}
+
+func TestAsyncHookFlushesFatalEntries(t *testing.T) {
+	inner := newGatedHook()
+	close(inner.gate)
+	hook := NewAsyncHook(inner, 16, Block)
+	defer hook.Close()
+
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+	logger.Info("queued")
+	assert.Panics(t, func() { logger.Panic("panicking") })
+
+	assert.Equal(t, []string{"queued", "panicking"}, inner.messages)
+}
+
+func TestAsyncHookErrors(t *testing.T) {
+	inner := newGatedHook()
+	inner.err = errors.New("unavailable")
+	close(inner.gate)
+	hook := NewAsyncHook(inner, 16, Block)
+
+	handled := make(chan string, 1)
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+	logger.SetHookErrorHandler(func(err error, entry *Entry) {
+		handled <- err.Error() + " " + entry.Message
+	})
+	logger.Info("lost")
+	assert.Nil(t, hook.Close())
+
+	assert.Equal(t, "unavailable lost", <-handled)
+}
+
+func TestAsyncHookErrorHandlerLogging(t *testing.T) {
+	inner := newGatedHook()
+	inner.err = errors.New("unavailable")
+	close(inner.gate)
+	hook := NewAsyncHook(inner, 1, Block)
+
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+	logger.SetHookErrorHandler(func(err error, entry *Entry) {
+		if entry.Message != "hook failed" {
+			logger.WithField("entry", entry.Message).Warn("hook failed")
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			logger.Info("queued")
+		}
+		assert.Panics(t, func() { logger.Panic("panicking") })
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging deadlocked with an error handler logging")
+	}
+	assert.Nil(t, hook.Close())
+}
+
+func TestAsyncHookSize(t *testing.T) {
+	registered := len(handlers)
+	inner := newGatedHook()
+	close(inner.gate)
+	hook := NewAsyncHook(inner, 0, DropOldest)
+	assert.Equal(t, registered+1, len(handlers))
+
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+	logger.Info("not spinning")
+	hook.Flush()
+	assert.Nil(t, hook.Close())
+
+	assert.Equal(t, []string{"not spinning"}, inner.messages)
+	assert.Equal(t, registered, len(handlers), "closed hooks are unregistered from the exit handlers")
+	assert.NotPanics(t, func() { NewAsyncHook(inner, -1, Block).Close() })
+}
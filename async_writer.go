@@ -0,0 +1,178 @@
+package logrus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy tells what to do with an entry that can't be queued because
+// the queue is full.
+type OverflowPolicy uint32
+
+const (
+	// Block waits until there is room in the queue.
+	Block OverflowPolicy = iota
+	// DropOldest drops the oldest queued entry to make room for the new one.
+	DropOldest
+	// DropNewest drops the new entry.
+	DropNewest
+)
+
+// ErrWriterClosed is returned when writing to a closed AsyncWriter.
+var ErrWriterClosed = errors.New("logrus: write to closed writer")
+
+// AsyncWriter is an io.Writer queuing writes in a bounded buffer drained to
+// the wrapped writer by a background goroutine, so that a stalled output
+// doesn't block the logging goroutines. It is safe for concurrent use.
+//
+//    w := logrus.NewAsyncWriter(conn, 1024, logrus.DropOldest)
+//    defer w.Close()
+//    logger.Out = w
+//
+// Errors of the wrapped writer are reported on stderr since they can't be
+// returned to the caller anymore.
+type AsyncWriter struct {
+	out    io.Writer
+	policy OverflowPolicy
+	queue  chan asyncWrite
+
+	// Guards closed, writes hold it for reading so that the queue isn't
+	// closed during a send.
+	closeMu sync.RWMutex
+	closed  bool
+	done    chan struct{}
+
+	// Number of queued writes not yet written, for Flush.
+	pendingMu sync.Mutex
+	pending   int
+	flushed   *sync.Cond
+
+	dropped uint64
+}
+
+// asyncWrite is a queued write of p to out.
+type asyncWrite struct {
+	out io.Writer
+	p   []byte
+}
+
+// NewAsyncWriter returns an AsyncWriter writing to out with a queue of size
+// writes, at least 1, applying policy when the queue is full.
+func NewAsyncWriter(out io.Writer, size int, policy OverflowPolicy) *AsyncWriter {
+	if size < 1 {
+		size = 1
+	}
+	w := &AsyncWriter{
+		out:    out,
+		policy: policy,
+		queue:  make(chan asyncWrite, size),
+		done:   make(chan struct{}),
+	}
+	w.flushed = sync.NewCond(&w.pendingMu)
+	go w.run()
+	return w
+}
+
+// Write queues a copy of p to be written to the wrapped writer.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	return w.writeTo(w.out, p)
+}
+
+// writeTo queues a copy of p to be written to out, which allows a logger to
+// share the queue between the outputs of its levels.
+func (w *AsyncWriter) writeTo(out io.Writer, p []byte) (int, error) {
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	// Loggers reuse their buffers once Write returns.
+	buf := make([]byte, len(p))
+	copy(buf, p)
+	write := asyncWrite{out: out, p: buf}
+
+	w.addPending(1)
+	switch w.policy {
+	case DropNewest:
+		select {
+		case w.queue <- write:
+		default:
+			w.drop()
+		}
+	case DropOldest:
+		for {
+			select {
+			case w.queue <- write:
+				return len(p), nil
+			default:
+			}
+			select {
+			case <-w.queue:
+				w.drop()
+			default:
+			}
+		}
+	default:
+		w.queue <- write
+	}
+	return len(p), nil
+}
+
+// Flush waits until all the writes queued so far are written.
+func (w *AsyncWriter) Flush() {
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+	for w.pending > 0 {
+		w.flushed.Wait()
+	}
+}
+
+// Close writes the remaining queued writes and stops the background
+// goroutine. The wrapped writer isn't closed.
+func (w *AsyncWriter) Close() error {
+	w.closeMu.Lock()
+	if w.closed {
+		w.closeMu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.queue)
+	w.closeMu.Unlock()
+
+	<-w.done
+	return nil
+}
+
+// Dropped returns the number of writes dropped because the queue was full.
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	for write := range w.queue {
+		if _, err := write.out.Write(write.p); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+		}
+		w.addPending(-1)
+	}
+}
+
+func (w *AsyncWriter) drop() {
+	atomic.AddUint64(&w.dropped, 1)
+	w.addPending(-1)
+}
+
+func (w *AsyncWriter) addPending(delta int) {
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+	w.pending += delta
+	if w.pending == 0 {
+		w.flushed.Broadcast()
+	}
+}
@@ -0,0 +1,201 @@
+package logrus
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedWriter blocks every write until the gate is opened, signaling on
+// started when a write begins.
+type gatedWriter struct {
+	bytes.Buffer
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{started: make(chan struct{}, 100), gate: make(chan struct{})}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	w.started <- struct{}{}
+	<-w.gate
+	return w.Buffer.Write(p)
+}
+
+func TestAsyncWriterOrdering(t *testing.T) {
+	var buffer bytes.Buffer
+	w := NewAsyncWriter(&buffer, 16, Block)
+
+	var expected bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(w, "line %d\n", i)
+		fmt.Fprintf(&expected, "line %d\n", i)
+	}
+	assert.Nil(t, w.Close())
+
+	assert.Equal(t, expected.String(), buffer.String())
+	assert.Equal(t, uint64(0), w.Dropped())
+}
+
+func TestAsyncWriterConcurrentWrites(t *testing.T) {
+	var buffer bytes.Buffer
+	w := NewAsyncWriter(&buffer, 4, Block)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				w.Write([]byte("line\n"))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Nil(t, w.Close())
+
+	assert.Equal(t, 1000, strings.Count(buffer.String(), "line\n"))
+}
+
+func TestAsyncWriterOverflow(t *testing.T) {
+	testCases := []struct {
+		policy   OverflowPolicy
+		expected string
+	}{
+		{DropNewest, "123"},
+		{DropOldest, "145"},
+	}
+
+	for _, tc := range testCases {
+		out := newGatedWriter()
+		w := NewAsyncWriter(out, 2, tc.policy)
+
+		w.Write([]byte("1"))
+		<-out.started
+		for _, p := range []string{"2", "3", "4", "5"} {
+			w.Write([]byte(p))
+		}
+		close(out.gate)
+		assert.Nil(t, w.Close())
+
+		assert.Equal(t, tc.expected, out.String())
+		assert.Equal(t, uint64(2), w.Dropped())
+	}
+}
+
+func TestAsyncWriterFlush(t *testing.T) {
+	out := newGatedWriter()
+	w := NewAsyncWriter(out, 8, Block)
+	defer w.Close()
+
+	w.Write([]byte("a"))
+	w.Write([]byte("b"))
+
+	flushed := make(chan struct{})
+	go func() {
+		w.Flush()
+		close(flushed)
+	}()
+
+	<-out.started
+	select {
+	case <-flushed:
+		t.Fatal("Flush returned before the writes were done")
+	default:
+	}
+
+	close(out.gate)
+	<-flushed
+	assert.Equal(t, "ab", out.String())
+}
+
+func TestAsyncWriterClosed(t *testing.T) {
+	w := NewAsyncWriter(&bytes.Buffer{}, 1, Block)
+	assert.Nil(t, w.Close())
+	assert.Nil(t, w.Close())
+
+	_, err := w.Write([]byte("late"))
+	assert.Equal(t, ErrWriterClosed, err)
+}
+
+func TestAsyncWriterSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		var buffer bytes.Buffer
+		w := NewAsyncWriter(&buffer, size, DropOldest)
+		fmt.Fprint(w, "not spinning\n")
+		assert.Nil(t, w.Close())
+		assert.Equal(t, "not spinning\n", buffer.String())
+	}
+}
+
+func TestAsyncWriterAsLoggerOutput(t *testing.T) {
+	var buffer bytes.Buffer
+	w := NewAsyncWriter(&buffer, 8, Block)
+
+	logger := New()
+	logger.Out = w
+	logger.Formatter = new(JSONFormatter)
+	for i := 0; i < 10; i++ {
+		logger.WithField("i", i).Info("async")
+	}
+	assert.Nil(t, w.Close())
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	assert.Equal(t, 10, len(lines))
+	for i, line := range lines {
+		assert.Contains(t, line, fmt.Sprintf(`"i":%d`, i))
+	}
+}
+
+func TestLoggerSetAsync(t *testing.T) {
+	var out, errOut bytes.Buffer
+
+	logger := New()
+	logger.Out = &out
+	logger.Formatter = new(JSONFormatter)
+	logger.SetLevelOutput(ErrorLevel, &errOut)
+	logger.SetAsync(4, Block)
+
+	for i := 0; i < 100; i++ {
+		logger.WithField("i", i).Info("async")
+	}
+	logger.Error("failure")
+	logger.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Equal(t, 100, len(lines))
+	for i, line := range lines {
+		assert.Contains(t, line, fmt.Sprintf(`"i":%d`, i))
+	}
+	assert.Contains(t, errOut.String(), "failure")
+
+	assert.Nil(t, logger.Close())
+	out.Reset()
+	logger.Info("sync")
+	assert.Contains(t, out.String(), "sync", "entries must be written synchronously after Close")
+}
+
+func TestLoggerSetAsyncOverflow(t *testing.T) {
+	out := newGatedWriter()
+
+	logger := New()
+	logger.Out = out
+	logger.Formatter = &TextFormatter{DisableColors: true, DisableTimestamp: true, DisableRuntimeFields: true}
+	logger.SetAsync(2, DropNewest)
+
+	logger.Info("1")
+	<-out.started
+	for _, msg := range []string{"2", "3", "4", "5"} {
+		logger.Info(msg)
+	}
+	close(out.gate)
+	assert.Nil(t, logger.Close())
+
+	assert.Equal(t, "[info] 1 \n[info] 2 \n[info] 3 \n", out.String())
+}
@@ -0,0 +1,29 @@
+// +build otel
+
+package logrus
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// BaggageExtractor returns a context extractor adding the OpenTelemetry
+// baggage members with the given keys as fields, any other member of the
+// baggage is ignored. It's only available when building with the otel tag.
+func BaggageExtractor(keys ...string) func(ctx context.Context) Fields {
+	return func(ctx context.Context) Fields {
+		fields := make(Fields, len(keys))
+		if ctx == nil {
+			return fields
+		}
+
+		bag := baggage.FromContext(ctx)
+		for _, key := range keys {
+			if member := bag.Member(key); member.Key() != "" {
+				fields[key] = member.Value()
+			}
+		}
+		return fields
+	}
+}
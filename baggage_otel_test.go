@@ -0,0 +1,29 @@
+// +build otel
+
+package logrus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+func TestBaggageExtractor(t *testing.T) {
+	var members []baggage.Member
+	for key, value := range map[string]string{"tenant": "acme", "request_id": "42", "secret": "hunter2"} {
+		member, err := baggage.NewMember(key, value)
+		assert.NoError(t, err)
+		members = append(members, member)
+	}
+	bag, err := baggage.New(members...)
+	assert.NoError(t, err)
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	extract := BaggageExtractor("tenant", "request_id", "missing")
+
+	assert.Equal(t, Fields{"tenant": "acme", "request_id": "42"}, extract(ctx))
+	assert.Equal(t, Fields{}, extract(context.Background()))
+	assert.Equal(t, Fields{}, extract(nil))
+}
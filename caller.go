@@ -0,0 +1,51 @@
+package logrus
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Directory of the sources of logrus, whose frames are skipped to find the
+// caller of an entry. Frames are matched by file rather than by function so
+// that the tests of the package count as callers.
+var logrusDir = sourceDir()
+
+func sourceDir() string {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		return ""
+	}
+	return filepath.Dir(file)
+}
+
+// Maximum number of frames walked to find the caller.
+const maximumCallerDepth = 32
+
+// getCaller returns the first frame outside of logrus, after skipping skip
+// more frames for the logging wrappers, or nil if there is none.
+func getCaller(skip int) *runtime.Frame {
+	pcs := make([]uintptr, maximumCallerDepth)
+	// skip runtime.Callers and getCaller itself
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if !inLogrus(frame.File) {
+			if skip == 0 {
+				return &frame
+			}
+			skip--
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// inLogrus reports whether file is a source file of logrus, not counting
+// its tests.
+func inLogrus(file string) bool {
+	return filepath.Dir(file) == logrusDir && !strings.HasSuffix(file, "_test.go")
+}
@@ -0,0 +1,62 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func logThroughHelper(logger *Logger) {
+	logger.Info("helper")
+}
+
+func TestReportCaller(t *testing.T) {
+	var buffer bytes.Buffer
+	var fields Fields
+
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = new(JSONFormatter)
+	logger.SetReportCaller(true)
+
+	logger.WithField("file", "clash").Info("hello")
+	err := json.Unmarshal(buffer.Bytes(), &fields)
+	assert.Nil(t, err)
+	assert.Equal(t, "github.com/sirupsen/logrus.TestReportCaller", fields["func"])
+	assert.True(t, strings.Contains(fields["file"].(string), "caller_test.go:"), fields["file"])
+	assert.Equal(t, "clash", fields["fields.file"])
+
+	buffer.Reset()
+	logger.CallerSkip = 1
+	logThroughHelper(logger)
+	fields = nil
+	err = json.Unmarshal(buffer.Bytes(), &fields)
+	assert.Nil(t, err)
+	assert.Equal(t, "github.com/sirupsen/logrus.TestReportCaller", fields["func"])
+
+	buffer.Reset()
+	logger.SetReportCaller(false)
+	logger.Info("hello")
+	fields = nil
+	err = json.Unmarshal(buffer.Bytes(), &fields)
+	assert.Nil(t, err)
+	assert.Nil(t, fields["func"])
+	assert.Nil(t, fields["file"])
+}
+
+func TestReportCallerTextFormatter(t *testing.T) {
+	var buffer bytes.Buffer
+
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &TextFormatter{ForceColors: true, FieldMap: FieldMap{FieldKeyFunc: "caller"}}
+	logger.SetReportCaller(true)
+
+	logger.Info("hello")
+	assert.Contains(t, buffer.String(), "caller\x1b[0m=github.com/sirupsen/logrus.TestReportCallerTextFormatter")
+	assert.Contains(t, buffer.String(), "file\x1b[0m=")
+	assert.Contains(t, buffer.String(), "caller_test.go:")
+}
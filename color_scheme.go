@@ -0,0 +1,153 @@
+package logrus
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Color is a color of the colored output of the TextFormatter, given by the
+// parameters of an ANSI SGR escape sequence, e.g. "31" for red. Colors can be
+// combined with Colors, e.g. Colors(ColorBold, ColorRed, BgBlack) for bold
+// red on black.
+type Color string
+
+// The basic colors, supported by all the terminals. NoColor prints the text
+// without escape sequences, in the default color of the terminal.
+const (
+	NoColor Color = "0"
+
+	ColorBold      Color = "1"
+	ColorUnderline Color = "4"
+
+	ColorBlack   Color = "30"
+	ColorRed     Color = "31"
+	ColorGreen   Color = "32"
+	ColorYellow  Color = "33"
+	ColorBlue    Color = "34"
+	ColorMagenta Color = "35"
+	ColorCyan    Color = "36"
+	ColorWhite   Color = "37"
+	ColorGray    Color = "90"
+
+	BgBlack   Color = "40"
+	BgRed     Color = "41"
+	BgGreen   Color = "42"
+	BgYellow  Color = "43"
+	BgBlue    Color = "44"
+	BgMagenta Color = "45"
+	BgCyan    Color = "46"
+	BgWhite   Color = "47"
+)
+
+// Colors combines several colors, e.g. a foreground, a background and bold.
+func Colors(colors ...Color) Color {
+	params := make([]string, 0, len(colors))
+	for _, c := range colors {
+		if c != "" && c != NoColor {
+			params = append(params, string(c))
+		}
+	}
+	if len(params) == 0 {
+		return NoColor
+	}
+	return Color(strings.Join(params, ";"))
+}
+
+// RGBColor returns the 24-bit foreground color, for terminals supporting
+// true colors.
+func RGBColor(r, g, b uint8) Color {
+	return Color("38;2;" + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b)))
+}
+
+// RGBBackground returns the 24-bit background color, for terminals
+// supporting true colors.
+func RGBBackground(r, g, b uint8) Color {
+	return Color("48;2;" + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b)))
+}
+
+// Color256 returns the foreground color of the 256-color palette, for
+// terminals supporting it.
+func Color256(n uint8) Color {
+	return Color("38;5;" + strconv.Itoa(int(n)))
+}
+
+// Background256 returns the background color of the 256-color palette, for
+// terminals supporting it.
+func Background256(n uint8) Color {
+	return Color("48;5;" + strconv.Itoa(int(n)))
+}
+
+// ColorScheme sets the colors of the colored output of the TextFormatter:
+//
+//    formatter.ColorScheme = &logrus.ColorScheme{
+//      Levels: map[logrus.Level]logrus.Color{
+//        logrus.ErrorLevel: logrus.Colors(logrus.ColorBold, logrus.ColorRed, logrus.BgBlack),
+//        logrus.InfoLevel:  logrus.RGBColor(0, 128, 128),
+//      },
+//      Fields: map[string]logrus.Color{"request_id": logrus.NoColor},
+//    }
+//
+// The colors left empty are the default ones.
+type ColorScheme struct {
+	// Levels maps levels to the color of their level text, and of the keys
+	// of the fields of their entries unless Key is set. The levels left out
+	// keep their default color: gray for trace and debug, cyan for info,
+	// yellow for warnings and red for errors.
+	Levels map[Level]Color
+
+	// Key is the color of the keys of the fields, defaults to the level
+	// color.
+	Key Color
+
+	// Timestamp is the color of the timestamp, defaults to NoColor.
+	Timestamp Color
+
+	// Fields maps the keys of fields to their color, overriding Key and the
+	// level color, e.g. NoColor to print some fields without colors.
+	Fields map[string]Color
+}
+
+// customLevelColor returns the color set for level, if any.
+func (s *ColorScheme) customLevelColor(level Level) (Color, bool) {
+	if s == nil || s.Levels[level] == "" {
+		return "", false
+	}
+	return s.Levels[level], true
+}
+
+// levelColor returns the color of the level text of the entries of level.
+func (s *ColorScheme) levelColor(level Level) Color {
+	if c, ok := s.customLevelColor(level); ok {
+		return c
+	}
+	switch level {
+	case TraceLevel:
+		return sgrColor(darkGray)
+	case DebugLevel:
+		return sgrColor(gray)
+	case WarnLevel:
+		return sgrColor(yellow)
+	case ErrorLevel, FatalLevel, PanicLevel:
+		return sgrColor(red)
+	}
+	return sgrColor(blue)
+}
+
+func sgrColor(code int) Color {
+	return Color(strconv.Itoa(code))
+}
+
+// appendColored writes text in color c, without escape sequences for
+// NoColor.
+func appendColored(b *bytes.Buffer, c Color, text string) {
+	if c == "" || c == NoColor {
+		b.WriteString(text)
+		return
+	}
+	b.WriteString("\x1b[")
+	b.WriteString(string(c))
+	b.WriteByte('m')
+	b.WriteString(text)
+	b.WriteString("\x1b[0m")
+}
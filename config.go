@@ -0,0 +1,467 @@
+package logrus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config describes a logger declaratively, to be loaded from a JSON or YAML
+// file with `ConfigFromFile`, from the environment with `ConfigFromEnv`, or
+// filled by the program and passed to `NewFromConfig`. In JSON:
+//
+//    {
+//      "level": "debug",
+//      "name": "billing",
+//      "formatter": {"type": "json"},
+//      "output": "/var/log/billing.log",
+//      "rotation": {"max_size": 104857600, "max_backups": 7, "compress": true},
+//      "outputs": [
+//        {"path": "stderr", "formatter": {"type": "text"}, "levels": ["error", "fatal", "panic"]}
+//      ],
+//      "hooks": [
+//        {"type": "gelf", "levels": ["error"], "options": {"network": "udp", "address": "graylog:12201"}}
+//      ]
+//    }
+//
+// The zero Config is the configuration of `New`.
+type Config struct {
+	// Level is the level of the logger, as parsed by ParseLevel, defaults to
+	// info.
+	Level string `json:"level" yaml:"level"`
+
+	// Name is the name of the logger, see `Logger.Name`.
+	Name string `json:"name" yaml:"name"`
+
+	// NamedLevels sets the levels of the named loggers of the program with
+	// `SetLevels`, e.g. "db=debug,http=warn".
+	NamedLevels string `json:"named_levels" yaml:"named_levels"`
+
+	// Formatter formats the entries written to Output.
+	Formatter FormatterConfig `json:"formatter" yaml:"formatter"`
+
+	// Output is where the entries are written: "stderr", the default,
+	// "stdout", "discard" or the path of a file.
+	Output string `json:"output" yaml:"output"`
+
+	// Rotation rotates the file of Output.
+	Rotation *RotationConfig `json:"rotation" yaml:"rotation"`
+
+	// Outputs are written to in addition to Output, see `Logger.AddOutput`.
+	Outputs []OutputConfig `json:"outputs" yaml:"outputs"`
+
+	// Hooks are added to the logger, see `RegisterConfigHook`.
+	Hooks []HookConfig `json:"hooks" yaml:"hooks"`
+
+	// ReportCaller reports the caller of the entries, see
+	// `Logger.SetReportCaller`.
+	ReportCaller bool `json:"report_caller" yaml:"report_caller"`
+
+	// ReportSequence numbers the entries, see `Logger.ReportSequence`.
+	ReportSequence bool `json:"report_sequence" yaml:"report_sequence"`
+
+	// Async is the size of the buffer of an asynchronous logger, see
+	// `Logger.SetAsync`. 0 keeps the logger synchronous.
+	Async int `json:"async" yaml:"async"`
+}
+
+// FormatterConfig describes a formatter.
+type FormatterConfig struct {
+	// Type is one of "text", the default, "json", "logfmt", "ecs" and "gelf".
+	Type string `json:"type" yaml:"type"`
+
+	// TimestampFormat sets the format of the timestamps of the text, json
+	// and logfmt formatters.
+	TimestampFormat string `json:"timestamp_format" yaml:"timestamp_format"`
+
+	// DisableTimestamp leaves out the timestamps of the text, json and
+	// logfmt formatters.
+	DisableTimestamp bool `json:"disable_timestamp" yaml:"disable_timestamp"`
+
+	// FullTimestamp and DisableColors configure the text formatter.
+	FullTimestamp bool `json:"full_timestamp" yaml:"full_timestamp"`
+	DisableColors bool `json:"disable_colors" yaml:"disable_colors"`
+
+	// PrettyPrint indents the entries of the json formatter.
+	PrettyPrint bool `json:"pretty_print" yaml:"pretty_print"`
+}
+
+// RotationConfig describes the rotation of a log file, see
+// `RotatingFileWriter`.
+type RotationConfig struct {
+	MaxSize int64 `json:"max_size" yaml:"max_size"`
+	// MaxAge is a duration as parsed by time.ParseDuration, e.g. "24h".
+	MaxAge     string `json:"max_age" yaml:"max_age"`
+	Daily      bool   `json:"daily" yaml:"daily"`
+	MaxBackups int    `json:"max_backups" yaml:"max_backups"`
+	Compress   bool   `json:"compress" yaml:"compress"`
+}
+
+// OutputConfig describes an additional output of a logger.
+type OutputConfig struct {
+	// Path is "stderr", "stdout", "discard" or the path of a file.
+	Path      string          `json:"path" yaml:"path"`
+	Formatter FormatterConfig `json:"formatter" yaml:"formatter"`
+	Rotation  *RotationConfig `json:"rotation" yaml:"rotation"`
+	// Levels are the levels written to the output, defaults to all of them.
+	Levels []string `json:"levels" yaml:"levels"`
+}
+
+// HookConfig describes a hook, built by the factory registered for its type
+// with `RegisterConfigHook`.
+type HookConfig struct {
+	Type string `json:"type" yaml:"type"`
+	// Levels are the levels the hook is fired for, defaults to the ones of
+	// the hook.
+	Levels  []string          `json:"levels" yaml:"levels"`
+	Options map[string]string `json:"options" yaml:"options"`
+}
+
+var (
+	configHooksMu sync.RWMutex
+	configHooks   = map[string]func(options map[string]string) (Hook, error){
+		"gelf": newConfigGELFHook,
+	}
+
+	configDecodersMu sync.RWMutex
+	configDecoders   = map[string]func(data []byte, v interface{}) error{
+		".json": json.Unmarshal,
+	}
+)
+
+// RegisterConfigDecoder registers the function decoding the config files of
+// `ConfigFromFile` with the extension ext, e.g. ".yaml", into a *Config. The
+// fields of Config have yaml tags, so that the Unmarshal function of the YAML
+// packages can be registered as is, logrus not depending on one:
+//
+//    logrus.RegisterConfigDecoder(".yaml", yaml.Unmarshal)
+//    logrus.RegisterConfigDecoder(".yml", yaml.Unmarshal)
+//
+// The files with other extensions than the registered ones are decoded as
+// JSON.
+func RegisterConfigDecoder(ext string, decode func(data []byte, v interface{}) error) {
+	configDecodersMu.Lock()
+	defer configDecodersMu.Unlock()
+	configDecoders[strings.ToLower(ext)] = decode
+}
+
+// RegisterConfigHook registers the factory building the hooks of the given
+// type out of the options of their `HookConfig`. The "gelf" type is
+// registered, with the "network", "address" and "compress" options.
+func RegisterConfigHook(hookType string, factory func(options map[string]string) (Hook, error)) {
+	configHooksMu.Lock()
+	defer configHooksMu.Unlock()
+	configHooks[hookType] = factory
+}
+
+func newConfigGELFHook(options map[string]string) (Hook, error) {
+	network := options["network"]
+	if network == "" {
+		network = "udp"
+	}
+	hook, err := NewGELFHook(network, options["address"])
+	if err != nil {
+		return nil, err
+	}
+	hook.Compress = options["compress"] == "true"
+	return hook, nil
+}
+
+// ConfigFromFile returns a logger configured by the file at path, see
+// `Config`. It is decoded by the decoder registered for its extension with
+// `RegisterConfigDecoder`, as JSON by default. YAML files need a YAML
+// decoder to be registered first, an error is returned otherwise.
+func ConfigFromFile(path string) (*Logger, error) {
+	config, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromConfig(config)
+}
+
+func readConfigFile(path string) (Config, error) {
+	var config Config
+	ext := strings.ToLower(filepath.Ext(path))
+	configDecodersMu.RLock()
+	decode, ok := configDecoders[ext]
+	configDecodersMu.RUnlock()
+	if !ok {
+		switch ext {
+		case ".yaml", ".yml":
+			return config, fmt.Errorf("Unsupported config file %s, no decoder registered for %s files, see RegisterConfigDecoder", path, ext)
+		}
+		decode = json.Unmarshal
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := decode(data, &config); err != nil {
+		return config, fmt.Errorf("Failed to parse config file %s, %v", path, err)
+	}
+	return config, nil
+}
+
+// ConfigFromEnv returns a logger configured by environment variables. The
+// file named by LOGRUS_CONFIG is loaded first, as with `ConfigFromFile`,
+// then overridden by the variables which are set:
+//
+//    LOGRUS_LEVEL           Config.Level
+//    LOGRUS_NAME            Config.Name
+//    LOGRUS_NAMED_LEVELS    Config.NamedLevels
+//    LOGRUS_FORMATTER       Config.Formatter.Type
+//    LOGRUS_OUTPUT          Config.Output
+//    LOGRUS_REPORT_CALLER   Config.ReportCaller, "true" or "false"
+//    LOGRUS_ASYNC           Config.Async
+func ConfigFromEnv() (*Logger, error) {
+	var config Config
+	if path := os.Getenv("LOGRUS_CONFIG"); path != "" {
+		var err error
+		if config, err = readConfigFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, value := range map[string]*string{
+		"LOGRUS_LEVEL":        &config.Level,
+		"LOGRUS_NAME":         &config.Name,
+		"LOGRUS_NAMED_LEVELS": &config.NamedLevels,
+		"LOGRUS_FORMATTER":    &config.Formatter.Type,
+		"LOGRUS_OUTPUT":       &config.Output,
+	} {
+		if v, ok := os.LookupEnv(name); ok {
+			*value = v
+		}
+	}
+	if v, ok := os.LookupEnv("LOGRUS_REPORT_CALLER"); ok {
+		reportCaller, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid LOGRUS_REPORT_CALLER, %v", err)
+		}
+		config.ReportCaller = reportCaller
+	}
+	if v, ok := os.LookupEnv("LOGRUS_ASYNC"); ok {
+		async, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid LOGRUS_ASYNC, %v", err)
+		}
+		config.Async = async
+	}
+	return NewFromConfig(config)
+}
+
+// NewFromConfig returns a logger configured by config. The files it writes
+// to are opened by the first entries, they aren't closed by the logger. The
+// hooks built are closed if the configuration turns out to be invalid.
+func NewFromConfig(config Config) (_ *Logger, err error) {
+	var hooks []Hook
+	defer func() {
+		if err != nil {
+			closeHooks(hooks)
+		}
+	}()
+
+	logger := New()
+	logger.Name = config.Name
+	logger.ReportSequence = config.ReportSequence
+	logger.SetReportCaller(config.ReportCaller)
+
+	if config.Level != "" {
+		level, err := ParseLevel(config.Level)
+		if err != nil {
+			return nil, err
+		}
+		logger.SetLevel(level)
+	}
+
+	formatter, err := config.Formatter.newFormatter()
+	if err != nil {
+		return nil, err
+	}
+	logger.Formatter = formatter
+	if logger.Out, err = newConfigOutput(config.Output, config.Rotation); err != nil {
+		return nil, err
+	}
+
+	for _, o := range config.Outputs {
+		formatter, err := o.Formatter.newFormatter()
+		if err != nil {
+			return nil, err
+		}
+		out, err := newConfigOutput(o.Path, o.Rotation)
+		if err != nil {
+			return nil, err
+		}
+		levels, err := parseLevels(o.Levels)
+		if err != nil {
+			return nil, err
+		}
+		logger.AddOutput(out, formatter, levels...)
+	}
+
+	for _, h := range config.Hooks {
+		configHooksMu.RLock()
+		factory, ok := configHooks[h.Type]
+		configHooksMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("Unknown hook type %q", h.Type)
+		}
+		levels, err := parseLevels(h.Levels)
+		if err != nil {
+			return nil, err
+		}
+		hook, err := factory(h.Options)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create %s hook, %v", h.Type, err)
+		}
+		if len(levels) > 0 {
+			hook = levelsHook{hook, levels}
+		}
+		hooks = append(hooks, hook)
+		logger.AddHook(hook)
+	}
+
+	// Only set once everything else was validated, SetLevels being global
+	if config.NamedLevels != "" {
+		if err := SetLevels(config.NamedLevels); err != nil {
+			return nil, err
+		}
+	}
+	if config.Async > 0 {
+		logger.SetAsync(config.Async, Block)
+	}
+	return logger, nil
+}
+
+func (c FormatterConfig) newFormatter() (Formatter, error) {
+	switch c.Type {
+	case "", "text":
+		return &TextFormatter{
+			TimestampFormat:  c.TimestampFormat,
+			DisableTimestamp: c.DisableTimestamp,
+			FullTimestamp:    c.FullTimestamp,
+			DisableColors:    c.DisableColors,
+		}, nil
+	case "json":
+		return &JSONFormatter{
+			TimestampFormat:  c.TimestampFormat,
+			DisableTimestamp: c.DisableTimestamp,
+			PrettyPrint:      c.PrettyPrint,
+		}, nil
+	case "logfmt":
+		return &LogfmtFormatter{
+			TimestampFormat:  c.TimestampFormat,
+			DisableTimestamp: c.DisableTimestamp,
+		}, nil
+	case "ecs":
+		return new(ECSFormatter), nil
+	case "gelf":
+		return new(GELFFormatter), nil
+	}
+	return nil, fmt.Errorf("Unknown formatter type %q", c.Type)
+}
+
+func newConfigOutput(path string, rotation *RotationConfig) (io.Writer, error) {
+	switch path {
+	case "", "stderr":
+		return os.Stderr, nil
+	case "stdout":
+		return os.Stdout, nil
+	case "discard":
+		return ioutil.Discard, nil
+	}
+	if rotation == nil {
+		return &ReopenableFileWriter{Filename: path}, nil
+	}
+
+	var maxAge time.Duration
+	if rotation.MaxAge != "" {
+		var err error
+		if maxAge, err = time.ParseDuration(rotation.MaxAge); err != nil {
+			return nil, fmt.Errorf("Invalid max_age of %s, %v", path, err)
+		}
+	}
+	return &RotatingFileWriter{
+		Filename:   path,
+		MaxSize:    rotation.MaxSize,
+		MaxAge:     maxAge,
+		Daily:      rotation.Daily,
+		MaxBackups: rotation.MaxBackups,
+		Compress:   rotation.Compress,
+	}, nil
+}
+
+func parseLevels(names []string) ([]Level, error) {
+	levels := make([]Level, 0, len(names))
+	for _, name := range names {
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// levelsHook restricts a hook to the levels of its HookConfig. It forwards
+// the optional interfaces of the hook, so that it is still fired with the
+// context of the entries, in batches, and flushed and closed.
+type levelsHook struct {
+	Hook
+	levels []Level
+}
+
+func (hook levelsHook) Levels() []Level {
+	return hook.levels
+}
+
+func (hook levelsHook) FireContext(ctx context.Context, entry *Entry) error {
+	if contextHook, ok := hook.Hook.(ContextHook); ok {
+		return contextHook.FireContext(ctx, entry)
+	}
+	return hook.Hook.Fire(entry)
+}
+
+func (hook levelsHook) FireBatch(entries []*Entry) error {
+	if batchHook, ok := hook.Hook.(BatchHook); ok {
+		return batchHook.FireBatch(entries)
+	}
+	for _, entry := range entries {
+		if err := fireHook(hook.Hook, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (hook levelsHook) Flush() {
+	if f, ok := hook.Hook.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+}
+
+func (hook levelsHook) Close() error {
+	if c, ok := hook.Hook.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// closeHooks closes the hooks implementing io.Closer, such as the ones
+// holding a connection.
+func closeHooks(hooks []Hook) {
+	for _, hook := range hooks {
+		if c, ok := hook.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
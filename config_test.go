@@ -0,0 +1,212 @@
+package logrus
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestConfigFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	hook := new(TestHook)
+	RegisterConfigHook("test", func(options map[string]string) (Hook, error) {
+		assert.Equal(t, map[string]string{"key": "value"}, options)
+		return hook, nil
+	})
+
+	logName := filepath.Join(dir, "app.log")
+	errName := filepath.Join(dir, "errors.log")
+	path := writeConfigFile(t, dir, "logrus.json", `{
+	  "level": "debug",
+	  "name": "billing",
+	  "formatter": {"type": "json", "disable_timestamp": true},
+	  "output": "`+filepath.ToSlash(logName)+`",
+	  "rotation": {"max_size": 1048576, "max_age": "24h", "max_backups": 3},
+	  "outputs": [
+	    {"path": "`+filepath.ToSlash(errName)+`", "formatter": {"type": "logfmt", "disable_timestamp": true}, "levels": ["error"]}
+	  ],
+	  "hooks": [{"type": "test", "levels": ["error"], "options": {"key": "value"}}]
+	}`)
+
+	logger, err := ConfigFromFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, DebugLevel, logger.level())
+	assert.Equal(t, "billing", logger.Name)
+	if out, ok := logger.Out.(*RotatingFileWriter); assert.True(t, ok) {
+		defer out.Close()
+		assert.Equal(t, int64(1048576), out.MaxSize)
+		assert.Equal(t, 3, out.MaxBackups)
+	}
+	defer logger.extraOutputs()[0].logger.Out.(*ReopenableFileWriter).Close()
+
+	logger.Debug("debug")
+	logger.Error("error")
+
+	assert.Equal(t, `{"level":"debug","msg":"debug"}`+"\n"+`{"level":"error","msg":"error"}`+"\n", readFile(t, logName))
+	assert.Equal(t, "level=error msg=error\n", readFile(t, errName))
+	assert.Equal(t, []Level{ErrorLevel}, logger.Hooks[ErrorLevel][0].Levels())
+	assert.Len(t, logger.Hooks[DebugLevel], 0)
+	assert.True(t, hook.Fired)
+}
+
+func TestConfigFromFileErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"logrus.yml":        "level: debug",
+		"malformed.json":    `{"level": `,
+		"level.json":        `{"level": "loud"}`,
+		"formatter.json":    `{"formatter": {"type": "xml"}}`,
+		"hook.json":         `{"hooks": [{"type": "unknown"}]}`,
+		"rotation.json":     `{"output": "app.log", "rotation": {"max_age": "daily"}}`,
+		"named.json":        `{"named_levels": "db"}`,
+		"output_level.json": `{"outputs": [{"levels": ["loud"]}]}`,
+	} {
+		_, err := ConfigFromFile(writeConfigFile(t, dir, name, content))
+		assert.Error(t, err, name)
+	}
+	_, err = ConfigFromFile(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestConfigDecoder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	configDecodersMu.Lock()
+	saved := make(map[string]func([]byte, interface{}) error, len(configDecoders))
+	for ext, decode := range configDecoders {
+		saved[ext] = decode
+	}
+	configDecodersMu.Unlock()
+	defer func() {
+		configDecodersMu.Lock()
+		configDecoders = saved
+		configDecodersMu.Unlock()
+	}()
+
+	// Decodes flat "key: value" documents, as a YAML package would
+	RegisterConfigDecoder(".YAML", func(data []byte, v interface{}) error {
+		fields := map[string]string{}
+		for _, line := range strings.Split(string(data), "\n") {
+			if kv := strings.SplitN(line, ":", 2); len(kv) == 2 {
+				fields[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+			}
+		}
+		b, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(b, v)
+	})
+
+	logger, err := ConfigFromFile(writeConfigFile(t, dir, "logrus.yaml", "level: debug\nname: billing\noutput: discard\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, DebugLevel, logger.level())
+	assert.Equal(t, "billing", logger.Name)
+
+	_, err = ConfigFromFile(writeConfigFile(t, dir, "bad.yaml", "level: loud"))
+	assert.Error(t, err)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := writeConfigFile(t, dir, "logrus.json", `{"level": "debug", "formatter": {"type": "json"}, "output": "stdout"}`)
+	env := map[string]string{
+		"LOGRUS_CONFIG":        path,
+		"LOGRUS_LEVEL":         "warn",
+		"LOGRUS_NAME":          "http",
+		"LOGRUS_REPORT_CALLER": "true",
+	}
+	for name, value := range env {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+
+	logger, err := ConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, WarnLevel, logger.level())
+	assert.Equal(t, "http", logger.Name)
+	assert.True(t, logger.ReportsCaller())
+	assert.Equal(t, os.Stdout, logger.Out)
+	_, ok := logger.Formatter.(*JSONFormatter)
+	assert.True(t, ok)
+
+	os.Setenv("LOGRUS_ASYNC", "many")
+	defer os.Unsetenv("LOGRUS_ASYNC")
+	_, err = ConfigFromEnv()
+	assert.Error(t, err)
+}
+
+func TestNewFromZeroConfig(t *testing.T) {
+	logger, err := NewFromConfig(Config{})
+	assert.NoError(t, err)
+	assert.Equal(t, InfoLevel, logger.level())
+	assert.Equal(t, os.Stderr, logger.Out)
+	_, ok := logger.Formatter.(*TextFormatter)
+	assert.True(t, ok)
+}
+
+// configuredHook records how it was used through the levels of its
+// HookConfig.
+type configuredHook struct {
+	ctx     context.Context
+	flushed bool
+	closed  bool
+}
+
+func (hook *configuredHook) Levels() []Level         { return AllLevels }
+func (hook *configuredHook) Fire(entry *Entry) error { return nil }
+func (hook *configuredHook) Flush()                  { hook.flushed = true }
+func (hook *configuredHook) Close() error            { hook.closed = true; return nil }
+func (hook *configuredHook) FireContext(ctx context.Context, entry *Entry) error {
+	hook.ctx = ctx
+	return nil
+}
+
+func TestConfigHookInterfaces(t *testing.T) {
+	var hook *configuredHook
+	RegisterConfigHook("configured", func(options map[string]string) (Hook, error) {
+		hook = new(configuredHook)
+		return hook, nil
+	})
+	defer func() {
+		configHooksMu.Lock()
+		delete(configHooks, "configured")
+		configHooksMu.Unlock()
+	}()
+
+	logger, err := NewFromConfig(Config{Hooks: []HookConfig{{Type: "configured", Levels: []string{"info"}}}})
+	assert.NoError(t, err)
+	logger.Out = ioutil.Discard
+	ctx := context.WithValue(context.Background(), contextKey("request_id"), "req-42")
+	logger.WithContext(ctx).Info("with context")
+	assert.Equal(t, ctx, hook.ctx)
+	logger.Flush()
+	assert.True(t, hook.flushed)
+
+	_, err = NewFromConfig(Config{Hooks: []HookConfig{{Type: "configured", Levels: []string{"info"}}, {Type: "unknown"}}})
+	assert.Error(t, err)
+	assert.True(t, hook.closed, "the hooks built must be closed on errors")
+}
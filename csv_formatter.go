@@ -0,0 +1,101 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+// Columns of the CSVFormatter standing for the runtime information printed
+// by the TextFormatter.
+const (
+	CSVColumnProcessID = "process ID"
+	CSVColumnThreadID  = "thread ID"
+	CSVColumnOS        = "OS"
+)
+
+// CSVFormatter formats logs into CSV records, one per entry, e.g. to import
+// them into a spreadsheet. Values containing commas, quotes or newlines are
+// quoted as described in RFC 4180.
+type CSVFormatter struct {
+	// Columns lists the keys of the values making up each record, in order.
+	// Besides the keys of the fields, it can contain FieldKeyTime,
+	// FieldKeyLevel, FieldKeyMsg, CSVColumnProcessID, CSVColumnThreadID and
+	// CSVColumnOS. Defaults to time, level and message. Fields missing from
+	// an entry are left empty, fields not listed are left out.
+	Columns []string
+
+	// Header writes a record with the names of the columns before the first
+	// entry.
+	Header bool
+
+	// TimestampFormat sets the format used for the time column.
+	TimestampFormat string
+
+	// Set once the header has been written.
+	headerWritten uint32
+}
+
+var defaultCSVColumns = []string{FieldKeyTime, FieldKeyLevel, FieldKeyMsg}
+
+// Format renders a single log entry
+func (f *CSVFormatter) Format(entry *Entry) ([]byte, error) {
+	columns := f.Columns
+	if len(columns) == 0 {
+		columns = defaultCSVColumns
+	}
+
+	b := &bytes.Buffer{}
+	w := csv.NewWriter(b)
+	if f.Header && atomic.CompareAndSwapUint32(&f.headerWritten, 0, 1) {
+		w.Write(columns)
+	}
+
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = f.value(entry, column)
+	}
+	w.Write(record)
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("Failed to write CSV record, %v", err)
+	}
+	return b.Bytes(), nil
+}
+
+// value returns the value of the column for entry, an empty string if the
+// entry has no such field.
+func (f *CSVFormatter) value(entry *Entry, column string) string {
+	switch column {
+	case FieldKeyTime:
+		timestampFormat := f.TimestampFormat
+		if timestampFormat == "" {
+			timestampFormat = defaultTimestampFormat
+		}
+		return entry.Time.Format(timestampFormat)
+	case FieldKeyLevel:
+		return entry.Level.String()
+	case FieldKeyMsg:
+		return entry.Message
+	case CSVColumnProcessID:
+		return strconv.Itoa(getpid())
+	case CSVColumnThreadID:
+		return strconv.Itoa(getCurrentThreadID())
+	case CSVColumnOS:
+		return detectOS()
+	}
+
+	switch value := entry.Data[column].(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case error:
+		return value.Error()
+	default:
+		return fmt.Sprint(value)
+	}
+}
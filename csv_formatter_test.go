@@ -0,0 +1,44 @@
+package logrus
+
+import (
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVFormatter(t *testing.T) {
+	formatter := &CSVFormatter{Columns: []string{FieldKeyLevel, FieldKeyMsg, "user", "error", "missing"}}
+
+	entry := WithFields(Fields{"user": `o"brien, pat`, "error": errors.New("line\nbreak"), "ignored": 1})
+	entry.Level = WarnLevel
+	entry.Message = "hello, world"
+	b, err := formatter.Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "warning,\"hello, world\",\"o\"\"brien, pat\",\"line\nbreak\",\n", string(b))
+
+	records, err := csv.NewReader(strings.NewReader(string(b))).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"warning", "hello, world", `o"brien, pat`, "line\nbreak", ""}}, records)
+}
+
+func TestCSVFormatterDefaultColumns(t *testing.T) {
+	formatter := &CSVFormatter{}
+
+	now := time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)
+	b, _ := formatter.Format(&Entry{Time: now, Level: InfoLevel, Message: "started", Data: Fields{"foo": "bar"}})
+	assert.Equal(t, "2018-03-04T05:06:07Z,info,started\n", string(b))
+}
+
+func TestCSVFormatterHeader(t *testing.T) {
+	formatter := &CSVFormatter{Columns: []string{FieldKeyMsg, CSVColumnOS}, Header: true}
+
+	b, _ := formatter.Format(&Entry{Message: "first", Data: Fields{}})
+	assert.Equal(t, "msg,OS\nfirst,"+detectOS()+"\n", string(b))
+
+	b, _ = formatter.Format(&Entry{Message: "second", Data: Fields{}})
+	assert.Equal(t, "second,"+detectOS()+"\n", string(b))
+}
@@ -0,0 +1,99 @@
+package logrus
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ECSVersion is the version of the Elastic Common Schema the ECSFormatter
+// follows.
+const ECSVersion = "1.6.0"
+
+// ecsReservedKeys are the top-level keys of the documents written by the
+// ECSFormatter, fields with these keys are prefixed with "fields.".
+var ecsReservedKeys = map[string]bool{
+	"@timestamp": true,
+	"message":    true,
+	"log":        true,
+	"process":    true,
+	"host":       true,
+	"ecs":        true,
+	"error":      true,
+}
+
+// ECSFormatter formats logs into JSON documents laid out as defined by the
+// Elastic Common Schema, see https://www.elastic.co/guide/en/ecs/current,
+// which Elasticsearch ingests without pipeline:
+//
+//    {"@timestamp":"2017-07-14T02:40:00.250Z","ecs":{"version":"1.6.0"},
+//     "log":{"level":"warning"},"message":"disk almost full",
+//     "process":{"pid":1234,"thread":{"id":1235}},"host":{"os":{"type":"linux"}},
+//     "disk":"/dev/sda1"}
+//
+// The caller, when reported, goes to log.origin and the error added with
+// WithError to error.message. The other fields are kept at the top level.
+type ECSFormatter struct {
+	// ReportHostname adds the hostname of the machine as host.hostname.
+	ReportHostname bool
+}
+
+// Format renders a single log entry
+func (f *ECSFormatter) Format(entry *Entry) ([]byte, error) {
+	data := make(map[string]interface{}, len(entry.Data)+7)
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok && k == ErrorKey {
+			data["error"] = map[string]interface{}{"message": err.Error()}
+			continue
+		}
+		if ecsReservedKeys[k] {
+			k = "fields." + k
+		}
+		switch v := v.(type) {
+		case error:
+			data[k] = v.Error()
+		default:
+			data[k] = v
+		}
+	}
+
+	logObject := map[string]interface{}{"level": entry.Level.String()}
+	if entry.HasCaller() {
+		logObject["origin"] = map[string]interface{}{
+			"file":     map[string]interface{}{"name": entry.Caller.File, "line": entry.Caller.Line},
+			"function": entry.Caller.Function,
+		}
+	}
+	host := map[string]interface{}{"os": map[string]interface{}{"type": ecsOSType(getOS())}}
+	if f.ReportHostname {
+		host["hostname"] = getHostname()
+	}
+
+	data["@timestamp"] = entry.Time.UTC().Format("2006-01-02T15:04:05.000Z07:00")
+	data["ecs"] = map[string]interface{}{"version": ECSVersion}
+	data["log"] = logObject
+	data["message"] = entry.Message
+	data["process"] = map[string]interface{}{
+		"pid":    getpid(),
+		"thread": map[string]interface{}{"id": getCurrentThreadID()},
+	}
+	data["host"] = host
+
+	serialized, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to marshal fields to JSON, %v", err)
+	}
+	return append(serialized, '\n'), nil
+}
+
+// ecsOSType returns the host.os.type of goos, one of the values allowed by
+// ECS.
+func ecsOSType(goos string) string {
+	switch goos {
+	case "linux", "windows", "android", "ios":
+		return goos
+	case "darwin":
+		return "macos"
+	default:
+		return "unix"
+	}
+}
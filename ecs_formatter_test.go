@@ -0,0 +1,66 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func formatECS(t *testing.T, formatter *ECSFormatter, entry *Entry) map[string]interface{} {
+	b, err := formatter.Format(entry)
+	assert.NoError(t, err)
+	document := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(b, &document))
+	return document
+}
+
+func TestECSFormatter(t *testing.T) {
+	defer SetRuntimeInfo(nil)
+	SetRuntimeInfo(stubRuntimeInfo{pid: 1234, tid: 1235, os: "darwin"})
+
+	entry := &Entry{
+		Time:    time.Unix(1500000000, 250000000),
+		Level:   WarnLevel,
+		Message: "disk almost full",
+		Data:    Fields{"disk": "/dev/sda1", "error": errors.New("ENOSPC"), "host": "clash"},
+	}
+	document := formatECS(t, new(ECSFormatter), entry)
+
+	assert.Equal(t, "2017-07-14T02:40:00.250Z", document["@timestamp"])
+	assert.Equal(t, map[string]interface{}{"version": ECSVersion}, document["ecs"])
+	assert.Equal(t, map[string]interface{}{"level": "warning"}, document["log"])
+	assert.Equal(t, "disk almost full", document["message"])
+	assert.Equal(t, map[string]interface{}{"pid": 1234.0, "thread": map[string]interface{}{"id": 1235.0}}, document["process"])
+	assert.Equal(t, map[string]interface{}{"os": map[string]interface{}{"type": "macos"}}, document["host"])
+	assert.Equal(t, map[string]interface{}{"message": "ENOSPC"}, document["error"])
+	assert.Equal(t, "/dev/sda1", document["disk"])
+	assert.Equal(t, "clash", document["fields.host"])
+}
+
+func TestECSFormatterCaller(t *testing.T) {
+	var buffer bytes.Buffer
+
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &ECSFormatter{ReportHostname: true}
+	logger.SetReportCaller(true)
+	logger.Info("hello")
+
+	document := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &document))
+	origin := document["log"].(map[string]interface{})["origin"].(map[string]interface{})
+	assert.Equal(t, "github.com/sirupsen/logrus.TestECSFormatterCaller", origin["function"])
+	assert.Contains(t, origin["file"].(map[string]interface{})["name"], "ecs_formatter_test.go")
+	assert.Equal(t, getHostname(), document["host"].(map[string]interface{})["hostname"])
+}
+
+func TestECSOSType(t *testing.T) {
+	assert.Equal(t, "linux", ecsOSType("linux"))
+	assert.Equal(t, "macos", ecsOSType("darwin"))
+	assert.Equal(t, "windows", ecsOSType("windows"))
+	assert.Equal(t, "unix", ecsOSType("freebsd"))
+}
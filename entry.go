@@ -2,25 +2,50 @@ package logrus
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var bufferPool *sync.Pool
 
+// loggedPool holds the copies of the entries made when they are logged, so
+// that they aren't allocated for every entry.
+var loggedPool *sync.Pool
+
+// now returns the current time, it is the time source of the entries and of
+// the timestamps relative to the start of the program. Tests replace it to
+// freeze the clock.
+var now = time.Now
+
 func init() {
 	bufferPool = &sync.Pool{
 		New: func() interface{} {
 			return new(bytes.Buffer)
 		},
 	}
+	loggedPool = &sync.Pool{
+		New: func() interface{} {
+			return new(Entry)
+		},
+	}
 }
 
 // Defines the key when adding errors using WithError.
 var ErrorKey = "error"
 
+// Defines the key when adding the sampling rate using WithSampleRate.
+var SampleRateKey = "sample_rate"
+
+// Defines the key of the sequence number of the entries of loggers with
+// ReportSequence set.
+var SequenceKey = "seq"
+
 // An entry is the final or intermediate Logrus logging entry. It contains all
 // the fields passed with WithField{,s}. It's finally logged when Debug, Info,
 // Warn, Error, Fatal or Panic is called on it. These objects can be reused and
@@ -31,7 +56,8 @@ type Entry struct {
 	// Contains all the fields set by the user.
 	Data Fields
 
-	// Time at which the log entry was created
+	// Time at which the log entry was created, set when it is logged unless
+	// it was set before
 	Time time.Time
 
 	// Level the log entry was logged at: Debug, Info, Warn, Error, Fatal or Panic
@@ -41,8 +67,24 @@ type Entry struct {
 	// Message passed to Debug, Info, Warn, Error, Fatal or Panic
 	Message string
 
+	// Caller is the frame logging the entry, set when the logger reports the
+	// caller, see `SetReportCaller`, unless it was set before.
+	Caller *runtime.Frame
+
 	// When formatter is called in entry.log(), an Buffer may be set to entry
 	Buffer *bytes.Buffer
+
+	// Context set with WithContext, from which the logger's ContextExtractor
+	// extracts fields when the entry is logged
+	Context context.Context
+
+	// Set by Drop, e.g. by hooks sampling entries, so that the entry isn't
+	// formatted nor written
+	dropped bool
+
+	// ID of the entry, generated when it is written if a formatter of the
+	// logger reports it, see `TextFormatter.ReportEntryID`
+	id string
 }
 
 func NewEntry(logger *Logger) *Entry {
@@ -64,37 +106,184 @@ func (entry *Entry) String() (string, error) {
 	return str, nil
 }
 
-// Add an error as single field (using the key defined in ErrorKey) to the Entry.
+// Add an error as single field (using the key defined in ErrorKey) to the Entry,
+// along with its causes and stack trace if the logger reports them, see
+// `Logger.ErrorCauses` and `Logger.ErrorStackTrace`.
 func (entry *Entry) WithError(err error) *Entry {
-	return entry.WithField(ErrorKey, err)
+	derived := entry.WithField(ErrorKey, err)
+	MergeFields(derived.Data, errorDetails(entry.Logger, err))
+	return derived
+}
+
+// Add the sampling rate N of an entry kept by a 1 in N sampler as single field
+// (using the key defined in SampleRateKey), so that aggregations downstream
+// can multiply counts back.
+func (entry *Entry) WithSampleRate(rate int) *Entry {
+	return entry.WithField(SampleRateKey, rate)
 }
 
 // Add a single field to the Entry.
 func (entry *Entry) WithField(key string, value interface{}) *Entry {
-	return entry.WithFields(Fields{key: value})
+	derived := entry.derive(len(entry.Data) + 1)
+	derived.Data[key] = value
+	return derived
 }
 
-// Add a map of fields to the Entry.
+// Add a map of fields to the Entry. The fields are added to a copy of the
+// entry's data, sized for both, so that neither the entry nor other entries
+// derived from it are modified.
 func (entry *Entry) WithFields(fields Fields) *Entry {
+	derived := entry.derive(len(entry.Data) + len(fields))
+	MergeFields(derived.Data, fields)
+	return derived
+}
+
+// Add a context to the Entry, the fields extracted from it by the logger's
+// ContextExtractor are added when the entry is logged.
+func (entry *Entry) WithContext(ctx context.Context) *Entry {
+	derived := entry.derive(len(entry.Data))
+	derived.Context = ctx
+	return derived
+}
+
+// derive returns a new entry of the same logger holding a copy of the data
+// of the entry, with room for size fields.
+func (entry *Entry) derive(size int) *Entry {
+	derived := newPooledEntry(entry.Logger, size)
+	MergeFields(derived.Data, entry.Data)
+	derived.Context = entry.Context
+	derived.dropped = entry.dropped
+	return derived
+}
+
+// Release returns the entry to the pool of its logger, so that the next
+// entry created by WithField, WithFields, WithContext or WithError reuses it
+// and its data instead of allocating them:
+//
+//    entry := logger.WithFields(logrus.Fields{"request": id, "user": user})
+//    entry.Info("handled")
+//    entry.Release()
+//
+// The entry must not be used once released, nor its data. Hooks are fired
+// with a copy of the data, so the entries they keep, such as the ones of the
+// test package, aren't affected. Releasing an entry is optional, entries not
+// released are garbage collected as usual.
+func (entry *Entry) Release() {
+	if entry.Logger != nil {
+		entry.Logger.releaseEntry(entry)
+	}
+}
+
+// Dup returns a copy of the entry with its own copy of the data, so that
+// goroutines sharing an entry can each add fields to their copy safely.
+func (entry *Entry) Dup() *Entry {
+	data := make(Fields, len(entry.Data))
+	MergeFields(data, entry.Data)
+	return &Entry{Logger: entry.Logger, Data: data, Time: entry.Time, Level: entry.Level, Message: entry.Message, Caller: entry.Caller, Context: entry.Context, dropped: entry.dropped, id: entry.id}
+}
+
+// HasCaller reports whether the caller of the entry is known, see
+// `SetReportCaller`.
+func (entry *Entry) HasCaller() bool {
+	return entry.Caller != nil
+}
+
+// Drop marks the entry as dropped: it is neither formatted nor written when
+// logged, e.g. after a sampling decision made at runtime. Hooks can call it
+// on the entry they are fired with. It returns the entry for chaining:
+//
+//    logger.WithField("request", id).Drop().Info("not logged")
+func (entry *Entry) Drop() *Entry {
+	entry.dropped = true
+	return entry
+}
+
+// context returns the context of the entry, context.Background() if it has
+// none.
+func (entry *Entry) context() context.Context {
+	if entry.Context == nil {
+		return context.Background()
+	}
+	return entry.Context
+}
+
+// addContextFields adds the fields extracted from the context of the entry,
+// fields set explicitly take precedence. Data is copied rather than modified
+// since it may be shared with other entries.
+func (entry *Entry) addContextFields() {
+	extract := entry.Logger.ContextExtractor
+	if entry.Context == nil || extract == nil {
+		return
+	}
+	fields := extract(entry.Context)
+	if len(fields) == 0 {
+		return
+	}
 	data := make(Fields, len(entry.Data)+len(fields))
-	for k, v := range entry.Data {
+	for k, v := range fields {
 		data[k] = v
 	}
-	for k, v := range fields {
+	for k, v := range entry.Data {
 		data[k] = v
 	}
-	return &Entry{Logger: entry.Logger, Data: data}
+	entry.Data = data
+}
+
+// sprint is fmt.Sprint, returning a single string argument, the most common
+// case, as is rather than copying it.
+func sprint(args ...interface{}) string {
+	if len(args) == 1 {
+		if s, ok := args[0].(string); ok {
+			return s
+		}
+	}
+	return fmt.Sprint(args...)
 }
 
 // This function is not declared with a pointer value because otherwise
 // race conditions will occur when using multiple goroutines
 func (entry Entry) log(level Level, msg string) {
+	if entry.dropped || entry.Logger.IsPaused() || isQuiet(entry.Logger.Name) {
+		return
+	}
+
+	// The copy escapes to the hooks and formatters, taking it from a pool
+	// saves allocating it. It can only be reused when no hook, which may
+	// keep it, was fired with it.
+	logged := loggedPool.Get().(*Entry)
+	*logged = entry
+	if logged.logCopy(level, msg) {
+		*logged = Entry{}
+		loggedPool.Put(logged)
+	}
+}
+
+// logCopy logs the copy of an entry made by log, it reports whether the
+// copy can be reused.
+func (entry *Entry) logCopy(level Level, msg string) bool {
 	var buffer *bytes.Buffer
-	entry.Time = time.Now()
+	// Entries bridged from other loggers keep their time and caller
+	if entry.Time.IsZero() {
+		entry.Time = now()
+	}
 	entry.Level = level
 	entry.Message = msg
+	if sampler := entry.Logger.sampler(); sampler != nil && level > FatalLevel && !sampler.Sample(entry) {
+		return true
+	}
+	if entry.Logger.ReportsCaller() && entry.Caller == nil {
+		entry.Caller = getCaller(entry.Logger.CallerSkip)
+	}
 
-	entry.fireHooks()
+	entry.addContextFields()
+	if redactor := entry.Logger.getRedactor(); redactor != nil {
+		entry.Data = redactor.redact(entry.Data)
+		entry.Message = redactor.redactText(entry.Message)
+	}
+	hooked := entry.fireHooks()
+	if entry.dropped {
+		return !hooked
+	}
 
 	buffer = bufferPool.Get().(*bytes.Buffer)
 	buffer.Reset()
@@ -105,42 +294,126 @@ func (entry Entry) log(level Level, msg string) {
 
 	entry.Buffer = nil
 
+	// The program exits or panics next, queued entries must be written first
+	if level <= FatalLevel {
+		entry.Logger.Flush()
+	}
+
 	// To avoid Entry#log() returning a value that only would make sense for
 	// panic() to use in Entry#Panic(), we avoid the allocation by checking
 	// directly here.
 	if level <= PanicLevel {
-		panic(&entry)
+		panic(entry)
 	}
+	return !hooked
+}
+
+// This function is only called on the copy of the entry made by log, so that
+// hooks dropping the entry don't race with other goroutines using it. It
+// reports whether hooks were fired.
+func (entry *Entry) fireHooks() bool {
+	var hooked bool
+	errs := func() []error {
+		entry.Logger.mu.Lock()
+		defer entry.Logger.mu.Unlock()
+		hooked = len(entry.Logger.Hooks[entry.Level]) > 0
+		if !hooked {
+			return nil
+		}
+		// Hooks may keep the entry, its data must not be the one of the
+		// entry logged, which may be released and reused
+		data := make(Fields, len(entry.Data))
+		MergeFields(data, entry.Data)
+		entry.Data = data
+		return entry.Logger.Hooks.fireAll(entry.Level, entry)
+	}()
+
+	// Handled without holding the lock, so that handlers can log
+	for _, err := range errs {
+		entry.Logger.handleHookError(err, entry)
+	}
+	return hooked
 }
 
-// This function is not declared with a pointer value because otherwise
-// race conditions will occur when using multiple goroutines
-func (entry Entry) fireHooks() {
-	entry.Logger.mu.Lock()
-	defer entry.Logger.mu.Unlock()
-	err := entry.Logger.Hooks.Fire(entry.Level, &entry)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+func (entry *Entry) write() {
+	// The program exits or panics next, fatal and panic entries are kept
+	if limiter := entry.Logger.rateLimiter(); limiter != nil && entry.Level > FatalLevel {
+		allowed, dropped := limiter.allow()
+		if !allowed {
+			return
+		}
+		if dropped > 0 {
+			entry.Logger.writeDropped(dropped, entry.Time)
+		}
 	}
+	if entry.Logger.ReportSequence {
+		data := make(Fields, len(entry.Data)+1)
+		MergeFields(data, entry.Data)
+		data[SequenceKey] = atomic.AddUint64(&entry.Logger.core().sequence, 1)
+		entry.Data = data
+	}
+	if entry.Logger.reportsEntryID() {
+		entry.id = newEntryID()
+	}
+	entry.output()
 }
 
-func (entry *Entry) write() {
-	serialized, err := entry.Logger.Formatter.Format(entry)
-	entry.Logger.mu.Lock()
-	defer entry.Logger.mu.Unlock()
+// output formats the entry and writes it to the output of its level, and
+// to the additional outputs of the logger enabled for its level.
+func (entry *Entry) output() {
+	logger := entry.Logger
+	entry.writeTo(logger, logger.levelOutput(entry.Level), logger.Formatter)
+
+	for _, o := range logger.extraOutputs() {
+		if o.levels != nil && !o.levels[entry.Level] {
+			continue
+		}
+		formatter := o.formatter
+		if formatter == nil {
+			formatter = logger.Formatter
+		}
+		copied := *entry
+		copied.Logger = o.logger
+		// The buffer of the entry is reused, its content is written to the
+		// output of the logger already
+		if copied.Buffer != nil {
+			copied.Buffer.Reset()
+		}
+		copied.writeTo(logger, o.logger.Out, formatter)
+	}
+}
+
+// writeTo formats the entry with formatter and writes it to out on behalf
+// of logger, through its queue if it is asynchronous. The writes of named
+// loggers are serialized with the ones of the logger they are derived from.
+func (entry *Entry) writeTo(logger *Logger, out io.Writer, formatter Formatter) {
+	serialized, err := formatter.Format(entry)
+	core := logger.core()
+	core.mu.Lock()
+	defer core.mu.Unlock()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to obtain reader, %v\n", err)
+		return
+	}
+	if async := core.asyncWriter(); async != nil {
+		_, err = async.writeTo(out, serialized)
 	} else {
-		_, err = entry.Logger.Out.Write(serialized)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
-		}
+		_, err = out.Write(serialized)
+	}
+	if err != nil {
+		logger.handleWriteError(serialized, err)
+	}
+}
+
+func (entry *Entry) Trace(args ...interface{}) {
+	if entry.Logger.IsLevelEnabled(TraceLevel) {
+		entry.log(TraceLevel, sprint(args...))
 	}
 }
 
 func (entry *Entry) Debug(args ...interface{}) {
-	if entry.Logger.level() >= DebugLevel {
-		entry.log(DebugLevel, fmt.Sprint(args...))
+	if entry.Logger.IsLevelEnabled(DebugLevel) {
+		entry.log(DebugLevel, sprint(args...))
 	}
 }
 
@@ -149,14 +422,14 @@ func (entry *Entry) Print(args ...interface{}) {
 }
 
 func (entry *Entry) Info(args ...interface{}) {
-	if entry.Logger.level() >= InfoLevel {
-		entry.log(InfoLevel, fmt.Sprint(args...))
+	if entry.Logger.IsLevelEnabled(InfoLevel) {
+		entry.log(InfoLevel, sprint(args...))
 	}
 }
 
 func (entry *Entry) Warn(args ...interface{}) {
-	if entry.Logger.level() >= WarnLevel {
-		entry.log(WarnLevel, fmt.Sprint(args...))
+	if entry.Logger.IsLevelEnabled(WarnLevel) {
+		entry.log(WarnLevel, sprint(args...))
 	}
 }
 
@@ -165,35 +438,41 @@ func (entry *Entry) Warning(args ...interface{}) {
 }
 
 func (entry *Entry) Error(args ...interface{}) {
-	if entry.Logger.level() >= ErrorLevel {
-		entry.log(ErrorLevel, fmt.Sprint(args...))
+	if entry.Logger.IsLevelEnabled(ErrorLevel) {
+		entry.log(ErrorLevel, sprint(args...))
 	}
 }
 
 func (entry *Entry) Fatal(args ...interface{}) {
-	if entry.Logger.level() >= FatalLevel {
-		entry.log(FatalLevel, fmt.Sprint(args...))
+	if entry.Logger.IsLevelEnabled(FatalLevel) {
+		entry.log(FatalLevel, sprint(args...))
 	}
-	Exit(1)
+	entry.Logger.Exit(1)
 }
 
 func (entry *Entry) Panic(args ...interface{}) {
-	if entry.Logger.level() >= PanicLevel {
-		entry.log(PanicLevel, fmt.Sprint(args...))
+	if entry.Logger.IsLevelEnabled(PanicLevel) {
+		entry.log(PanicLevel, sprint(args...))
 	}
-	panic(fmt.Sprint(args...))
+	panic(sprint(args...))
 }
 
 // Entry Printf family functions
 
+func (entry *Entry) Tracef(format string, args ...interface{}) {
+	if entry.Logger.IsLevelEnabled(TraceLevel) {
+		entry.Trace(fmt.Sprintf(format, args...))
+	}
+}
+
 func (entry *Entry) Debugf(format string, args ...interface{}) {
-	if entry.Logger.level() >= DebugLevel {
+	if entry.Logger.IsLevelEnabled(DebugLevel) {
 		entry.Debug(fmt.Sprintf(format, args...))
 	}
 }
 
 func (entry *Entry) Infof(format string, args ...interface{}) {
-	if entry.Logger.level() >= InfoLevel {
+	if entry.Logger.IsLevelEnabled(InfoLevel) {
 		entry.Info(fmt.Sprintf(format, args...))
 	}
 }
@@ -203,7 +482,7 @@ func (entry *Entry) Printf(format string, args ...interface{}) {
 }
 
 func (entry *Entry) Warnf(format string, args ...interface{}) {
-	if entry.Logger.level() >= WarnLevel {
+	if entry.Logger.IsLevelEnabled(WarnLevel) {
 		entry.Warn(fmt.Sprintf(format, args...))
 	}
 }
@@ -213,34 +492,40 @@ func (entry *Entry) Warningf(format string, args ...interface{}) {
 }
 
 func (entry *Entry) Errorf(format string, args ...interface{}) {
-	if entry.Logger.level() >= ErrorLevel {
+	if entry.Logger.IsLevelEnabled(ErrorLevel) {
 		entry.Error(fmt.Sprintf(format, args...))
 	}
 }
 
 func (entry *Entry) Fatalf(format string, args ...interface{}) {
-	if entry.Logger.level() >= FatalLevel {
-		entry.Fatal(fmt.Sprintf(format, args...))
+	if entry.Logger.IsLevelEnabled(FatalLevel) {
+		entry.log(FatalLevel, fmt.Sprintf(format, args...))
 	}
-	Exit(1)
+	entry.Logger.Exit(1)
 }
 
 func (entry *Entry) Panicf(format string, args ...interface{}) {
-	if entry.Logger.level() >= PanicLevel {
+	if entry.Logger.IsLevelEnabled(PanicLevel) {
 		entry.Panic(fmt.Sprintf(format, args...))
 	}
 }
 
 // Entry Println family functions
 
+func (entry *Entry) Traceln(args ...interface{}) {
+	if entry.Logger.IsLevelEnabled(TraceLevel) {
+		entry.Trace(entry.sprintlnn(args...))
+	}
+}
+
 func (entry *Entry) Debugln(args ...interface{}) {
-	if entry.Logger.level() >= DebugLevel {
+	if entry.Logger.IsLevelEnabled(DebugLevel) {
 		entry.Debug(entry.sprintlnn(args...))
 	}
 }
 
 func (entry *Entry) Infoln(args ...interface{}) {
-	if entry.Logger.level() >= InfoLevel {
+	if entry.Logger.IsLevelEnabled(InfoLevel) {
 		entry.Info(entry.sprintlnn(args...))
 	}
 }
@@ -250,7 +535,7 @@ func (entry *Entry) Println(args ...interface{}) {
 }
 
 func (entry *Entry) Warnln(args ...interface{}) {
-	if entry.Logger.level() >= WarnLevel {
+	if entry.Logger.IsLevelEnabled(WarnLevel) {
 		entry.Warn(entry.sprintlnn(args...))
 	}
 }
@@ -260,20 +545,20 @@ func (entry *Entry) Warningln(args ...interface{}) {
 }
 
 func (entry *Entry) Errorln(args ...interface{}) {
-	if entry.Logger.level() >= ErrorLevel {
+	if entry.Logger.IsLevelEnabled(ErrorLevel) {
 		entry.Error(entry.sprintlnn(args...))
 	}
 }
 
 func (entry *Entry) Fatalln(args ...interface{}) {
-	if entry.Logger.level() >= FatalLevel {
-		entry.Fatal(entry.sprintlnn(args...))
+	if entry.Logger.IsLevelEnabled(FatalLevel) {
+		entry.log(FatalLevel, entry.sprintlnn(args...))
 	}
-	Exit(1)
+	entry.Logger.Exit(1)
 }
 
 func (entry *Entry) Panicln(args ...interface{}) {
-	if entry.Logger.level() >= PanicLevel {
+	if entry.Logger.IsLevelEnabled(PanicLevel) {
 		entry.Panic(entry.sprintlnn(args...))
 	}
 }
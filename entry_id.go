@@ -0,0 +1,82 @@
+package logrus
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// entryIDSource buffers the random bytes of the entry IDs, so that reading
+// them doesn't take a system call per entry.
+type entryIDSource struct {
+	mu     sync.Mutex
+	reader *bufio.Reader
+}
+
+var (
+	entryIDs = entryIDSource{reader: bufio.NewReaderSize(rand.Reader, 512)}
+
+	// Counter of the fallback entry IDs, used when random bytes can't be
+	// read.
+	entryIDCounter uint64
+)
+
+// newEntryID returns a random ID of 16 hexadecimal characters. If the
+// system's random source fails, it falls back to an ID made of the current
+// time and a counter, which is still unique within the process.
+func newEntryID() string {
+	id, err := randomID()
+	if err != nil {
+		return fmt.Sprintf("%08x%08x", uint32(now().Unix()), uint32(atomic.AddUint64(&entryIDCounter, 1)))
+	}
+	return hex.EncodeToString(id[:])
+}
+
+// randomID returns 8 random bytes read from the buffered system's random
+// source.
+func randomID() ([8]byte, error) {
+	var id [8]byte
+	entryIDs.mu.Lock()
+	defer entryIDs.mu.Unlock()
+	_, err := io.ReadFull(entryIDs.reader, id[:])
+	return id, err
+}
+
+// entryIDReporter is implemented by the formatters printing the IDs of the
+// entries, see `TextFormatter.ReportEntryID`, so that the ID of an entry is
+// generated once when it is written, and is the same in all the outputs.
+type entryIDReporter interface {
+	reportsEntryID() bool
+}
+
+func reportsEntryID(formatter Formatter) bool {
+	reporter, ok := formatter.(entryIDReporter)
+	return ok && reporter.reportsEntryID()
+}
+
+// reportsEntryID reports whether a formatter of the logger prints the IDs of
+// the entries.
+func (logger *Logger) reportsEntryID() bool {
+	if reportsEntryID(logger.Formatter) {
+		return true
+	}
+	for _, o := range logger.extraOutputs() {
+		if reportsEntryID(o.formatter) {
+			return true
+		}
+	}
+	return false
+}
+
+// entryID returns the ID generated when the entry was written, or a new one
+// for entries formatted without being logged.
+func (entry *Entry) entryID() string {
+	if entry.id != "" {
+		return entry.id
+	}
+	return newEntryID()
+}
@@ -0,0 +1,86 @@
+package logrus
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("no entropy")
+}
+
+func TestEntryIDsAreUnique(t *testing.T) {
+	const goroutines, perGoroutine = 8, 1000
+
+	var mu sync.Mutex
+	seen := make(map[string]bool, goroutines*perGoroutine)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				id := newEntryID()
+				mu.Lock()
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, goroutines*perGoroutine, len(seen), "entry IDs must not repeat")
+	for id := range seen {
+		assert.Len(t, id, 16)
+		break
+	}
+}
+
+func TestEntryIDFallback(t *testing.T) {
+	saved := entryIDs.reader
+	entryIDs.reader = bufio.NewReader(failingReader{})
+	defer func() { entryIDs.reader = saved }()
+
+	first, second := newEntryID(), newEntryID()
+	assert.Len(t, first, 16)
+	assert.NotEqual(t, first, second)
+}
+
+func TestReportEntryID(t *testing.T) {
+	tf := &TextFormatter{ForceColors: true, ReportEntryID: true}
+	entry := WithField("a", 1)
+
+	first, _ := tf.Format(entry)
+	assert.True(t, regexp.MustCompile("entry_id\x1b\\[0m=[0-9a-f]{16}").Match(first), string(first))
+	assert.Equal(t, 1, len(entry.Data), "entry data must not be modified")
+
+	tf = &TextFormatter{DisableColors: true, ReportEntryID: true, FieldMap: FieldMap{FieldKeyEntryID: "id"}}
+	b, _ := tf.Format(&Entry{Data: Fields{}, Message: "hello"})
+	assert.True(t, regexp.MustCompile(" [0-9a-f]{16} hello").Match(b), string(b))
+}
+
+func TestReportEntryIDAcrossOutputs(t *testing.T) {
+	var first, second bytes.Buffer
+	formatter := &TextFormatter{DisableColors: true, DisableTimestamp: true, ReportEntryID: true}
+	logger := NewTeeLogger(
+		TeeOutput{Out: &first, Formatter: &JSONFormatter{}},
+		TeeOutput{Out: &second, Formatter: formatter},
+		TeeOutput{Out: &second, Formatter: &TransformFormatter{Next: formatter}},
+	)
+
+	logger.Info("one")
+	logger.Info("two")
+	ids := regexp.MustCompile("[0-9a-f]{16}").FindAllString(second.String(), -1)
+	assert.Len(t, ids, 4)
+	assert.Equal(t, ids[0], ids[1], "an entry has the same ID in all the outputs")
+	assert.Equal(t, ids[2], ids[3])
+	assert.NotEqual(t, ids[0], ids[2])
+}
@@ -2,7 +2,12 @@ package logrus
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -32,6 +37,58 @@ func TestEntryWithError(t *testing.T) {
 
 }
 
+func TestEntryWithSampleRate(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = new(JSONFormatter)
+
+	for i := 0; i < 9; i++ {
+		if i%3 == 0 {
+			logger.WithField("i", i).WithSampleRate(3).Info("kept")
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	assert.Equal(t, 3, len(lines))
+	for _, line := range lines {
+		var fields Fields
+		assert.Nil(t, json.Unmarshal([]byte(line), &fields))
+		assert.Equal(t, float64(3), fields["sample_rate"])
+	}
+}
+
+type contextKey string
+
+func TestEntryWithContext(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &TextFormatter{DisableColors: true, DisableTimestamp: true}
+	logger.ContextExtractor = func(ctx context.Context) Fields {
+		fields := Fields{}
+		for _, key := range []contextKey{"request_id", "tenant"} {
+			if v := ctx.Value(key); v != nil {
+				fields[string(key)] = v
+			}
+		}
+		return fields
+	}
+
+	ctx := context.WithValue(context.Background(), contextKey("request_id"), "req-42")
+	ctx = context.WithValue(ctx, contextKey("tenant"), "acme")
+
+	entry := logger.WithContext(ctx).WithField("tenant", "explicit")
+	entry.Info("handled")
+	assert.Contains(t, buffer.String(), " req-42 explicit handled")
+	assert.NotContains(t, buffer.String(), "acme")
+	assert.Equal(t, Fields{"tenant": "explicit"}, entry.Data, "entry data must not be modified")
+
+	buffer.Reset()
+	logger.WithContext(nil).WithField("foo", "bar").Info("no context")
+	assert.Contains(t, buffer.String(), " bar no context")
+}
+
 func TestEntryPanicln(t *testing.T) {
 	errBoom := fmt.Errorf("boom time")
 
@@ -113,3 +170,191 @@ func TestEntryHooksPanic(t *testing.T) {
 	entry := NewEntry(logger)
 	entry.Info(badMessage)
 }
+
+type countingFormatter struct {
+	calls int
+}
+
+func (f *countingFormatter) Format(entry *Entry) ([]byte, error) {
+	f.calls++
+	return []byte(entry.Message + "\n"), nil
+}
+
+type dropHook struct {
+	field string
+}
+
+func (hook *dropHook) Levels() []Level {
+	return AllLevels
+}
+
+func (hook *dropHook) Fire(entry *Entry) error {
+	if _, ok := entry.Data[hook.field]; ok {
+		entry.Drop()
+	}
+	return nil
+}
+
+func TestEntryDrop(t *testing.T) {
+	var buffer bytes.Buffer
+	formatter := &countingFormatter{}
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = formatter
+	logger.AddHook(&dropHook{field: "noisy"})
+
+	logger.WithField("foo", "bar").Drop().Info("dropped")
+	logger.WithField("foo", "bar").Drop().WithField("baz", 1).Info("dropped after chaining")
+	logger.WithField("noisy", true).Info("dropped by hook")
+	assert.Equal(t, "", buffer.String())
+	assert.Equal(t, 0, formatter.calls)
+
+	logger.WithField("foo", "bar").Info("kept")
+	assert.Equal(t, "kept\n", buffer.String())
+	assert.Equal(t, 1, formatter.calls)
+}
+
+func TestLoggerPaused(t *testing.T) {
+	var buffer bytes.Buffer
+	formatter := &countingFormatter{}
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = formatter
+
+	logger.SetPaused(true)
+	assert.True(t, logger.IsPaused())
+	logger.Error("paused")
+	logger.WithField("foo", "bar").Warn("paused")
+	assert.Equal(t, "", buffer.String())
+	assert.Equal(t, 0, formatter.calls)
+
+	logger.SetPaused(false)
+	assert.False(t, logger.IsPaused())
+	logger.Info("resumed")
+	assert.Equal(t, "resumed\n", buffer.String())
+}
+
+func TestMergeFields(t *testing.T) {
+	dst := Fields{"a": 1, "b": 2}
+	MergeFields(dst, Fields{"b": 3, "c": 4})
+	assert.Equal(t, Fields{"a": 1, "b": 3, "c": 4}, dst)
+
+	MergeFields(dst, nil)
+	assert.Equal(t, Fields{"a": 1, "b": 3, "c": 4}, dst)
+}
+
+func TestEntryWithFieldsIsolation(t *testing.T) {
+	parent := New().WithFields(Fields{"parent": 1})
+	first := parent.WithField("child", "first")
+	second := parent.WithFields(Fields{"child": "second", "parent": 2})
+	first.WithField("grandchild", true)
+
+	assert.Equal(t, Fields{"parent": 1}, parent.Data)
+	assert.Equal(t, Fields{"parent": 1, "child": "first"}, first.Data)
+	assert.Equal(t, Fields{"parent": 2, "child": "second"}, second.Data)
+}
+
+func TestEntryWithFieldsPresized(t *testing.T) {
+	parent := New().WithFields(largeFields)
+	fields := Fields{"extra1": 1, "extra2": 2, "extra3": 3}
+
+	// Growing the data from empty allocates new buckets several times
+	grown := testing.AllocsPerRun(100, func() {
+		data := Fields{}
+		MergeFields(data, parent.Data)
+		MergeFields(data, fields)
+		entrySink = &Entry{Data: data}
+	})
+	presized := testing.AllocsPerRun(100, func() {
+		entrySink = parent.WithFields(fields)
+	})
+	assert.True(t, presized < grown, "got %v allocations, %v growing the data", presized, grown)
+}
+
+// entrySink keeps the entries created in the allocation tests on the heap.
+var entrySink *Entry
+
+func TestEntryRelease(t *testing.T) {
+	logger := New()
+	entry := logger.WithFields(Fields{"released": true}).WithContext(context.Background())
+	entry.Message = "released"
+	entry.Release()
+
+	reused := logger.WithField("fresh", 1)
+	assert.Equal(t, Fields{"fresh": 1}, reused.Data)
+	assert.Equal(t, logger, reused.Logger)
+	assert.Nil(t, reused.Context)
+	assert.Equal(t, "", reused.Message)
+
+	derived := reused.WithField("derived", 2)
+	reused.Release()
+	assert.Equal(t, Fields{"fresh": 1, "derived": 2}, derived.Data, "derived entries own their data")
+
+	fields := Fields{"one": 1, "two": 2}
+	allocated := testing.AllocsPerRun(100, func() {
+		entrySink = logger.WithFields(fields)
+	})
+	released := testing.AllocsPerRun(100, func() {
+		logger.WithFields(fields).Release()
+	})
+	assert.True(t, released < allocated, "released entries must be reused")
+}
+
+func TestReleaseEntryWithoutData(t *testing.T) {
+	logger := New()
+	(&Entry{Logger: logger}).Release()
+
+	entry := logger.WithField("key", "value")
+	assert.Equal(t, Fields{"key": "value"}, entry.Data)
+}
+
+func TestReleasedEntriesDontChangeHookedEntries(t *testing.T) {
+	logger := New()
+	logger.Out = ioutil.Discard
+	hook := new(captureHook)
+	logger.AddHook(hook)
+
+	logger.Info("first")
+	kept := hook.entry
+	logger.WithField("user", "bob").Info("second")
+
+	assert.Equal(t, "first", kept.Message)
+	assert.Equal(t, Fields{}, kept.Data)
+}
+
+func TestEntryDup(t *testing.T) {
+	entry := New().WithFields(Fields{"shared": 1})
+	entry.Message = "original"
+	dup := entry.Dup()
+	dup.Data["own"] = 2
+
+	assert.Equal(t, "original", dup.Message)
+	assert.Equal(t, entry.Logger, dup.Logger)
+	assert.Equal(t, Fields{"shared": 1}, entry.Data)
+	assert.Equal(t, Fields{"shared": 1, "own": 2}, dup.Data)
+}
+
+// Run with -race to check that entries can be fanned out to goroutines.
+func TestEntryDupFanOut(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &TextFormatter{DisableColors: true, DisableTimestamp: true}
+	entry := logger.WithFields(Fields{"request": "r1", "msg": "clashing"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dup := entry.Dup()
+			dup.Data[fmt.Sprintf("worker%d", i)] = i
+			dup.Info("fanned out")
+			entry.Info("shared")
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, Fields{"request": "r1", "msg": "clashing"}, entry.Data, "formatting must not modify the entry")
+	assert.Equal(t, 16, strings.Count(buffer.String(), "\n"))
+}
@@ -0,0 +1,118 @@
+package logrus
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Defines the keys, following ErrorKey and a dot, of the messages of the
+// wrapped errors and of the stack trace added by WithError, see
+// `Logger.ErrorCauses` and `Logger.ErrorStackTrace`.
+var (
+	ErrorCauseKey = "cause"
+	ErrorStackKey = "stack"
+)
+
+// Maximum number of wrapped errors walked, in case an error wraps itself.
+const maximumErrorCauses = 32
+
+// errorDetails returns the fields added by WithError next to err: its causes
+// and its stack trace if the logger reports them, or nil.
+func errorDetails(logger *Logger, err error) Fields {
+	if logger == nil || err == nil || !logger.ErrorCauses && !logger.ErrorStackTrace {
+		return nil
+	}
+	fields := make(Fields)
+	if logger.ErrorCauses {
+		key := ErrorKey
+		last := err.Error()
+		cause := unwrapError(err)
+		for i := 0; cause != nil && i < maximumErrorCauses; i++ {
+			// Errors adding a stack trace keep the message of the error they
+			// wrap, such as the ones of github.com/pkg/errors
+			if msg := cause.Error(); msg != last {
+				key += "." + ErrorCauseKey
+				fields[key] = msg
+				last = msg
+			}
+			cause = unwrapError(cause)
+		}
+	}
+	if logger.ErrorStackTrace {
+		fields[ErrorKey+"."+ErrorStackKey] = errorStackTrace(err)
+	}
+	return fields
+}
+
+// isErrorStackKey reports whether key is the key of the stack trace added by
+// WithError, without building it.
+func isErrorStackKey(key string) bool {
+	return len(key) == len(ErrorKey)+1+len(ErrorStackKey) &&
+		strings.HasPrefix(key, ErrorKey) && key[len(ErrorKey)] == '.' &&
+		strings.HasSuffix(key, ErrorStackKey)
+}
+
+// unwrapError returns the error wrapped by err, with its Unwrap method or the
+// Cause method of github.com/pkg/errors, or nil if it wraps none.
+func unwrapError(err error) error {
+	switch err := err.(type) {
+	case interface{ Unwrap() error }:
+		return err.Unwrap()
+	case interface{ Cause() error }:
+		return err.Cause()
+	}
+	return nil
+}
+
+// errorStackTrace returns the stack trace of the innermost error carrying one
+// among err and the errors it wraps, see stackTrace. When none carries one,
+// it is the stack of the goroutine from the caller of WithError.
+func errorStackTrace(err error) string {
+	var trace string
+	for i := 0; err != nil && i <= maximumErrorCauses; i++ {
+		if t := stackTrace(err); t != "" {
+			trace = t
+		}
+		err = unwrapError(err)
+	}
+	if trace != "" {
+		return trace
+	}
+	return callerStackTrace()
+}
+
+// stackTrace returns the stack trace of err if it carries one, recognized
+// by implementing fmt.Formatter to print it with the "%+v" verb as
+// github.com/pkg/errors does.
+func stackTrace(err error) string {
+	if _, ok := err.(fmt.Formatter); !ok {
+		return ""
+	}
+	trace := strings.TrimPrefix(fmt.Sprintf("%+v", err), err.Error())
+	return strings.Trim(trace, "\n")
+}
+
+// callerStackTrace returns the stack of the goroutine from the first frame
+// outside of logrus, formatted like the stack traces of github.com/pkg/errors.
+func callerStackTrace() string {
+	pcs := make([]uintptr, maximumCallerDepth)
+	// skip runtime.Callers and callerStackTrace itself
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var b bytes.Buffer
+	for {
+		frame, more := frames.Next()
+		if !inLogrus(frame.File) {
+			if b.Len() > 0 {
+				b.WriteByte('\n')
+			}
+			fmt.Fprintf(&b, "%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			return b.String()
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// causeError wraps an error with the Cause method of github.com/pkg/errors.
+type causeError struct {
+	msg   string
+	cause error
+}
+
+func (e causeError) Error() string {
+	return e.msg + ": " + e.cause.Error()
+}
+
+func (e causeError) Cause() error {
+	return e.cause
+}
+
+func TestErrorCauses(t *testing.T) {
+	root := errors.New("permission denied")
+	err := causeError{"load config", fmt.Errorf("open /etc/app.conf: %w", root)}
+
+	logger := New()
+	entry := logger.WithError(err)
+	assert.Equal(t, Fields{ErrorKey: err}, entry.Data, "causes must not be added by default")
+
+	logger.ErrorCauses = true
+	entry = logger.WithError(err)
+	assert.Equal(t, Fields{
+		"error":             err,
+		"error.cause":       "open /etc/app.conf: permission denied",
+		"error.cause.cause": "permission denied",
+	}, entry.Data)
+
+	entry = logger.WithField("request", 1).WithError(root)
+	assert.Equal(t, Fields{"request": 1, "error": root}, entry.Data)
+}
+
+func TestErrorCausesSkipRepeatedMessages(t *testing.T) {
+	// Wrapping with a stack trace keeps the message, as errors.WithStack
+	root := errors.New("timeout")
+	err := causeError{"query", causeError{"retry", root}}
+	stacked := stackedError{err}
+
+	logger := New()
+	logger.ErrorCauses = true
+	entry := logger.WithError(stacked)
+	assert.Equal(t, "retry: timeout", entry.Data["error.cause"])
+	assert.Equal(t, "timeout", entry.Data["error.cause.cause"])
+	assert.Equal(t, 3, len(entry.Data))
+}
+
+// stackedError wraps an error without changing its message.
+type stackedError struct {
+	error
+}
+
+func (e stackedError) Unwrap() error {
+	return e.error
+}
+
+func TestErrorStackTrace(t *testing.T) {
+	logger := New()
+	logger.ErrorStackTrace = true
+
+	inner := stackError{"boom", []string{"main.handler", "\t/app/main.go:42"}}
+	entry := logger.WithError(causeError{"request failed", inner})
+	assert.Equal(t, "main.handler\n\t/app/main.go:42", entry.Data["error.stack"])
+
+	entry = logger.WithError(errors.New("no stack"))
+	stack := entry.Data["error.stack"].(string)
+	assert.True(t, strings.HasPrefix(stack, "github.com/sirupsen/logrus.TestErrorStackTrace\n\t"), "got %q", stack)
+	assert.Contains(t, stack, "error_details_test.go:")
+}
+
+func TestErrorDetailsKeys(t *testing.T) {
+	defer func(cause, stack string) { ErrorCauseKey, ErrorStackKey = cause, stack }(ErrorCauseKey, ErrorStackKey)
+	ErrorCauseKey, ErrorStackKey = "caused_by", "stack_trace"
+
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = new(JSONFormatter)
+	logger.ErrorCauses = true
+	logger.ErrorStackTrace = true
+	logger.WithError(fmt.Errorf("write: %w", stackError{"disk full", []string{"io.write"}})).Error("failed")
+
+	fields := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &fields))
+	assert.Equal(t, "write: disk full", fields["error"])
+	assert.Equal(t, "disk full", fields["error.caused_by"])
+	assert.Equal(t, "io.write", fields["error.stack_trace"])
+
+	buffer.Reset()
+	logger.Formatter = &TextFormatter{DisableColors: true}
+	logger.WithError(fmt.Errorf("write: %w", errors.New("disk full"))).Error("failed")
+	lines := strings.Split(buffer.String(), "\n")
+	assert.True(t, strings.HasSuffix(lines[0], `"write: disk full" disk full failed `), "got %q", lines[0])
+	assert.Equal(t, "\tgithub.com/sirupsen/logrus.TestErrorDetailsKeys", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "\t\t"), "got %q", lines[2])
+}
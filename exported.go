@@ -1,6 +1,7 @@
 package logrus
 
 import (
+	"context"
 	"io"
 )
 
@@ -22,9 +23,7 @@ func SetOutput(out io.Writer) {
 
 // SetFormatter sets the standard logger formatter.
 func SetFormatter(formatter Formatter) {
-	std.mu.Lock()
-	defer std.mu.Unlock()
-	std.Formatter = formatter
+	std.SetFormatter(formatter)
 }
 
 // SetLevel sets the standard logger level.
@@ -41,6 +40,38 @@ func GetLevel() Level {
 	return std.level()
 }
 
+// IsLevelEnabled reports whether the standard logger logs entries of the
+// given level.
+func IsLevelEnabled(level Level) bool {
+	return std.IsLevelEnabled(level)
+}
+
+// SetReportCaller sets whether the standard logger reports the caller of
+// the entries.
+func SetReportCaller(reportCaller bool) {
+	std.SetReportCaller(reportCaller)
+}
+
+// SetSampler sets the standard logger sampler.
+func SetSampler(sampler Sampler) {
+	std.SetSampler(sampler)
+}
+
+// SetRedactor sets the standard logger redactor.
+func SetRedactor(redactor *Redactor) {
+	std.SetRedactor(redactor)
+}
+
+// SetAsync makes the standard logger write entries asynchronously.
+func SetAsync(bufferSize int, policy OverflowPolicy) {
+	std.SetAsync(bufferSize, policy)
+}
+
+// Flush waits until the entries queued by the standard logger are written.
+func Flush() {
+	std.Flush()
+}
+
 // AddHook adds a hook to the standard logger hooks.
 func AddHook(hook Hook) {
 	std.mu.Lock()
@@ -48,9 +79,31 @@ func AddHook(hook Hook) {
 	std.Hooks.Add(hook)
 }
 
+// Named returns a logger for a component of the program, derived from the
+// standard logger, see `Logger.Named`.
+func Named(name string) *Logger {
+	return std.Named(name)
+}
+
+// SetContextExtractor sets the standard logger context extractor.
+func SetContextExtractor(extract func(ctx context.Context) Fields) {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	std.ContextExtractor = extract
+}
+
+// WithContext creates an entry from the standard logger and adds a context to
+// it, from which request-scoped fields are extracted when the entry is logged.
+//
+// Note that it doesn't log until you call Debug, Print, Info, Warn, Fatal
+// or Panic on the Entry it returns.
+func WithContext(ctx context.Context) *Entry {
+	return std.WithContext(ctx)
+}
+
 // WithError creates an entry from the standard logger and adds an error to it, using the value defined in ErrorKey as key.
 func WithError(err error) *Entry {
-	return std.WithField(ErrorKey, err)
+	return std.WithError(err)
 }
 
 // WithField creates an entry from the standard logger and adds a field to
@@ -72,6 +125,11 @@ func WithFields(fields Fields) *Entry {
 	return std.WithFields(fields)
 }
 
+// Trace logs a message at level Trace on the standard logger.
+func Trace(args ...interface{}) {
+	std.Trace(args...)
+}
+
 // Debug logs a message at level Debug on the standard logger.
 func Debug(args ...interface{}) {
 	std.Debug(args...)
@@ -112,6 +170,11 @@ func Fatal(args ...interface{}) {
 	std.Fatal(args...)
 }
 
+// Tracef logs a message at level Trace on the standard logger.
+func Tracef(format string, args ...interface{}) {
+	std.Tracef(format, args...)
+}
+
 // Debugf logs a message at level Debug on the standard logger.
 func Debugf(format string, args ...interface{}) {
 	std.Debugf(format, args...)
@@ -152,6 +215,11 @@ func Fatalf(format string, args ...interface{}) {
 	std.Fatalf(format, args...)
 }
 
+// Traceln logs a message at level Trace on the standard logger.
+func Traceln(args ...interface{}) {
+	std.Traceln(args...)
+}
+
 // Debugln logs a message at level Debug on the standard logger.
 func Debugln(args ...interface{}) {
 	std.Debugln(args...)
@@ -4,6 +4,32 @@ import "time"
 
 const defaultTimestampFormat = time.RFC3339
 
+// LevelNumbers maps levels to the numeric severity reported in the level
+// number field of the formatters, see `ReportLevelNumber`.
+type LevelNumbers map[Level]int
+
+// SyslogLevelNumbers maps levels to syslog severities, from 2 (critical) for
+// PanicLevel and FatalLevel to 7 (debug) for DebugLevel and TraceLevel, the
+// severities the syslog hook sends the entries with.
+var SyslogLevelNumbers = LevelNumbers{
+	PanicLevel: 2,
+	FatalLevel: 2,
+	ErrorLevel: 3,
+	WarnLevel:  4,
+	InfoLevel:  6,
+	DebugLevel: 7,
+	TraceLevel: 7,
+}
+
+// number returns the severity of level, which is the numeric value of the
+// level itself if it isn't part of the mapping.
+func (numbers LevelNumbers) number(level Level) int {
+	if n, ok := numbers[level]; ok {
+		return n
+	}
+	return int(level)
+}
+
 // The Formatter interface is used to implement a custom Formatter. It takes an
 // `Entry`. It exposes all the fields, including the default ones:
 //
@@ -18,8 +44,67 @@ type Formatter interface {
 	Format(*Entry) ([]byte, error)
 }
 
-// This is to not silently overwrite `time`, `msg` and `level` fields when
-// dumping it. If this code wasn't there doing:
+// fieldMapper is implemented by the formatters whose default field keys can be
+// customized with a FieldMap, so that it can be validated on setup.
+type fieldMapper interface {
+	fieldMap() FieldMap
+}
+
+// fieldsCarrier is implemented by errors carrying fields of context, as some
+// error libraries allow to attach.
+type fieldsCarrier interface {
+	error
+	Fields() map[string]interface{}
+}
+
+// nestedErrorFields returns the fields carried by the errors in data, keyed by the
+// key of the error and the name of the field, e.g. "error.code". Fields which
+// would clash with a key already in data are left out.
+func nestedErrorFields(data Fields) Fields {
+	var fields Fields
+	for k, v := range data {
+		err, ok := v.(fieldsCarrier)
+		if !ok {
+			continue
+		}
+		for name, value := range err.Fields() {
+			key := k + "." + name
+			if _, ok := data[key]; ok {
+				continue
+			}
+			if fields == nil {
+				fields = make(Fields)
+			}
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// hasFieldClashes reports whether prefixFieldClashes would rename fields of
+// data.
+func hasFieldClashes(data Fields, fieldMap FieldMap, reportCaller bool) bool {
+	for _, key := range reservedKeys(reportCaller) {
+		if _, ok := data[fieldMap.resolve(key)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// reservedKeys returns the default fields which fields of the entries can
+// clash with, including the caller ones if it is reported.
+func reservedKeys(reportCaller bool) []fieldKey {
+	keys := []fieldKey{FieldKeyTime, FieldKeyMsg, FieldKeyLevel}
+	if reportCaller {
+		keys = append(keys, FieldKeyFunc, FieldKeyFile)
+	}
+	return keys
+}
+
+// This is to not silently overwrite `time`, `msg` and `level` fields, and
+// `func` and `file` when the caller is reported, when dumping it. If this
+// code wasn't there doing:
 //
 //  logrus.WithField("level", 1).Info("hello")
 //
@@ -30,22 +115,12 @@ type Formatter interface {
 //
 // It's not exported because it's still using Data in an opinionated way. It's to
 // avoid code duplication between the two default formatters.
-func prefixFieldClashes(data Fields, fieldMap FieldMap) {
-	timeKey := fieldMap.resolve(FieldKeyTime)
-	if t, ok := data[timeKey]; ok {
-		data["fields."+timeKey] = t
-		delete(data, timeKey)
-	}
-
-	msgKey := fieldMap.resolve(FieldKeyMsg)
-	if m, ok := data[msgKey]; ok {
-		data["fields."+msgKey] = m
-		delete(data, msgKey)
-	}
-
-	levelKey := fieldMap.resolve(FieldKeyLevel)
-	if l, ok := data[levelKey]; ok {
-		data["fields."+levelKey] = l
-		delete(data, levelKey)
+func prefixFieldClashes(data Fields, fieldMap FieldMap, reportCaller bool) {
+	for _, key := range reservedKeys(reportCaller) {
+		name := fieldMap.resolve(key)
+		if v, ok := data[name]; ok {
+			data["fields."+name] = v
+			delete(data, name)
+		}
 	}
 }
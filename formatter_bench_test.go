@@ -51,6 +51,17 @@ var errorFields = Fields{
 	"baz": fmt.Errorf("qux"),
 }
 
+var intFields = Fields{
+	"count":    42,
+	"id":       int64(9007199254740993),
+	"size":     uint32(4096),
+	"retries":  int8(-3),
+	"bytes":    uint64(18446744073709551615),
+	"latency":  0.123,
+	"ratio":    float32(0.5),
+	"attempts": 7,
+}
+
 func BenchmarkErrorTextFormatter(b *testing.B) {
 	doBenchmark(b, &TextFormatter{DisableColors: true}, errorFields)
 }
@@ -63,6 +74,30 @@ func BenchmarkLargeTextFormatter(b *testing.B) {
 	doBenchmark(b, &TextFormatter{DisableColors: true}, largeFields)
 }
 
+func BenchmarkNoFieldsTextFormatter(b *testing.B) {
+	doBenchmark(b, &TextFormatter{DisableColors: true}, Fields{})
+}
+
+// detectedOS keeps the result of detectOS from being optimized away
+var detectedOS string
+
+// BenchmarkDetectOS measures the cost saved on every entry by detecting the
+// OS once, compare with BenchmarkNoFieldsTextFormatter.
+func BenchmarkDetectOS(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		detectedOS = detectOS()
+	}
+}
+
+func BenchmarkIntTextFormatter(b *testing.B) {
+	doBenchmark(b, &TextFormatter{DisableColors: true}, intFields)
+}
+
+func BenchmarkIntColoredTextFormatter(b *testing.B) {
+	doBenchmark(b, &TextFormatter{ForceColors: true}, intFields)
+}
+
 func BenchmarkSmallColoredTextFormatter(b *testing.B) {
 	doBenchmark(b, &TextFormatter{ForceColors: true}, smallFields)
 }
@@ -0,0 +1,65 @@
+package logrus
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GELFFormatter formats logs into Graylog Extended Log Format (GELF) 1.1
+// messages, see http://docs.graylog.org/en/latest/pages/gelf.html. Levels are
+// reported as syslog severities and fields as additional fields, prefixed
+// with an underscore.
+type GELFFormatter struct {
+	// Host reported as the source of the messages, defaults to the hostname
+	// of the machine.
+	Host string
+
+	// LevelNumbers customizes the severities reported for the levels,
+	// defaults to SyslogLevelNumbers.
+	LevelNumbers LevelNumbers
+}
+
+// Format renders a single log entry
+func (f *GELFFormatter) Format(entry *Entry) ([]byte, error) {
+	data := make(map[string]interface{}, len(entry.Data)+7)
+	for k, v := range entry.Data {
+		switch k {
+		case "id", "pid":
+			// _id is reserved by GELF, _pid is set below
+			k = "fields." + k
+		}
+		switch v := v.(type) {
+		case error:
+			data["_"+k] = v.Error()
+		default:
+			data["_"+k] = v
+		}
+	}
+
+	host := f.Host
+	if host == "" {
+		host = getHostname()
+	}
+	levelNumbers := f.LevelNumbers
+	if levelNumbers == nil {
+		levelNumbers = SyslogLevelNumbers
+	}
+
+	data["version"] = "1.1"
+	data["host"] = host
+	data["short_message"] = entry.Message
+	if i := strings.IndexByte(entry.Message, '\n'); i >= 0 {
+		data["short_message"] = entry.Message[:i]
+		data["full_message"] = entry.Message
+	}
+	data["timestamp"] = float64(entry.Time.UnixNano()/int64(1000)) / 1e6
+	data["level"] = levelNumbers.number(entry.Level)
+	data["_pid"] = getpid()
+
+	serialized, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to marshal fields to JSON, %v", err)
+	}
+	return append(serialized, '\n'), nil
+}
@@ -0,0 +1,54 @@
+package logrus
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func formatGELF(t *testing.T, formatter *GELFFormatter, entry *Entry) map[string]interface{} {
+	b, err := formatter.Format(entry)
+	assert.NoError(t, err)
+	message := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(b, &message))
+	return message
+}
+
+func TestGELFFormatter(t *testing.T) {
+	formatter := &GELFFormatter{Host: "node1"}
+	entry := &Entry{
+		Time:    time.Unix(1500000000, 250000000),
+		Level:   WarnLevel,
+		Message: "disk almost full",
+		Data:    Fields{"disk": "/dev/sda1", "error": errors.New("ENOSPC"), "id": 7, "pid": "worker"},
+	}
+
+	message := formatGELF(t, formatter, entry)
+	assert.Equal(t, "1.1", message["version"])
+	assert.Equal(t, "node1", message["host"])
+	assert.Equal(t, "disk almost full", message["short_message"])
+	assert.Equal(t, 1500000000.25, message["timestamp"])
+	assert.Equal(t, float64(4), message["level"])
+	assert.Equal(t, float64(getpid()), message["_pid"])
+	assert.Equal(t, "/dev/sda1", message["_disk"])
+	assert.Equal(t, "ENOSPC", message["_error"])
+	assert.Equal(t, float64(7), message["_fields.id"])
+	assert.Equal(t, "worker", message["_fields.pid"])
+	assert.Nil(t, message["_id"])
+	assert.Nil(t, message["full_message"])
+	assert.Nil(t, message["disk"])
+}
+
+func TestGELFFormatterFullMessage(t *testing.T) {
+	formatter := &GELFFormatter{}
+	entry := &Entry{Level: ErrorLevel, Message: "request failed\nstatus: 500", Data: Fields{}}
+
+	message := formatGELF(t, formatter, entry)
+	assert.Equal(t, "request failed", message["short_message"])
+	assert.Equal(t, "request failed\nstatus: 500", message["full_message"])
+	assert.Equal(t, getHostname(), message["host"])
+	assert.Equal(t, float64(3), message["level"])
+}
@@ -0,0 +1,148 @@
+package logrus
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// Limits of chunked GELF messages sent over UDP.
+const (
+	// DefaultGELFChunkSize fits in the MTU of most LANs.
+	DefaultGELFChunkSize = 8154
+	gelfMaxChunks        = 128
+	gelfChunkHeaderSize  = 12
+)
+
+// GELFHook sends the entries to a Graylog server as GELF messages, over UDP
+// or TCP:
+//
+//    hook, err := logrus.NewGELFHook("udp", "graylog:12201")
+//    if err == nil {
+//      logger.AddHook(hook)
+//    }
+//
+// Over UDP, messages larger than ChunkSize are split in chunks, up to 128 of
+// them, and can be gzipped. Over TCP, messages are delimited by null bytes
+// as Graylog expects, and aren't compressed.
+type GELFHook struct {
+	// Formatter formats the messages, its Host and LevelNumbers can be set.
+	Formatter *GELFFormatter
+
+	// LogLevels are the levels of the entries sent, defaults to all the
+	// levels.
+	LogLevels []Level
+
+	// ChunkSize is the maximum size of the UDP datagrams sent, headers
+	// included, defaults to DefaultGELFChunkSize.
+	ChunkSize int
+
+	// Compress gzips the messages sent over UDP.
+	Compress bool
+
+	network string
+	mu      sync.Mutex
+	conn    net.Conn
+
+	// Number of the fallback chunked message IDs.
+	messageIDs uint64
+}
+
+// NewGELFHook returns a hook sending the entries to the Graylog server at
+// addr, network being "udp" or "tcp".
+func NewGELFHook(network, addr string) (*GELFHook, error) {
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("Unsupported GELF network %q", network)
+	}
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &GELFHook{Formatter: new(GELFFormatter), network: network, conn: conn}, nil
+}
+
+func (hook *GELFHook) Levels() []Level {
+	if hook.LogLevels == nil {
+		return AllLevels
+	}
+	return hook.LogLevels
+}
+
+func (hook *GELFHook) Fire(entry *Entry) error {
+	message, err := hook.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	message = bytes.TrimSuffix(message, []byte("\n"))
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if hook.network[:3] == "tcp" {
+		_, err = hook.conn.Write(append(message, 0))
+		return err
+	}
+	return hook.sendUDP(message)
+}
+
+// Close closes the connection to the server.
+func (hook *GELFHook) Close() error {
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	return hook.conn.Close()
+}
+
+// sendUDP sends message in as many datagrams as needed.
+func (hook *GELFHook) sendUDP(message []byte) error {
+	if hook.Compress {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		if _, err := gz.Write(message); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		message = compressed.Bytes()
+	}
+
+	chunkSize := hook.ChunkSize
+	if chunkSize <= gelfChunkHeaderSize {
+		chunkSize = DefaultGELFChunkSize
+	}
+	if len(message) <= chunkSize {
+		_, err := hook.conn.Write(message)
+		return err
+	}
+
+	payloadSize := chunkSize - gelfChunkHeaderSize
+	count := (len(message) + payloadSize - 1) / payloadSize
+	if count > gelfMaxChunks {
+		return fmt.Errorf("GELF message of %d bytes exceeds %d chunks", len(message), gelfMaxChunks)
+	}
+
+	id, err := randomID()
+	if err != nil {
+		binary.BigEndian.PutUint64(id[:], atomic.AddUint64(&hook.messageIDs, 1))
+	}
+	chunk := make([]byte, 0, chunkSize)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * payloadSize
+		if end > len(message) {
+			end = len(message)
+		}
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, message[i*payloadSize:end]...)
+		if _, err := hook.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
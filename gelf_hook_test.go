@@ -0,0 +1,134 @@
+package logrus
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readDatagram(t *testing.T, conn net.PacketConn) []byte {
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	return buf[:n]
+}
+
+func TestGELFHookUDP(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer server.Close()
+
+	hook, err := NewGELFHook("udp", server.LocalAddr().String())
+	assert.NoError(t, err)
+	defer hook.Close()
+	hook.Formatter.Host = "node1"
+
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+	logger.WithField("disk", "/dev/sda1").Warn("disk almost full")
+
+	message := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(readDatagram(t, server), &message))
+	assert.Equal(t, "node1", message["host"])
+	assert.Equal(t, "disk almost full", message["short_message"])
+	assert.Equal(t, "/dev/sda1", message["_disk"])
+	assert.Equal(t, float64(4), message["level"])
+}
+
+func TestGELFHookChunking(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer server.Close()
+
+	hook, err := NewGELFHook("udp", server.LocalAddr().String())
+	assert.NoError(t, err)
+	defer hook.Close()
+	hook.ChunkSize = 112
+	hook.Compress = true
+
+	long := strings.Repeat("0123456789", 100)
+	assert.NoError(t, hook.Fire(&Entry{Level: InfoLevel, Message: "long", Data: Fields{"payload": long}}))
+
+	var compressed bytes.Buffer
+	var id []byte
+	count := -1
+	for i := 0; i != count; i++ {
+		chunk := readDatagram(t, server)
+		assert.True(t, len(chunk) <= 112)
+		assert.Equal(t, []byte{0x1e, 0x0f}, chunk[:2])
+		if id == nil {
+			id = chunk[2:10]
+			count = int(chunk[11])
+		}
+		assert.Equal(t, id, chunk[2:10], "chunks must share the message ID")
+		assert.Equal(t, byte(i), chunk[10])
+		compressed.Write(chunk[12:])
+	}
+	assert.True(t, count > 1, "message expected to be chunked")
+
+	gz, err := gzip.NewReader(&compressed)
+	assert.NoError(t, err)
+	message := make(map[string]interface{})
+	assert.NoError(t, json.NewDecoder(gz).Decode(&message))
+	assert.Equal(t, long, message["_payload"])
+
+	hook.ChunkSize = gelfChunkHeaderSize
+	hook.Compress = false
+	err = hook.Fire(&Entry{Level: InfoLevel, Message: long, Data: Fields{}})
+	assert.NoError(t, err, "chunk sizes too small must fall back to the default")
+}
+
+func TestGELFHookTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		var messages []string
+		for len(messages) < 2 {
+			message, err := reader.ReadString(0)
+			if err != nil {
+				break
+			}
+			messages = append(messages, strings.TrimSuffix(message, "\x00"))
+		}
+		received <- messages
+	}()
+
+	hook, err := NewGELFHook("tcp", listener.Addr().String())
+	assert.NoError(t, err)
+	defer hook.Close()
+	assert.NoError(t, hook.Fire(&Entry{Level: InfoLevel, Message: "first", Data: Fields{}}))
+	assert.NoError(t, hook.Fire(&Entry{Level: ErrorLevel, Message: "second", Data: Fields{}}))
+
+	messages := <-received
+	assert.Equal(t, 2, len(messages))
+	for i, expected := range []string{"first", "second"} {
+		message := make(map[string]interface{})
+		assert.NoError(t, json.Unmarshal([]byte(messages[i]), &message))
+		assert.Equal(t, expected, message["short_message"])
+	}
+}
+
+func TestGELFHookUnsupportedNetwork(t *testing.T) {
+	_, err := NewGELFHook("unix", "/tmp/graylog.sock")
+	assert.Error(t, err)
+}
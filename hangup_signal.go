@@ -0,0 +1,12 @@
+// +build !js
+
+package logrus
+
+import (
+	"os"
+	"syscall"
+)
+
+// hangupSignals are the signals handled by default by ReopenOnSignal and
+// ToggleDebugOnSignal, SIGHUP.
+var hangupSignals = []os.Signal{syscall.SIGHUP}
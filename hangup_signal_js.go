@@ -0,0 +1,8 @@
+// +build js
+
+package logrus
+
+import "os"
+
+// There is no SIGHUP on js, no signals are handled by default.
+var hangupSignals []os.Signal
@@ -1,8 +1,10 @@
 package logrus
 
 import (
+	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -142,3 +144,50 @@ func TestAddHookRace(t *testing.T) {
 		// actually assert on the hook
 	})
 }
+
+type traceHook struct {
+	fired bool
+}
+
+func (hook *traceHook) Levels() []Level {
+	return AllLevels
+}
+
+func (hook *traceHook) Fire(entry *Entry) error {
+	hook.fired = true
+	return nil
+}
+
+func (hook *traceHook) FireContext(ctx context.Context, entry *Entry) error {
+	if traceID, ok := ctx.Value(contextKey("trace_id")).(string); ok {
+		entry.Data["trace_id"] = traceID
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		entry.Data["deadline"] = deadline.Format(time.RFC3339)
+	}
+	return nil
+}
+
+func TestContextHook(t *testing.T) {
+	hook := new(traceHook)
+	deadline := time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)
+	ctx, cancel := context.WithDeadline(context.WithValue(context.Background(), contextKey("trace_id"), "abc123"), deadline)
+	defer cancel()
+
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.Hooks.Add(hook)
+		log.WithContext(ctx).WithField("foo", "bar").Info("test")
+	}, func(fields Fields) {
+		assert.Equal(t, "abc123", fields["trace_id"])
+		assert.Equal(t, "2018-03-04T05:06:07Z", fields["deadline"])
+		assert.Equal(t, "bar", fields["foo"])
+	})
+
+	LogAndAssertJSON(t, func(log *Logger) {
+		log.Hooks.Add(hook)
+		log.Info("test")
+	}, func(fields Fields) {
+		assert.Nil(t, fields["trace_id"])
+	})
+	assert.False(t, hook.fired, "Fire must not be called for context hooks")
+}
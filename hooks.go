@@ -1,5 +1,7 @@
 package logrus
 
+import "context"
+
 // A hook to be fired when logging on the logging levels returned from
 // `Levels()` on your implementation of the interface. Note that this is not
 // fired in a goroutine or a channel with workers, you should handle such
@@ -10,6 +12,15 @@ type Hook interface {
 	Fire(*Entry) error
 }
 
+// ContextHook is a Hook given the context of the entry, set with
+// `WithContext`, to read request-scoped values such as the trace ID of a
+// span or the deadline of a request. FireContext is called instead of Fire,
+// with context.Background() for the entries without context.
+type ContextHook interface {
+	Hook
+	FireContext(ctx context.Context, entry *Entry) error
+}
+
 // Internal type for storing the hooks on a logger instance.
 type LevelHooks map[Level][]Hook
 
@@ -25,10 +36,30 @@ func (hooks LevelHooks) Add(hook Hook) {
 // appropriate hooks for a log entry.
 func (hooks LevelHooks) Fire(level Level, entry *Entry) error {
 	for _, hook := range hooks[level] {
-		if err := hook.Fire(entry); err != nil {
+		if err := fireHook(hook, entry); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// fireAll fires all the hooks for the passed level, unlike Fire it doesn't
+// stop at the first failing hook, and returns the errors of all of them.
+func (hooks LevelHooks) fireAll(level Level, entry *Entry) []error {
+	var errs []error
+	for _, hook := range hooks[level] {
+		if err := fireHook(hook, entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// fireHook fires hook, with the context of the entry if it is a ContextHook.
+func fireHook(hook Hook, entry *Entry) error {
+	if contextHook, ok := hook.(ContextHook); ok {
+		return contextHook.FireContext(entry.context(), entry)
+	}
+	return hook.Fire(entry)
+}
@@ -3,30 +3,68 @@ package logrus_sourcefile
 import (
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 )
 
+// Directory of the sources of logrus, whose frames are skipped to find the
+// caller, matched by file as logrus does to report the caller of entries.
+var logrusDir = sourceDir(logrus.New)
+
+func sourceDir(function interface{}) string {
+	f := runtime.FuncForPC(reflect.ValueOf(function).Pointer())
+	if f == nil {
+		return ""
+	}
+	file, _ := f.FileLine(f.Entry())
+	return filepath.Dir(file)
+}
+
 type SourceFileHook struct {
 	LogLevel logrus.Level
 }
 
+// Fire sets the source_file field to the location of the first caller outside
+// of logrus, skipping the additional frames of logging wrappers configured
+// with the logger's CallerSkip. It is the caller of the entry if the logger
+// reports it already.
 func (hook *SourceFileHook) Fire(entry *logrus.Entry) (_ error) {
-	for skip := 4; skip < 9; skip++ {
-		_, file, line, _ := runtime.Caller(skip)
-		split := strings.Split(file, "/")
-		if l := len(split); l > 1 {
-			pkg := split[l-2]
-			if pkg != "logrus" {
-				file = fmt.Sprintf("%s/%s:%d", split[l-2], split[l-1], line)
-				// set source_file field
-				entry.Data["source_file"] = file
+	if entry.HasCaller() {
+		setSourceFile(entry, entry.Caller)
+		return
+	}
+
+	pcs := make([]uintptr, 32)
+	// skip runtime.Callers and Fire itself
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	skip := 0
+	if entry.Logger != nil {
+		skip = entry.Logger.CallerSkip
+	}
+	for {
+		frame, more := frames.Next()
+		if !inLogrus(frame.File) {
+			if skip == 0 {
+				setSourceFile(entry, &frame)
 				return
 			}
+			skip--
+		}
+		if !more {
+			return
 		}
 	}
+}
 
-	return
+func setSourceFile(entry *logrus.Entry, frame *runtime.Frame) {
+	split := strings.Split(frame.File, "/")
+	if l := len(split); l > 1 {
+		entry.Data["source_file"] = fmt.Sprintf("%s/%s:%d", split[l-2], split[l-1], frame.Line)
+	}
 }
 
 func (hook *SourceFileHook) Levels() []logrus.Level {
@@ -36,3 +74,9 @@ func (hook *SourceFileHook) Levels() []logrus.Level {
 	}
 	return levels
 }
+
+// inLogrus reports whether file is a source file of logrus, not counting
+// its tests.
+func inLogrus(file string) bool {
+	return filepath.Dir(file) == logrusDir && !strings.HasSuffix(file, "_test.go")
+}
@@ -0,0 +1,73 @@
+package logrus_sourcefile
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Line of the call to Info in logInfo.
+var infoLine int
+
+func logInfo(log *logrus.Logger, msg string) {
+	_, _, infoLine, _ = runtime.Caller(0)
+	log.Info(msg)
+}
+
+func logRequest(log *logrus.Logger, msg string) {
+	logInfo(log, msg)
+}
+
+func TestCallerSkip(t *testing.T) {
+	var buffer bytes.Buffer
+	log := logrus.New()
+	log.Out = &buffer
+	log.Formatter = &logrus.JSONFormatter{}
+	log.Hooks.Add(&SourceFileHook{LogLevel: logrus.InfoLevel})
+
+	_, file, line, _ := runtime.Caller(0)
+	logRequest(log, "through wrappers")
+	log.CallerSkip = 2
+	logRequest(log, "through wrappers")
+	log.CallerSkip = 0
+	log.Info("direct")
+
+	split := strings.Split(file, "/")
+	here := split[len(split)-2] + "/" + split[len(split)-1]
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 entries, got %q", buffer.String())
+	}
+	expected := []string{
+		fmt.Sprintf(`"source_file":"%s:%d"`, here, infoLine+1),
+		fmt.Sprintf(`"source_file":"%s:%d"`, here, line+3),
+		fmt.Sprintf(`"source_file":"%s:%d"`, here, line+5),
+	}
+	for i, e := range expected {
+		if !strings.Contains(lines[i], e) {
+			t.Errorf("entry %d expected to contain %s, got %s", i, e, lines[i])
+		}
+	}
+}
+
+func TestReportedCaller(t *testing.T) {
+	var buffer bytes.Buffer
+	log := logrus.New()
+	log.Out = &buffer
+	log.Formatter = &logrus.JSONFormatter{}
+	log.SetReportCaller(true)
+	log.Hooks.Add(&SourceFileHook{LogLevel: logrus.InfoLevel})
+
+	_, file, line, _ := runtime.Caller(0)
+	log.Info("direct")
+
+	split := strings.Split(file, "/")
+	expected := fmt.Sprintf(`"source_file":"%s/%s:%d"`, split[len(split)-2], split[len(split)-1], line+1)
+	if !strings.Contains(buffer.String(), expected) {
+		t.Errorf("expected %s, got %s", expected, buffer.String())
+	}
+}
@@ -1,8 +1,13 @@
 package logrus
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type fieldKey string
@@ -15,8 +20,38 @@ const (
 	FieldKeyMsg   = "msg"
 	FieldKeyLevel = "level"
 	FieldKeyTime  = "time"
+
+	FieldKeyHostname = "hostname"
+	FieldKeyLevelNum = "level_num"
+
+	FieldKeyFieldCount = "field_count"
+	FieldKeyEntryID    = "entry_id"
+
+	FieldKeyProcessID = "process_id"
+	FieldKeyThreadID  = "thread_id"
+	FieldKeyOS        = "os"
+
+	FieldKeyFunc = "func"
+	FieldKeyFile = "file"
 )
 
+// reservedFieldKeys lists the default fields whose key can be customized in
+// a FieldMap.
+var reservedFieldKeys = []fieldKey{
+	FieldKeyMsg,
+	FieldKeyLevel,
+	FieldKeyTime,
+	FieldKeyHostname,
+	FieldKeyLevelNum,
+	FieldKeyFieldCount,
+	FieldKeyEntryID,
+	FieldKeyProcessID,
+	FieldKeyThreadID,
+	FieldKeyOS,
+	FieldKeyFunc,
+	FieldKeyFile,
+}
+
 func (f FieldMap) resolve(key fieldKey) string {
 	if k, ok := f[key]; ok {
 		return k
@@ -25,6 +60,20 @@ func (f FieldMap) resolve(key fieldKey) string {
 	return string(key)
 }
 
+// Validate returns an error if several default fields are mapped to the same
+// key, in which case one of their values would silently overwrite the other.
+func (f FieldMap) Validate() error {
+	seen := make(map[string]fieldKey, len(reservedFieldKeys))
+	for _, key := range reservedFieldKeys {
+		name := f.resolve(key)
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("FieldMap maps both %q and %q to %q", other, key, name)
+		}
+		seen[name] = key
+	}
+	return nil
+}
+
 // JSONFormatter formats logs into parsable json
 type JSONFormatter struct {
 	// TimestampFormat sets the format used for marshaling timestamps.
@@ -43,12 +92,66 @@ type JSONFormatter struct {
 	//    },
 	// }
 	FieldMap FieldMap
+
+	// ReportLevelNumber adds the numeric severity of the level as a number,
+	// under the FieldKeyLevelNum key.
+	ReportLevelNumber bool
+
+	// LevelNumbers customizes the severities reported with ReportLevelNumber,
+	// e.g. SyslogLevelNumbers. Defaults to the numeric value of the Level.
+	LevelNumbers LevelNumbers
+
+	// IncludePID, IncludeTID and IncludeOS add the process ID, the thread ID
+	// and the OS under the FieldKeyProcessID, FieldKeyThreadID and
+	// FieldKeyOS keys. The OS is runtime.GOOS, e.g. "linux", rather than the
+	// letter printed by the TextFormatter.
+	IncludePID bool
+	IncludeTID bool
+	IncludeOS  bool
+
+	// PrettyPrint indents the JSON of the entries, which then span several
+	// lines. This is meant for reading logs interactively: tools expecting
+	// one entry per line won't be able to parse the output.
+	PrettyPrint bool
+
+	// Indent is the indentation used by PrettyPrint, defaults to two spaces.
+	Indent string
+
+	// ValueFormatters renders the values of the fields of some types, e.g.
+	// durations which are otherwise written as a number of nanoseconds, see
+	// StandardValueFormatters.
+	ValueFormatters ValueFormatters
+
+	// NestDottedKeys writes the fields whose keys contain dots as nested
+	// objects, e.g. "http.status" as {"http":{"status":200}}, as expected by
+	// the Elasticsearch mappings of objects. This applies to the keys set
+	// in FieldMap as well. A field whose key clashes with another, such as
+	// "http.status" next to a "http" field which isn't nested, keeps its
+	// dotted key.
+	NestDottedKeys bool
+
+	// FlattenNestedFields writes the values of the fields which are maps
+	// keyed by strings as fields with dotted keys, the reverse of
+	// NestDottedKeys, e.g. {"http":{"status":200}} as {"http.status":200}.
+	// Fields logged with a dotted key take precedence over the flattened
+	// ones. Empty maps are kept as is.
+	FlattenNestedFields bool
+}
+
+func (f *JSONFormatter) fieldMap() FieldMap {
+	return f.FieldMap
 }
 
 // Format renders a single log entry
 func (f *JSONFormatter) Format(entry *Entry) ([]byte, error) {
+	if len(entry.Data) == 0 && !f.PrettyPrint && !entry.HasCaller() && !f.NestDottedKeys {
+		if serialized, ok := f.formatWithoutData(entry); ok {
+			return serialized, nil
+		}
+	}
+
 	data := make(Fields, len(entry.Data)+3)
-	for k, v := range entry.Data {
+	for k, v := range f.ValueFormatters.formatValues(entry.Data) {
 		switch v := v.(type) {
 		case error:
 			// Otherwise errors are ignored by `encoding/json`
@@ -58,7 +161,10 @@ func (f *JSONFormatter) Format(entry *Entry) ([]byte, error) {
 			data[k] = v
 		}
 	}
-	prefixFieldClashes(data, f.FieldMap)
+	for k, v := range nestedErrorFields(entry.Data) {
+		data[k] = v
+	}
+	prefixFieldClashes(data, f.FieldMap, entry.HasCaller())
 
 	timestampFormat := f.TimestampFormat
 	if timestampFormat == "" {
@@ -70,10 +176,263 @@ func (f *JSONFormatter) Format(entry *Entry) ([]byte, error) {
 	}
 	data[f.FieldMap.resolve(FieldKeyMsg)] = entry.Message
 	data[f.FieldMap.resolve(FieldKeyLevel)] = entry.Level.String()
+	if f.ReportLevelNumber {
+		data[f.FieldMap.resolve(FieldKeyLevelNum)] = f.LevelNumbers.number(entry.Level)
+	}
+	if entry.HasCaller() {
+		data[f.FieldMap.resolve(FieldKeyFunc)] = entry.Caller.Function
+		data[f.FieldMap.resolve(FieldKeyFile)] = fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)
+	}
+	if f.IncludePID {
+		data[f.FieldMap.resolve(FieldKeyProcessID)] = getpid()
+	}
+	if f.IncludeTID {
+		data[f.FieldMap.resolve(FieldKeyThreadID)] = getCurrentThreadID()
+	}
+	if f.IncludeOS {
+		data[f.FieldMap.resolve(FieldKeyOS)] = getOS()
+	}
 
-	serialized, err := json.Marshal(data)
-	if err != nil {
+	if f.FlattenNestedFields {
+		data = flattenFields(data)
+	}
+	if f.NestDottedKeys {
+		data = nestDottedKeys(data)
+	}
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	encoder := json.NewEncoder(b)
+	if f.PrettyPrint {
+		indent := f.Indent
+		if indent == "" {
+			indent = "  "
+		}
+		encoder.SetIndent("", indent)
+	}
+	if err := encoder.Encode(data); err != nil {
 		return nil, fmt.Errorf("Failed to marshal fields to JSON, %v", err)
 	}
-	return append(serialized, '\n'), nil
+	return b.Bytes(), nil
+}
+
+// jsonField is a field written by formatWithoutData, the value is given by
+// the kind, one of the FieldKey constants.
+type jsonField struct {
+	key  string
+	kind fieldKey
+}
+
+// formatWithoutData formats an entry without data, the most common case,
+// writing the fields directly instead of marshaling a map. The output is the
+// one of encoding/json, so it reports false when some text would have to be
+// escaped, to leave it to encoding/json.
+func (f *JSONFormatter) formatWithoutData(entry *Entry) ([]byte, bool) {
+	var fields [7]jsonField
+	n := 0
+	add := func(kind fieldKey) {
+		fields[n] = jsonField{key: f.FieldMap.resolve(kind), kind: kind}
+		n++
+	}
+	if !f.DisableTimestamp {
+		add(FieldKeyTime)
+	}
+	add(FieldKeyMsg)
+	add(FieldKeyLevel)
+	if f.ReportLevelNumber {
+		add(FieldKeyLevelNum)
+	}
+	if f.IncludePID {
+		add(FieldKeyProcessID)
+	}
+	if f.IncludeTID {
+		add(FieldKeyThreadID)
+	}
+	if f.IncludeOS {
+		add(FieldKeyOS)
+	}
+
+	// encoding/json sorts the keys of maps
+	for i := 1; i < n; i++ {
+		for j := i; j > 0 && fields[j].key < fields[j-1].key; j-- {
+			fields[j], fields[j-1] = fields[j-1], fields[j]
+		}
+	}
+	for i := 0; i < n; i++ {
+		if !isJSONSafe(fields[i].key) || i > 0 && fields[i].key == fields[i-1].key {
+			return nil, false
+		}
+	}
+
+	var scratch [64]byte
+	var timestamp []byte
+	if !f.DisableTimestamp {
+		timestampFormat := f.TimestampFormat
+		if timestampFormat == "" {
+			timestampFormat = defaultTimestampFormat
+		}
+		timestamp = entry.Time.AppendFormat(scratch[:0], timestampFormat)
+	}
+	level := entry.Level.String()
+	if !isJSONSafe(entry.Message) || !isJSONSafe(string(timestamp)) || !isJSONSafe(level) {
+		return nil, false
+	}
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+	var digits [20]byte
+	b.WriteByte('{')
+	for i, field := range fields[:n] {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('"')
+		b.WriteString(field.key)
+		b.WriteString(`":`)
+		switch field.kind {
+		case FieldKeyTime:
+			b.WriteByte('"')
+			b.Write(timestamp)
+			b.WriteByte('"')
+		case FieldKeyMsg:
+			b.WriteByte('"')
+			b.WriteString(entry.Message)
+			b.WriteByte('"')
+		case FieldKeyLevel:
+			b.WriteByte('"')
+			b.WriteString(level)
+			b.WriteByte('"')
+		case FieldKeyLevelNum:
+			b.Write(strconv.AppendInt(digits[:0], int64(f.LevelNumbers.number(entry.Level)), 10))
+		case FieldKeyProcessID:
+			b.Write(strconv.AppendInt(digits[:0], int64(getpid()), 10))
+		case FieldKeyThreadID:
+			b.Write(strconv.AppendInt(digits[:0], int64(getCurrentThreadID()), 10))
+		case FieldKeyOS:
+			b.WriteByte('"')
+			b.WriteString(getOS())
+			b.WriteByte('"')
+		}
+	}
+	b.WriteString("}\n")
+	return b.Bytes(), true
+}
+
+// isJSONSafe reports whether text is ASCII written as is in a JSON string by
+// encoding/json, which escapes quotes, backslashes, control characters and
+// the HTML characters <, > and &.
+func isJSONSafe(text string) bool {
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c < 0x20 || c >= 0x80:
+			return false
+		case c == '"' || c == '\\' || c == '<' || c == '>' || c == '&':
+			return false
+		}
+	}
+	return true
+}
+
+// jsonObject is an object created by nestDottedKeys, telling it apart from
+// the maps logged as values, which are never modified.
+type jsonObject map[string]interface{}
+
+// nestDottedKeys returns data with the fields whose keys contain dots moved
+// into nested objects, see JSONFormatter.NestDottedKeys.
+func nestDottedKeys(data Fields) Fields {
+	nested := make(Fields, len(data))
+	var dotted []string
+	for k, v := range data {
+		if isDottedKey(k) {
+			dotted = append(dotted, k)
+		} else {
+			nested[k] = v
+		}
+	}
+	// Shorter keys first, so that "a.b" clashing with "a.b.c" is the one
+	// nested
+	sort.Strings(dotted)
+
+	for _, key := range dotted {
+		parts := strings.Split(key, ".")
+		object := jsonObject(nested)
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := object[part]
+			if !ok {
+				created := make(jsonObject)
+				object[part] = created
+				object = created
+				continue
+			}
+			if object, ok = child.(jsonObject); !ok {
+				break
+			}
+		}
+		last := parts[len(parts)-1]
+		if _, ok := object[last]; object == nil || ok {
+			nested[key] = data[key]
+			continue
+		}
+		object[last] = data[key]
+	}
+	return nested
+}
+
+// isDottedKey reports whether key is made of several parts separated by
+// dots, none being empty.
+func isDottedKey(key string) bool {
+	if !strings.Contains(key, ".") {
+		return false
+	}
+	return !strings.HasPrefix(key, ".") && !strings.HasSuffix(key, ".") && !strings.Contains(key, "..")
+}
+
+// flattenFields returns data with the values which are maps keyed by
+// strings replaced by fields with dotted keys, see
+// JSONFormatter.FlattenNestedFields.
+func flattenFields(data Fields) Fields {
+	flat := make(Fields, len(data))
+	var maps []string
+	for k, v := range data {
+		if isFlattenable(v) {
+			maps = append(maps, k)
+		} else {
+			flat[k] = v
+		}
+	}
+	for _, k := range maps {
+		flattenValue(flat, k, data[k])
+	}
+	return flat
+}
+
+func flattenValue(flat Fields, key string, value interface{}) {
+	if !isFlattenable(value) {
+		if _, ok := flat[key]; !ok {
+			flat[key] = value
+		}
+		return
+	}
+	m := reflect.ValueOf(value)
+	for _, k := range m.MapKeys() {
+		flattenValue(flat, key+"."+k.String(), m.MapIndex(k).Interface())
+	}
+}
+
+// isFlattenable reports whether value is a non-empty map keyed by strings.
+func isFlattenable(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String && v.Len() > 0
 }
@@ -1,11 +1,14 @@
 package logrus
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestErrorNotLost(t *testing.T) {
@@ -252,3 +255,290 @@ func TestJSONEnableTimestamp(t *testing.T) {
 		t.Error("Timestamp not present", s)
 	}
 }
+
+func TestReportLevelNumber(t *testing.T) {
+	testCases := []struct {
+		numbers  LevelNumbers
+		level    Level
+		expected float64
+	}{
+		{nil, PanicLevel, 0},
+		{nil, DebugLevel, 5},
+		{SyslogLevelNumbers, PanicLevel, 2},
+		{SyslogLevelNumbers, FatalLevel, 2},
+		{SyslogLevelNumbers, WarnLevel, 4},
+		{SyslogLevelNumbers, DebugLevel, 7},
+		{LevelNumbers{InfoLevel: 30}, InfoLevel, 30},
+	}
+
+	for _, tc := range testCases {
+		formatter := &JSONFormatter{ReportLevelNumber: true, LevelNumbers: tc.numbers}
+		entry := WithField("foo", "bar")
+		entry.Level = tc.level
+
+		b, err := formatter.Format(entry)
+		if err != nil {
+			t.Fatal("Unable to format entry: ", err)
+		}
+
+		data := make(map[string]interface{})
+		err = json.Unmarshal(b, &data)
+		if err != nil {
+			t.Fatal("Unable to unmarshal formatted entry: ", err)
+		}
+
+		if data["level_num"] != tc.expected {
+			t.Errorf("level_num for %s expected to be %v, got %v", tc.level, tc.expected, data["level_num"])
+		}
+		if data["level"] != tc.level.String() {
+			t.Errorf("level for %s expected to be kept, got %v", tc.level, data["level"])
+		}
+	}
+}
+
+func TestLevelNumbersAreOrdered(t *testing.T) {
+	for i := 1; i < len(AllLevels); i++ {
+		// syslog has no severity finer than debug, which trace shares
+		if SyslogLevelNumbers.number(AllLevels[i-1]) > SyslogLevelNumbers.number(AllLevels[i]) {
+			t.Errorf("severity of %s expected not to be above %s", AllLevels[i-1], AllLevels[i])
+		}
+	}
+}
+
+func TestFieldMapValidate(t *testing.T) {
+	valid := []FieldMap{
+		nil,
+		{FieldKeyTime: "@timestamp", FieldKeyLevel: "@level", FieldKeyMsg: "@message"},
+		{FieldKeyMsg: "time", FieldKeyTime: "msg"},
+	}
+	for _, fieldMap := range valid {
+		if err := fieldMap.Validate(); err != nil {
+			t.Errorf("FieldMap %v expected to be valid, got %v", fieldMap, err)
+		}
+	}
+
+	invalid := []FieldMap{
+		{FieldKeyMsg: "data", FieldKeyLevel: "data"},
+		{FieldKeyMsg: "level"},
+		{FieldKeyHostname: "time"},
+	}
+	for _, fieldMap := range invalid {
+		err := fieldMap.Validate()
+		if err == nil {
+			t.Errorf("FieldMap %v expected to be invalid", fieldMap)
+			continue
+		}
+		if !strings.Contains(err.Error(), "FieldMap maps both") {
+			t.Errorf("Unexpected error for FieldMap %v: %v", fieldMap, err)
+		}
+	}
+}
+
+type contextError struct {
+	fields map[string]interface{}
+}
+
+func (e contextError) Error() string {
+	return "request failed"
+}
+
+func (e contextError) Fields() map[string]interface{} {
+	return e.fields
+}
+
+func TestErrorFields(t *testing.T) {
+	formatter := &JSONFormatter{}
+
+	err := contextError{map[string]interface{}{"code": 503, "host": "db1"}}
+	b, _ := formatter.Format(WithFields(Fields{"error": err, "error.host": "explicit"}))
+
+	entry := make(map[string]interface{})
+	if err := json.Unmarshal(b, &entry); err != nil {
+		t.Fatal("Unable to unmarshal formatted entry: ", err)
+	}
+
+	if entry["error"] != "request failed" {
+		t.Errorf("error expected to be rendered as its message, got %v", entry["error"])
+	}
+	if entry["error.code"] != float64(503) {
+		t.Errorf("error.code expected to be 503, got %v", entry["error.code"])
+	}
+	if entry["error.host"] != "explicit" {
+		t.Errorf("error.host expected to keep the explicit field, got %v", entry["error.host"])
+	}
+}
+
+func TestJSONPrettyPrint(t *testing.T) {
+	formatter := &JSONFormatter{DisableTimestamp: true}
+	compact, _ := formatter.Format(WithFields(Fields{"foo": "bar", "n": 1}))
+
+	formatter.PrettyPrint = true
+	pretty, _ := formatter.Format(WithFields(Fields{"foo": "bar", "n": 1}))
+
+	if bytes.Count(compact, []byte("\n")) != 1 {
+		t.Fatal("compact output expected to be a single line, got", string(compact))
+	}
+	if !bytes.Contains(pretty, []byte("\n  \"foo\": \"bar\"")) {
+		t.Fatal("pretty output expected to be indented with two spaces, got", string(pretty))
+	}
+
+	var compactEntry, prettyEntry map[string]interface{}
+	if err := json.Unmarshal(compact, &compactEntry); err != nil {
+		t.Fatal("Unable to unmarshal compact entry: ", err)
+	}
+	if err := json.Unmarshal(pretty, &prettyEntry); err != nil {
+		t.Fatal("Unable to unmarshal pretty entry: ", err)
+	}
+	if !reflect.DeepEqual(compactEntry, prettyEntry) {
+		t.Fatalf("pretty entry %v expected to equal compact entry %v", prettyEntry, compactEntry)
+	}
+
+	formatter.Indent = "\t"
+	pretty, _ = formatter.Format(WithFields(Fields{"foo": "bar"}))
+	if !bytes.Contains(pretty, []byte("\n\t\"foo\"")) {
+		t.Fatal("pretty output expected to be indented with a tab, got", string(pretty))
+	}
+}
+
+func TestJSONRuntimeFields(t *testing.T) {
+	defer SetRuntimeInfo(nil)
+	SetRuntimeInfo(stubRuntimeInfo{pid: 123, tid: 45, os: "plan9"})
+
+	formatter := &JSONFormatter{IncludePID: true, IncludeTID: true, IncludeOS: true}
+	b, err := formatter.Format(WithField("foo", "bar"))
+	if err != nil {
+		t.Fatal("Unable to format entry: ", err)
+	}
+	entry := make(map[string]interface{})
+	if err := json.Unmarshal(b, &entry); err != nil {
+		t.Fatal("Unable to unmarshal formatted entry: ", err)
+	}
+	if entry["process_id"] != 123.0 || entry["thread_id"] != 45.0 || entry["os"] != "plan9" {
+		t.Fatal("process ID, thread ID and OS expected, got", string(b))
+	}
+
+	formatter = &JSONFormatter{IncludePID: true, FieldMap: FieldMap{FieldKeyProcessID: "pid"}}
+	b, _ = formatter.Format(WithField("foo", "bar"))
+	entry = make(map[string]interface{})
+	json.Unmarshal(b, &entry)
+	if entry["pid"] != 123.0 || entry["thread_id"] != nil || entry["os"] != nil {
+		t.Fatal("only the mapped process ID expected, got", string(b))
+	}
+}
+
+// marshalWithoutData formats entry with the data of the general path, and
+// marshals the result back without it as encoding/json would.
+func marshalWithoutData(t *testing.T, formatter *JSONFormatter, entry *Entry) string {
+	withData := *entry
+	withData.Data = Fields{"x": 1}
+	b, err := formatter.Format(&withData)
+	if err != nil {
+		t.Fatal("Unable to format entry: ", err)
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(b, &fields); err != nil {
+		t.Fatal("Unable to unmarshal formatted entry: ", err)
+	}
+	delete(fields, "x")
+	b, err = json.Marshal(fields)
+	if err != nil {
+		t.Fatal("Unable to marshal fields: ", err)
+	}
+	return string(b) + "\n"
+}
+
+func TestJSONFormatterWithoutData(t *testing.T) {
+	formatters := []*JSONFormatter{
+		{},
+		{DisableTimestamp: true},
+		{TimestampFormat: "Jan 2 15:04"},
+		{FieldMap: FieldMap{FieldKeyTime: "@timestamp", FieldKeyMsg: "@message", FieldKeyLevel: "severity"}},
+		{ReportLevelNumber: true, LevelNumbers: SyslogLevelNumbers, IncludePID: true, IncludeTID: true, IncludeOS: true},
+	}
+	messages := []string{"", "plain message", `"quoted" \ <b> & tab	`, "café  "}
+
+	for _, formatter := range formatters {
+		for _, message := range messages {
+			entry := &Entry{Time: time.Unix(1500000000, 250000000).UTC(), Level: WarnLevel, Message: message, Data: Fields{}}
+			b, err := formatter.Format(entry)
+			if err != nil {
+				t.Fatal("Unable to format entry: ", err)
+			}
+			if expected := marshalWithoutData(t, formatter, entry); string(b) != expected {
+				t.Errorf("expected %q, got %q", expected, string(b))
+			}
+		}
+	}
+}
+
+func TestJSONNestDottedKeys(t *testing.T) {
+	formatter := &JSONFormatter{
+		DisableTimestamp: true,
+		NestDottedKeys:   true,
+		FieldMap:         FieldMap{FieldKeyLevel: "log.level"},
+	}
+	entry := &Entry{Level: InfoLevel, Message: "handled", Data: Fields{
+		"http.status":         200,
+		"http.request.method": "GET",
+		"user":                "ada",
+		"user.id":             42,
+		"db.rows":             3,
+		"db.rows.cached":      true,
+		"payload":             map[string]interface{}{"a": 1},
+		"payload.b":           2,
+		"odd..key":            "kept",
+	}}
+
+	b, err := formatter.Format(entry)
+	if err != nil {
+		t.Fatal("Unable to format entry: ", err)
+	}
+	expected := `{"db":{"rows":3},"db.rows.cached":true,"http":{"request":{"method":"GET"},"status":200},` +
+		`"log":{"level":"info"},"msg":"handled","odd..key":"kept","payload":{"a":1},"payload.b":2,"user":"ada","user.id":42}` + "\n"
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+	if len(entry.Data["payload"].(map[string]interface{})) != 1 {
+		t.Error("logged maps must not be modified")
+	}
+
+	entry.Data = Fields{}
+	b, err = formatter.Format(entry)
+	if err != nil {
+		t.Fatal("Unable to format entry: ", err)
+	}
+	if expected := `{"log":{"level":"info"},"msg":"handled"}` + "\n"; string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+}
+
+func TestJSONFlattenNestedFields(t *testing.T) {
+	formatter := &JSONFormatter{DisableTimestamp: true, FlattenNestedFields: true}
+	entry := &Entry{Level: InfoLevel, Message: "handled", Data: Fields{
+		"http":      map[string]interface{}{"status": 200, "request": Fields{"method": "GET"}},
+		"db.rows":   3,
+		"db":        map[string]int{"rows": 4, "calls": 1},
+		"empty":     map[string]string{},
+		"ids":       []int{1, 2},
+		"http.code": "kept",
+	}}
+
+	b, err := formatter.Format(entry)
+	if err != nil {
+		t.Fatal("Unable to format entry: ", err)
+	}
+	expected := `{"db.calls":1,"db.rows":3,"empty":{},"http.code":"kept","http.request.method":"GET","http.status":200,"ids":[1,2],"level":"info","msg":"handled"}` + "\n"
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+
+	formatter.NestDottedKeys = true
+	b, err = formatter.Format(entry)
+	if err != nil {
+		t.Fatal("Unable to format entry: ", err)
+	}
+	expected = `{"db":{"calls":1,"rows":3},"empty":{},"http":{"code":"kept","request":{"method":"GET"},"status":200},"ids":[1,2],"level":"info","msg":"handled"}` + "\n"
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+}
@@ -0,0 +1,44 @@
+package logrus
+
+import "fmt"
+
+// LevelDispatchFormatter formats each entry with the formatter registered for
+// its level, e.g. JSON for the levels consumed by machines and text for the
+// ones read by humans:
+//
+//    logger.Formatter = &LevelDispatchFormatter{
+//      Formatters: map[Level]Formatter{
+//        ErrorLevel: new(JSONFormatter),
+//      },
+//      Default: new(TextFormatter),
+//    }
+//
+// The chosen formatter gets the entry as is, so it still applies its own
+// field handling and terminal detection.
+type LevelDispatchFormatter struct {
+	// Formatters used for specific levels.
+	Formatters map[Level]Formatter
+
+	// Default is used for the levels without a formatter in Formatters.
+	Default Formatter
+}
+
+// Format renders a single log entry with the formatter of its level
+func (f *LevelDispatchFormatter) Format(entry *Entry) ([]byte, error) {
+	if formatter, ok := f.Formatters[entry.Level]; ok && formatter != nil {
+		return formatter.Format(entry)
+	}
+	if f.Default == nil {
+		return nil, fmt.Errorf("No formatter for level %s", entry.Level)
+	}
+	return f.Default.Format(entry)
+}
+
+func (f *LevelDispatchFormatter) reportsEntryID() bool {
+	for _, formatter := range f.Formatters {
+		if reportsEntryID(formatter) {
+			return true
+		}
+	}
+	return reportsEntryID(f.Default)
+}
@@ -0,0 +1,51 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelDispatchFormatter(t *testing.T) {
+	var buffer bytes.Buffer
+
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &LevelDispatchFormatter{
+		Formatters: map[Level]Formatter{
+			ErrorLevel: new(JSONFormatter),
+		},
+		Default: &TextFormatter{DisableColors: true},
+	}
+
+	logger.WithField("foo", "bar").Error("json")
+	logger.WithField("foo", "bar").Info("text")
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	assert.Equal(t, 2, len(lines))
+
+	var fields Fields
+	err := json.Unmarshal([]byte(lines[0]), &fields)
+	assert.Nil(t, err)
+	assert.Equal(t, "json", fields["msg"])
+	assert.Equal(t, "bar", fields["foo"])
+
+	err = json.Unmarshal([]byte(lines[1]), &fields)
+	assert.NotNil(t, err)
+	assert.Contains(t, lines[1], "[info]")
+	assert.Contains(t, lines[1], "bar text")
+}
+
+func TestLevelDispatchFormatterWithoutDefault(t *testing.T) {
+	formatter := &LevelDispatchFormatter{
+		Formatters: map[Level]Formatter{
+			ErrorLevel: new(JSONFormatter),
+		},
+	}
+
+	_, err := formatter.Format(&Entry{Level: InfoLevel})
+	assert.NotNil(t, err)
+}
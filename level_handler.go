@@ -0,0 +1,133 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// maxLevelBodySize is the size in bytes of the largest request body
+// accepted by LevelHandler, far more than any level document.
+const maxLevelBodySize = 1024
+
+// levelDocument is the JSON document served and accepted by LevelHandler.
+type levelDocument struct {
+	Level *Level `json:"level,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// LevelHandler returns an http.Handler exposing the level of logger, so that
+// the verbosity of a running program can be changed without restarting it:
+//
+//    http.Handle("/log/level", logrus.LevelHandler(logger))
+//
+// GET responds with the level as a JSON document, e.g. {"level":"info"}. PUT
+// sets the level from the same document, or from the name of the level as a
+// plain text body, and responds with the new level. Invalid levels, and
+// bodies larger than 1 KiB, are refused with a 400 status and the error in
+// the document.
+func LevelHandler(logger *Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			level, err := readLevel(w, r)
+			if err != nil {
+				writeLevelDocument(w, http.StatusBadRequest, levelDocument{Error: err.Error()})
+				return
+			}
+			logger.SetLevel(level)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			writeLevelDocument(w, http.StatusMethodNotAllowed, levelDocument{Error: "only GET and PUT are supported"})
+			return
+		}
+		level := logger.level()
+		writeLevelDocument(w, http.StatusOK, levelDocument{Level: &level})
+	})
+}
+
+// readLevel reads the level of a PUT request, given as a JSON document or as
+// plain text.
+func readLevel(w http.ResponseWriter, r *http.Request) (Level, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxLevelBodySize))
+	if err != nil {
+		return 0, err
+	}
+	body = bytes.TrimSpace(body)
+	if !bytes.HasPrefix(body, []byte("{")) {
+		return ParseLevel(string(body))
+	}
+
+	var document levelDocument
+	if err := json.Unmarshal(body, &document); err != nil {
+		return 0, err
+	}
+	if document.Level == nil {
+		return 0, fmt.Errorf("missing level")
+	}
+	return *document.Level, nil
+}
+
+func writeLevelDocument(w http.ResponseWriter, status int, document levelDocument) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(document); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write level, %v\n", err)
+	}
+}
+
+// ToggleDebugOnSignal switches logger to DebugLevel whenever the process
+// receives one of signals, SIGHUP by default or none on js, and back to its
+// previous level on the next one. Pass another signal, e.g. syscall.SIGUSR1,
+// when SIGHUP is used to reopen the log files, see `ReopenOnSignal`. The
+// returned function stops handling the signals.
+func ToggleDebugOnSignal(logger *Logger, signals ...os.Signal) func() {
+	if len(signals) == 0 {
+		signals = hangupSignals
+	}
+	if len(signals) == 0 {
+		// signal.Notify would relay all the signals
+		return func() {}
+	}
+
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, signals...)
+	go func() {
+		previous := InfoLevel
+		for {
+			select {
+			case <-c:
+				previous = toggleDebug(logger, previous)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
+
+// toggleDebug switches logger to DebugLevel, or back to previous if it logs
+// debug entries already. It returns the level to switch back to next.
+func toggleDebug(logger *Logger, previous Level) Level {
+	level := logger.level()
+	if level >= DebugLevel {
+		logger.SetLevel(previous)
+		return previous
+	}
+	logger.SetLevel(DebugLevel)
+	return level
+}
@@ -0,0 +1,56 @@
+package logrus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelHandler(t *testing.T) {
+	logger := New()
+	handler := LevelHandler(logger)
+
+	testCases := []struct {
+		method   string
+		body     string
+		status   int
+		response string
+		level    Level
+	}{
+		{"GET", "", http.StatusOK, `{"level":"info"}`, InfoLevel},
+		{"PUT", `{"level":"debug"}`, http.StatusOK, `{"level":"debug"}`, DebugLevel},
+		{"PUT", "warn\n", http.StatusOK, `{"level":"warning"}`, WarnLevel},
+		{"PUT", `{"level":"loud"}`, http.StatusBadRequest, `{"error":"not a valid logrus Level: \"loud\""}`, WarnLevel},
+		{"PUT", `{}`, http.StatusBadRequest, `{"error":"missing level"}`, WarnLevel},
+		{"POST", "error", http.StatusMethodNotAllowed, `{"error":"only GET and PUT are supported"}`, WarnLevel},
+		{"PUT", "debug" + strings.Repeat(" ", maxLevelBodySize), http.StatusBadRequest, `{"error":"http: request body too large"}`, WarnLevel},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tc.method, "/log/level", strings.NewReader(tc.body)))
+
+		assert.Equal(t, tc.status, w.Code, tc.method+" "+tc.body)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.Equal(t, tc.response+"\n", w.Body.String(), tc.method+" "+tc.body)
+		assert.Equal(t, tc.level, logger.level(), tc.method+" "+tc.body)
+	}
+}
+
+func TestToggleDebug(t *testing.T) {
+	logger := New()
+	logger.SetLevel(WarnLevel)
+
+	previous := toggleDebug(logger, InfoLevel)
+	assert.Equal(t, DebugLevel, logger.level())
+	previous = toggleDebug(logger, previous)
+	assert.Equal(t, WarnLevel, logger.level())
+
+	logger.SetLevel(TraceLevel)
+	previous = toggleDebug(logger, previous)
+	assert.Equal(t, WarnLevel, logger.level())
+	assert.Equal(t, WarnLevel, previous)
+}
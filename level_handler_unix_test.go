@@ -0,0 +1,29 @@
+//go:build !windows && !js
+// +build !windows,!js
+
+package logrus
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToggleDebugOnSignal(t *testing.T) {
+	logger := New()
+	logger.SetLevel(ErrorLevel)
+	stop := ToggleDebugOnSignal(logger, syscall.SIGUSR2)
+	defer stop()
+
+	for _, expected := range []Level{DebugLevel, ErrorLevel} {
+		assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+		deadline := time.Now().Add(5 * time.Second)
+		for logger.level() != expected && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, expected, logger.level())
+	}
+}
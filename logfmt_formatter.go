@@ -0,0 +1,168 @@
+package logrus
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// LogfmtFormatter formats logs into strict logfmt, one key=value pair per
+// field, meant to be parsed by machines:
+//
+//    time=2017-07-14T02:40:00Z level=warning msg="disk almost full" disk=/dev/sda1 usage.percent=97
+//
+// Values containing spaces, quotes, equal signs or non printable characters
+// are quoted and escaped as Go strings, and characters which aren't allowed
+// in keys are replaced by underscores. Maps among the fields are flattened,
+// their keys being joined to the key of the field with KeySeparator.
+type LogfmtFormatter struct {
+	// TimestampFormat to use for display, defaults to time.RFC3339.
+	TimestampFormat string
+
+	// DisableTimestamp leaves the time out.
+	DisableTimestamp bool
+
+	// KeySeparator joins the keys of nested maps, defaults to ".".
+	KeySeparator string
+
+	// FieldMap allows users to customize the names of keys for default fields.
+	FieldMap FieldMap
+
+	// ValueFormatters renders the values of the fields of some types, e.g.
+	// durations, see StandardValueFormatters.
+	ValueFormatters ValueFormatters
+}
+
+// Format renders a single log entry
+func (f *LogfmtFormatter) Format(entry *Entry) ([]byte, error) {
+	data := make(Fields, len(entry.Data))
+	MergeFields(data, f.ValueFormatters.formatValues(entry.Data))
+	prefixFieldClashes(data, f.FieldMap, entry.HasCaller())
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	if !f.DisableTimestamp {
+		timestampFormat := f.TimestampFormat
+		if timestampFormat == "" {
+			timestampFormat = time.RFC3339
+		}
+		f.appendPair(b, f.FieldMap.resolve(FieldKeyTime), entry.Time.Format(timestampFormat))
+	}
+	f.appendPair(b, f.FieldMap.resolve(FieldKeyLevel), entry.Level.String())
+	f.appendPair(b, f.FieldMap.resolve(FieldKeyMsg), entry.Message)
+	if entry.HasCaller() {
+		f.appendPair(b, f.FieldMap.resolve(FieldKeyFunc), entry.Caller.Function)
+		f.appendPair(b, f.FieldMap.resolve(FieldKeyFile), fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line))
+	}
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		f.appendField(b, k, data[k])
+	}
+
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// appendField writes the field key, flattening value if it is a map.
+func (f *LogfmtFormatter) appendField(b *bytes.Buffer, key string, value interface{}) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map || v.IsNil() {
+		f.appendPair(b, key, value)
+		return
+	}
+
+	separator := f.KeySeparator
+	if separator == "" {
+		separator = "."
+	}
+	nested := make(map[string]interface{}, v.Len())
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		name := fmt.Sprint(k.Interface())
+		nested[name] = v.MapIndex(k).Interface()
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		f.appendField(b, key+separator+k, nested[k])
+	}
+}
+
+// appendPair writes a key=value pair, preceded by a space unless it is the
+// first one of the line.
+func (f *LogfmtFormatter) appendPair(b *bytes.Buffer, key string, value interface{}) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(logfmtKey(key))
+	b.WriteByte('=')
+
+	var text string
+	switch value := value.(type) {
+	case nil:
+		return
+	case string:
+		text = value
+	case error, fmt.Stringer:
+		// fmt renders the nil pointers implementing them as <nil> rather
+		// than panicking
+		text = fmt.Sprint(value)
+	default:
+		if appendNumber(b, value) {
+			return
+		}
+		text = fmt.Sprint(value)
+	}
+	if logfmtNeedsQuoting(text) {
+		b.WriteString(strconv.Quote(text))
+	} else {
+		b.WriteString(text)
+	}
+}
+
+// logfmtKey returns key with the characters not allowed in logfmt keys,
+// spaces, equal signs, quotes and non printable characters, replaced by
+// underscores.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8RuneError || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// Replacement character, standing for invalid UTF-8.
+const utf8RuneError = '\uFFFD'
+
+// logfmtNeedsQuoting reports whether text must be quoted to be parsed back
+// as is.
+func logfmtNeedsQuoting(text string) bool {
+	if text == "" {
+		return true
+	}
+	for _, r := range text {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
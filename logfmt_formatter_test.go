@@ -0,0 +1,63 @@
+package logrus
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogfmtFormatter(t *testing.T) {
+	formatter := new(LogfmtFormatter)
+	entry := &Entry{
+		Time:    time.Unix(1500000000, 0).UTC(),
+		Level:   WarnLevel,
+		Message: "disk almost full",
+		Data: Fields{
+			"disk":  "/dev/sda1",
+			"usage": map[string]interface{}{"percent": 97, "inodes": Fields{"free": 12}},
+			"error": errors.New("no space"),
+			"level": "clash",
+		},
+	}
+
+	b, err := formatter.Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `time=2017-07-14T02:40:00Z level=warning msg="disk almost full" disk=/dev/sda1 error="no space" fields.level=clash usage.inodes.free=12 usage.percent=97`+"\n", string(b))
+}
+
+func TestLogfmtQuoting(t *testing.T) {
+	formatter := &LogfmtFormatter{DisableTimestamp: true, KeySeparator: "_"}
+
+	testCases := []struct {
+		key      string
+		value    interface{}
+		expected string
+	}{
+		{"plain", "abc", "plain=abc"},
+		{"empty", "", `empty=""`},
+		{"nil", nil, "nil="},
+		{"space", "a b", `space="a b"`},
+		{"equal", "a=b", `equal="a=b"`},
+		{"quote", `say "hi"`, `quote="say \"hi\""`},
+		{"backslash", `C:\logs`, `backslash="C:\\logs"`},
+		{"newline", "a\nb", `newline="a\nb"`},
+		{"control", "a\x1bb", `control="a\x1bb"`},
+		{"unicode", "héllo", "unicode=héllo"},
+		{"bad key=\"x\"", 1, "bad_key__x_=1"},
+		{"", 1, "_=1"},
+		{"nested", map[string]int{"a": 1}, "nested_a=1"},
+		{"float", 1.5, "float=1.5"},
+		{"duration", time.Second, "duration=1s"},
+		{"nil_stringer", (*url.URL)(nil), "nil_stringer=<nil>"},
+		{"nil_error", (*os.PathError)(nil), "nil_error=<nil>"},
+	}
+
+	for _, tc := range testCases {
+		b, _ := formatter.Format(&Entry{Level: InfoLevel, Message: "m", Data: Fields{tc.key: tc.value}})
+		assert.Equal(t, "level=info msg=m "+tc.expected+"\n", string(b), "key %q", tc.key)
+	}
+}
@@ -1,13 +1,19 @@
 package logrus
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"os"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type Logger struct {
+	// Sequence number of the last entry written, see `ReportSequence`. First
+	// so that it is 64-bit aligned for atomic operations on 32-bit platforms.
+	sequence uint64
 	// The logs are `io.Copy`'d to this in a mutex. It's common to set this to a
 	// file, or leave it default which is `os.Stderr`. You can also set this to
 	// something more adventorous, such as logging to Kafka.
@@ -27,11 +33,94 @@ type Logger struct {
 	// to) `logrus.Info`, which allows Info(), Warn(), Error() and Fatal() to be
 	// logged.
 	Level Level
+	// Name identifies the logger, e.g. a subsystem such as "db". Named loggers
+	// can be muted centrally with `SetQuiet` and their level set centrally
+	// with `SetLevels`, see `Named`.
+	Name string
+	// ReportSequence adds a sequence number, strictly increasing from 1, to
+	// the entries written by the logger under the key defined in SequenceKey.
+	// It allows restoring the order of entries fanned out to several
+	// asynchronous outputs.
+	ReportSequence bool
+	// CallerSkip is the number of frames of logging wrappers to skip, in
+	// addition to the ones of logrus, when reporting the caller of an entry,
+	// e.g. 1 for entries logged through a helper calling the logger.
+	CallerSkip int
+	// ContextExtractor returns the request-scoped fields, such as a request
+	// ID, carried by the context of the entries added with `WithContext`.
+	ContextExtractor func(ctx context.Context) Fields
+	// ExitFunc terminates the program on Fatal, after the exit handlers ran,
+	// see `Exit`. It defaults to os.Exit, tests can replace it to check fatal
+	// entries without exiting.
+	ExitFunc func(code int)
+	// ErrorCauses makes `WithError` add the messages of the errors wrapped by
+	// the error, as returned by their Unwrap or Cause methods, under the
+	// keys ErrorKey followed by ErrorCauseKey, e.g. "error.cause" and
+	// "error.cause.cause" for the cause of the cause.
+	ErrorCauses bool
+	// ErrorStackTrace makes `WithError` add the stack trace of the error
+	// under the key ErrorKey followed by ErrorStackKey, e.g. "error.stack".
+	// It is the one of the innermost error carrying one, as the errors of
+	// github.com/pkg/errors, or else the stack of the caller of WithError.
+	ErrorStackTrace bool
 	// Used to sync writing to the log. Locking is enabled by Default
 	mu MutexWrap
+	// The logger named loggers are derived from, whose lock, queue and
+	// sequence number they write with, see `Named`
+	root *Logger
 	// Reusable empty entry
 	entryPool sync.Pool
-}
+	// Writers overriding Out for specific levels, see `SetLevelOutput`
+	levelOutputs atomic.Value
+	// Additional outputs, see `AddOutput`
+	outputs atomic.Value
+	// Caps the number of entries written per second, see `SetRateLimit`
+	rateLimit atomic.Value
+	// Queues the entries written asynchronously, see `SetAsync`
+	async atomic.Value
+	// Decides which entries are kept, see `SetSampler`
+	sample atomic.Value
+	// Masks secrets in the entries, see `SetRedactor`
+	redactor atomic.Value
+	// Handles the errors of the hooks, see `SetHookErrorHandler`
+	hookErrorHandler atomic.Value
+	// Set while the logger is paused, see `SetPaused`
+	paused uint32
+	// Set while the caller of the entries is reported, see `SetReportCaller`
+	reportCaller uint32
+	// What to do when writing to the output fails, see `SetOnWriteError`
+	onWriteError   WriteErrorPolicy
+	writeErrorOnce sync.Once
+}
+
+// Exit runs the exit handlers registered with `RegisterExitHandler` and
+// writes the entries queued by an asynchronous logger, then terminates the
+// program with ExitFunc, os.Exit by default. Fatal entries call it once
+// logged.
+func (logger *Logger) Exit(code int) {
+	runHandlers()
+	logger.Flush()
+	exit := logger.ExitFunc
+	if exit == nil {
+		exit = os.Exit
+	}
+	exit(code)
+}
+
+// WriteErrorPolicy tells a logger what to do when writing an entry to its
+// output fails.
+type WriteErrorPolicy uint32
+
+const (
+	// WriteErrorDrop drops the entry, only the first failure is reported on
+	// stderr. This is the default.
+	WriteErrorDrop WriteErrorPolicy = iota
+	// WriteErrorFallback writes the entry to stderr instead.
+	WriteErrorFallback
+	// WriteErrorPanic panics with the write error, which helps catching
+	// misconfigured outputs early in development.
+	WriteErrorPanic
+)
 
 type MutexWrap struct {
 	lock     sync.Mutex
@@ -76,14 +165,31 @@ func New() *Logger {
 }
 
 func (logger *Logger) newEntry() *Entry {
-	entry, ok := logger.entryPool.Get().(*Entry)
-	if ok {
-		return entry
+	return newPooledEntry(logger, 5)
+}
+
+// newPooledEntry returns an empty entry of logger, reusing one released to
+// its pool if there is one, or else allocating one with room for size fields.
+func newPooledEntry(logger *Logger, size int) *Entry {
+	if logger != nil {
+		if entry, ok := logger.entryPool.Get().(*Entry); ok {
+			return entry
+		}
 	}
-	return NewEntry(logger)
+	return &Entry{Logger: logger, Data: make(Fields, size)}
 }
 
 func (logger *Logger) releaseEntry(entry *Entry) {
+	// The fields of the entry must not show up on the next one, and entries
+	// created without data get some
+	data := entry.Data
+	for k := range data {
+		delete(data, k)
+	}
+	if data == nil {
+		data = make(Fields, 5)
+	}
+	*entry = Entry{Logger: logger, Data: data}
 	logger.entryPool.Put(entry)
 }
 
@@ -91,29 +197,44 @@ func (logger *Logger) releaseEntry(entry *Entry) {
 // Debug, Print, Info, Warn, Error, Fatal or Panic. It only creates a log entry.
 // If you want multiple fields, use `WithFields`.
 func (logger *Logger) WithField(key string, value interface{}) *Entry {
-	entry := logger.newEntry()
-	defer logger.releaseEntry(entry)
-	return entry.WithField(key, value)
+	entry := newPooledEntry(logger, 1)
+	entry.Data[key] = value
+	return entry
 }
 
 // Adds a struct of fields to the log entry. All it does is call `WithField` for
 // each `Field`.
 func (logger *Logger) WithFields(fields Fields) *Entry {
+	entry := newPooledEntry(logger, len(fields))
+	MergeFields(entry.Data, fields)
+	return entry
+}
+
+// Adds a context to the log entry, see `ContextExtractor`.
+func (logger *Logger) WithContext(ctx context.Context) *Entry {
 	entry := logger.newEntry()
-	defer logger.releaseEntry(entry)
-	return entry.WithFields(fields)
+	entry.Context = ctx
+	return entry
 }
 
 // Add an error as single field to the log entry.  All it does is call
 // `WithError` for the given `error`.
 func (logger *Logger) WithError(err error) *Entry {
-	entry := logger.newEntry()
-	defer logger.releaseEntry(entry)
-	return entry.WithError(err)
+	entry := logger.WithField(ErrorKey, err)
+	MergeFields(entry.Data, errorDetails(logger, err))
+	return entry
+}
+
+func (logger *Logger) Tracef(format string, args ...interface{}) {
+	if logger.IsLevelEnabled(TraceLevel) {
+		entry := logger.newEntry()
+		entry.Tracef(format, args...)
+		logger.releaseEntry(entry)
+	}
 }
 
 func (logger *Logger) Debugf(format string, args ...interface{}) {
-	if logger.level() >= DebugLevel {
+	if logger.IsLevelEnabled(DebugLevel) {
 		entry := logger.newEntry()
 		entry.Debugf(format, args...)
 		logger.releaseEntry(entry)
@@ -121,7 +242,7 @@ func (logger *Logger) Debugf(format string, args ...interface{}) {
 }
 
 func (logger *Logger) Infof(format string, args ...interface{}) {
-	if logger.level() >= InfoLevel {
+	if logger.IsLevelEnabled(InfoLevel) {
 		entry := logger.newEntry()
 		entry.Infof(format, args...)
 		logger.releaseEntry(entry)
@@ -135,7 +256,7 @@ func (logger *Logger) Printf(format string, args ...interface{}) {
 }
 
 func (logger *Logger) Warnf(format string, args ...interface{}) {
-	if logger.level() >= WarnLevel {
+	if logger.IsLevelEnabled(WarnLevel) {
 		entry := logger.newEntry()
 		entry.Warnf(format, args...)
 		logger.releaseEntry(entry)
@@ -143,7 +264,7 @@ func (logger *Logger) Warnf(format string, args ...interface{}) {
 }
 
 func (logger *Logger) Warningf(format string, args ...interface{}) {
-	if logger.level() >= WarnLevel {
+	if logger.IsLevelEnabled(WarnLevel) {
 		entry := logger.newEntry()
 		entry.Warnf(format, args...)
 		logger.releaseEntry(entry)
@@ -151,7 +272,7 @@ func (logger *Logger) Warningf(format string, args ...interface{}) {
 }
 
 func (logger *Logger) Errorf(format string, args ...interface{}) {
-	if logger.level() >= ErrorLevel {
+	if logger.IsLevelEnabled(ErrorLevel) {
 		entry := logger.newEntry()
 		entry.Errorf(format, args...)
 		logger.releaseEntry(entry)
@@ -159,24 +280,30 @@ func (logger *Logger) Errorf(format string, args ...interface{}) {
 }
 
 func (logger *Logger) Fatalf(format string, args ...interface{}) {
-	if logger.level() >= FatalLevel {
+	// The entry exits even if the level is disabled
+	entry := logger.newEntry()
+	entry.Fatalf(format, args...)
+	logger.releaseEntry(entry)
+}
+
+func (logger *Logger) Panicf(format string, args ...interface{}) {
+	if logger.IsLevelEnabled(PanicLevel) {
 		entry := logger.newEntry()
-		entry.Fatalf(format, args...)
+		entry.Panicf(format, args...)
 		logger.releaseEntry(entry)
 	}
-	Exit(1)
 }
 
-func (logger *Logger) Panicf(format string, args ...interface{}) {
-	if logger.level() >= PanicLevel {
+func (logger *Logger) Trace(args ...interface{}) {
+	if logger.IsLevelEnabled(TraceLevel) {
 		entry := logger.newEntry()
-		entry.Panicf(format, args...)
+		entry.Trace(args...)
 		logger.releaseEntry(entry)
 	}
 }
 
 func (logger *Logger) Debug(args ...interface{}) {
-	if logger.level() >= DebugLevel {
+	if logger.IsLevelEnabled(DebugLevel) {
 		entry := logger.newEntry()
 		entry.Debug(args...)
 		logger.releaseEntry(entry)
@@ -184,7 +311,7 @@ func (logger *Logger) Debug(args ...interface{}) {
 }
 
 func (logger *Logger) Info(args ...interface{}) {
-	if logger.level() >= InfoLevel {
+	if logger.IsLevelEnabled(InfoLevel) {
 		entry := logger.newEntry()
 		entry.Info(args...)
 		logger.releaseEntry(entry)
@@ -198,7 +325,7 @@ func (logger *Logger) Print(args ...interface{}) {
 }
 
 func (logger *Logger) Warn(args ...interface{}) {
-	if logger.level() >= WarnLevel {
+	if logger.IsLevelEnabled(WarnLevel) {
 		entry := logger.newEntry()
 		entry.Warn(args...)
 		logger.releaseEntry(entry)
@@ -206,7 +333,7 @@ func (logger *Logger) Warn(args ...interface{}) {
 }
 
 func (logger *Logger) Warning(args ...interface{}) {
-	if logger.level() >= WarnLevel {
+	if logger.IsLevelEnabled(WarnLevel) {
 		entry := logger.newEntry()
 		entry.Warn(args...)
 		logger.releaseEntry(entry)
@@ -214,7 +341,7 @@ func (logger *Logger) Warning(args ...interface{}) {
 }
 
 func (logger *Logger) Error(args ...interface{}) {
-	if logger.level() >= ErrorLevel {
+	if logger.IsLevelEnabled(ErrorLevel) {
 		entry := logger.newEntry()
 		entry.Error(args...)
 		logger.releaseEntry(entry)
@@ -222,24 +349,30 @@ func (logger *Logger) Error(args ...interface{}) {
 }
 
 func (logger *Logger) Fatal(args ...interface{}) {
-	if logger.level() >= FatalLevel {
+	// The entry exits even if the level is disabled
+	entry := logger.newEntry()
+	entry.Fatal(args...)
+	logger.releaseEntry(entry)
+}
+
+func (logger *Logger) Panic(args ...interface{}) {
+	if logger.IsLevelEnabled(PanicLevel) {
 		entry := logger.newEntry()
-		entry.Fatal(args...)
+		entry.Panic(args...)
 		logger.releaseEntry(entry)
 	}
-	Exit(1)
 }
 
-func (logger *Logger) Panic(args ...interface{}) {
-	if logger.level() >= PanicLevel {
+func (logger *Logger) Traceln(args ...interface{}) {
+	if logger.IsLevelEnabled(TraceLevel) {
 		entry := logger.newEntry()
-		entry.Panic(args...)
+		entry.Traceln(args...)
 		logger.releaseEntry(entry)
 	}
 }
 
 func (logger *Logger) Debugln(args ...interface{}) {
-	if logger.level() >= DebugLevel {
+	if logger.IsLevelEnabled(DebugLevel) {
 		entry := logger.newEntry()
 		entry.Debugln(args...)
 		logger.releaseEntry(entry)
@@ -247,7 +380,7 @@ func (logger *Logger) Debugln(args ...interface{}) {
 }
 
 func (logger *Logger) Infoln(args ...interface{}) {
-	if logger.level() >= InfoLevel {
+	if logger.IsLevelEnabled(InfoLevel) {
 		entry := logger.newEntry()
 		entry.Infoln(args...)
 		logger.releaseEntry(entry)
@@ -261,7 +394,7 @@ func (logger *Logger) Println(args ...interface{}) {
 }
 
 func (logger *Logger) Warnln(args ...interface{}) {
-	if logger.level() >= WarnLevel {
+	if logger.IsLevelEnabled(WarnLevel) {
 		entry := logger.newEntry()
 		entry.Warnln(args...)
 		logger.releaseEntry(entry)
@@ -269,7 +402,7 @@ func (logger *Logger) Warnln(args ...interface{}) {
 }
 
 func (logger *Logger) Warningln(args ...interface{}) {
-	if logger.level() >= WarnLevel {
+	if logger.IsLevelEnabled(WarnLevel) {
 		entry := logger.newEntry()
 		entry.Warnln(args...)
 		logger.releaseEntry(entry)
@@ -277,7 +410,7 @@ func (logger *Logger) Warningln(args ...interface{}) {
 }
 
 func (logger *Logger) Errorln(args ...interface{}) {
-	if logger.level() >= ErrorLevel {
+	if logger.IsLevelEnabled(ErrorLevel) {
 		entry := logger.newEntry()
 		entry.Errorln(args...)
 		logger.releaseEntry(entry)
@@ -285,16 +418,14 @@ func (logger *Logger) Errorln(args ...interface{}) {
 }
 
 func (logger *Logger) Fatalln(args ...interface{}) {
-	if logger.level() >= FatalLevel {
-		entry := logger.newEntry()
-		entry.Fatalln(args...)
-		logger.releaseEntry(entry)
-	}
-	Exit(1)
+	// The entry exits even if the level is disabled
+	entry := logger.newEntry()
+	entry.Fatalln(args...)
+	logger.releaseEntry(entry)
 }
 
 func (logger *Logger) Panicln(args ...interface{}) {
-	if logger.level() >= PanicLevel {
+	if logger.IsLevelEnabled(PanicLevel) {
 		entry := logger.newEntry()
 		entry.Panicln(args...)
 		logger.releaseEntry(entry)
@@ -308,14 +439,345 @@ func (logger *Logger) SetNoLock() {
 	logger.mu.Disable()
 }
 
+// level returns the level of the logger, the one set with `SetLevels` for its
+// name if there is one.
 func (logger *Logger) level() Level {
+	if logger.Name != "" {
+		if level, ok := namedLevel(logger.Name); ok {
+			return level
+		}
+	}
 	return Level(atomic.LoadUint32((*uint32)(&logger.Level)))
 }
 
+// IsLevelEnabled reports whether entries of the given level are logged, so
+// that costly fields can be computed only when needed:
+//
+//    if logger.IsLevelEnabled(logrus.DebugLevel) {
+//      logger.WithField("state", dumpState()).Debug("state dumped")
+//    }
+func (logger *Logger) IsLevelEnabled(level Level) bool {
+	return logger.level() >= level
+}
+
 func (logger *Logger) SetLevel(level Level) {
 	atomic.StoreUint32((*uint32)(&logger.Level), uint32(level))
 }
 
+// SetFormatter sets the logger formatter. A FieldMap of the formatter mapping
+// several default fields to the same key is reported on stderr, since one of
+// them would otherwise silently be lost on every entry.
+func (logger *Logger) SetFormatter(formatter Formatter) {
+	if mapper, ok := formatter.(fieldMapper); ok {
+		if err := mapper.fieldMap().Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid formatter FieldMap, %v\n", err)
+		}
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.Formatter = formatter
+}
+
+// SetOnWriteError sets what the logger does when writing an entry to its
+// output fails.
+func (logger *Logger) SetOnWriteError(policy WriteErrorPolicy) {
+	atomic.StoreUint32((*uint32)(&logger.onWriteError), uint32(policy))
+}
+
+// handleWriteError applies the write error policy to a failed write of
+// serialized. It is called with the core of the logger locked.
+func (logger *Logger) handleWriteError(serialized []byte, err error) {
+	switch WriteErrorPolicy(atomic.LoadUint32((*uint32)(&logger.onWriteError))) {
+	case WriteErrorFallback:
+		os.Stderr.Write(serialized)
+	case WriteErrorPanic:
+		panic(fmt.Errorf("Failed to write to log, %v", err))
+	default:
+		logger.writeErrorOnce.Do(func() {
+			fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+		})
+	}
+}
+
+// SetLevelOutput routes entries of the given level to w instead of `Out`, e.g.
+// to send errors to stderr while everything else goes to stdout:
+//
+//    logger.Out = os.Stdout
+//    for _, level := range []Level{ErrorLevel, FatalLevel, PanicLevel} {
+//      logger.SetLevelOutput(level, os.Stderr)
+//    }
+//
+// Passing a nil writer removes the override so the level falls back to `Out`.
+func (logger *Logger) SetLevelOutput(level Level, w io.Writer) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	old, _ := logger.levelOutputs.Load().(map[Level]io.Writer)
+	outputs := make(map[Level]io.Writer, len(old)+1)
+	for l, out := range old {
+		outputs[l] = out
+	}
+	if w == nil {
+		delete(outputs, level)
+	} else {
+		outputs[level] = w
+	}
+	logger.levelOutputs.Store(outputs)
+}
+
+// output is an additional destination of the entries of a logger.
+type output struct {
+	formatter Formatter
+	levels    map[Level]bool
+	// Logger standing for the output, so that formatters look at this
+	// output rather than the one of the logger of the entry.
+	logger *Logger
+}
+
+// AddOutput writes the entries of the given levels, or of all the levels if
+// none is given, to w in addition to `Out`, formatted with formatter, e.g.
+// errors as text to stderr while everything goes to a JSON file:
+//
+//    logger.Out = file
+//    logger.Formatter = new(logrus.JSONFormatter)
+//    logger.AddOutput(os.Stderr, new(logrus.TextFormatter), logrus.ErrorLevel, logrus.FatalLevel, logrus.PanicLevel)
+//
+// A nil formatter uses the formatter of the logger. The entries are
+// formatted for each output separately, so the TextFormatter colors them
+// only for the outputs which are terminals. Set `Out` to ioutil.Discard to
+// only write to the added outputs.
+func (logger *Logger) AddOutput(w io.Writer, formatter Formatter, levels ...Level) {
+	o := &output{
+		formatter: formatter,
+		logger: &Logger{
+			Out:       w,
+			Formatter: formatter,
+			Hooks:     make(LevelHooks),
+			Level:     TraceLevel,
+		},
+	}
+	if len(levels) > 0 {
+		o.levels = make(map[Level]bool, len(levels))
+		for _, level := range levels {
+			o.levels[level] = true
+		}
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	old := logger.extraOutputs()
+	outputs := make([]*output, len(old), len(old)+1)
+	copy(outputs, old)
+	logger.outputs.Store(append(outputs, o))
+}
+
+func (logger *Logger) extraOutputs() []*output {
+	outputs, _ := logger.outputs.Load().([]*output)
+	return outputs
+}
+
+// SetPaused pauses or resumes the logger. While paused, entries are dropped
+// before hooks are fired and before being formatted, regardless of the level.
+func (logger *Logger) SetPaused(paused bool) {
+	var value uint32
+	if paused {
+		value = 1
+	}
+	atomic.StoreUint32(&logger.paused, value)
+}
+
+// IsPaused reports whether the logger is paused, see `SetPaused`.
+func (logger *Logger) IsPaused() bool {
+	return atomic.LoadUint32(&logger.paused) == 1
+}
+
+// SetReportCaller sets whether the function, file and line logging each
+// entry are reported, in Entry.Caller, for the formatters to print them. The
+// frames of logrus and the additional ones set with CallerSkip are skipped.
+// This walks the stack for every entry, which has a cost.
+func (logger *Logger) SetReportCaller(reportCaller bool) {
+	var value uint32
+	if reportCaller {
+		value = 1
+	}
+	atomic.StoreUint32(&logger.reportCaller, value)
+}
+
+// ReportsCaller reports whether the logger reports the caller of the
+// entries, see `SetReportCaller`.
+func (logger *Logger) ReportsCaller() bool {
+	return atomic.LoadUint32(&logger.reportCaller) == 1
+}
+
+// SetRateLimit caps the number of entries written by the logger to perSecond
+// per second, with bursts of up to perSecond entries, to protect a shared log
+// sink. Excess entries are dropped before being formatted, unless policy is
+// Block, in which case logging waits for the rate to allow them. DropOldest
+// behaves as DropNewest since entries aren't queued. Fatal and panic entries
+// are never dropped. The number of entries dropped is reported in the
+// DroppedKey field of a warning written before the next entry let through,
+// or a second after the first one dropped if none was let through by then.
+// A perSecond of 0 removes the limit.
+func (logger *Logger) SetRateLimit(perSecond int, policy OverflowPolicy) {
+	var limiter *rateLimiter
+	if perSecond > 0 {
+		limiter = newRateLimiter(perSecond, policy, logger.writeDropped)
+	}
+	logger.rateLimit.Store(limiter)
+}
+
+// writeDropped writes the warning reporting the entries dropped by the rate
+// limit, see `SetRateLimit`.
+func (logger *Logger) writeDropped(dropped uint64, t time.Time) {
+	summary := &Entry{
+		Logger:  logger,
+		Data:    Fields{DroppedKey: dropped},
+		Time:    t,
+		Level:   WarnLevel,
+		Message: "rate limit exceeded, entries dropped",
+	}
+	summary.output()
+}
+
+func (logger *Logger) rateLimiter() *rateLimiter {
+	limiter, _ := logger.rateLimit.Load().(*rateLimiter)
+	return limiter
+}
+
+// SetSampler sets the sampler deciding which entries the logger keeps, e.g.
+// to cut the volume of identical warnings. Entries are sampled before the
+// hooks are fired and before being formatted. Fatal and panic entries are
+// always kept. A nil sampler keeps all the entries.
+func (logger *Logger) SetSampler(sampler Sampler) {
+	logger.sample.Store(samplerHolder{sampler})
+}
+
+// samplerHolder wraps the sampler of a logger, atomic.Value needing the
+// same concrete type to be stored every time.
+type samplerHolder struct {
+	Sampler
+}
+
+func (logger *Logger) sampler() Sampler {
+	holder, _ := logger.sample.Load().(samplerHolder)
+	return holder.Sampler
+}
+
+// SetRedactor sets the redactor masking secrets and personal data in the
+// fields and the message of the entries. Entries are redacted before the
+// hooks are fired, so that neither the hooks nor the formatters see the
+// secrets. Fields added by hooks aren't redacted. A nil redactor disables
+// redaction.
+func (logger *Logger) SetRedactor(redactor *Redactor) {
+	logger.redactor.Store(redactor)
+}
+
+func (logger *Logger) getRedactor() *Redactor {
+	redactor, _ := logger.redactor.Load().(*Redactor)
+	return redactor
+}
+
+// SetHookErrorHandler sets the function called with the errors returned by
+// the hooks and the entry they were fired with, e.g. to count the failures
+// or to fall back to another destination. The hooks following a failing one
+// are still fired. The handler is called without holding the lock of the
+// logger, so it may log. By default the errors are printed on stderr, a nil
+// handler restores this.
+func (logger *Logger) SetHookErrorHandler(handler func(err error, entry *Entry)) {
+	logger.hookErrorHandler.Store(handler)
+}
+
+func (logger *Logger) handleHookError(err error, entry *Entry) {
+	if handler, _ := logger.hookErrorHandler.Load().(func(error, *Entry)); handler != nil {
+		handler(err, entry)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+}
+
+// SetAsync makes the logger write the formatted entries asynchronously: they
+// are queued in a buffer of bufferSize entries, applying policy when it is
+// full, and written to the output of their level by a background goroutine.
+// This keeps a slow output from stalling the logging goroutines. Errors
+// writing to the output are reported on stderr.
+//
+// Entries still queued are lost if the program exits without calling `Flush`
+// or `Close`, except for fatal and panic entries which are flushed. A
+// bufferSize of 0 flushes the queue and makes the logger synchronous again.
+// Named loggers share the queue of the logger they are derived from.
+func (logger *Logger) SetAsync(bufferSize int, policy OverflowPolicy) {
+	var w *AsyncWriter
+	if bufferSize > 0 {
+		w = NewAsyncWriter(nil, bufferSize, policy)
+	}
+	core := logger.core()
+	core.mu.Lock()
+	old := core.asyncWriter()
+	core.async.Store(w)
+	core.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+}
+
+// Flush waits until the entries queued so far by an asynchronous logger are
+// written, see `SetAsync`, and the ones queued by its hooks having a Flush
+// method, such as `AsyncHook`, are fired. It returns immediately for
+// synchronous loggers without such hooks.
+func (logger *Logger) Flush() {
+	if w := logger.core().asyncWriter(); w != nil {
+		w.Flush()
+	}
+
+	// Flushed without holding the lock, which their background goroutines
+	// may need to log
+	var flushers []interface{ Flush() }
+	logger.mu.Lock()
+	for _, hooks := range logger.Hooks {
+		for _, hook := range hooks {
+			if f, ok := hook.(interface{ Flush() }); ok {
+				flushers = append(flushers, f)
+			}
+		}
+	}
+	logger.mu.Unlock()
+	for _, f := range flushers {
+		f.Flush()
+	}
+}
+
+// Close writes the entries queued by an asynchronous logger and stops its
+// background goroutine, the logger is synchronous afterwards. The outputs
+// aren't closed.
+func (logger *Logger) Close() error {
+	logger.SetAsync(0, Block)
+	return nil
+}
+
+// core returns the logger whose lock, queue and sequence number the entries
+// of logger are written with, the one named loggers are derived from.
+func (logger *Logger) core() *Logger {
+	if logger.root != nil {
+		return logger.root
+	}
+	return logger
+}
+
+func (logger *Logger) asyncWriter() *AsyncWriter {
+	w, _ := logger.async.Load().(*AsyncWriter)
+	return w
+}
+
+// levelOutput returns the writer entries of the given level are written to.
+func (logger *Logger) levelOutput(level Level) io.Writer {
+	if outputs, ok := logger.levelOutputs.Load().(map[Level]io.Writer); ok {
+		if out, ok := outputs[level]; ok {
+			return out
+		}
+	}
+	return logger.Out
+}
+
 func (logger *Logger) AddHook(hook Hook) {
 	logger.mu.Lock()
 	defer logger.mu.Unlock()
@@ -0,0 +1,25 @@
+// +build !race
+
+package logrus
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+// The race detector allocates, these tests only run without it.
+
+func TestLoggingWithoutDataDoesNotAllocate(t *testing.T) {
+	for _, formatter := range []Formatter{&TextFormatter{DisableColors: true}, &JSONFormatter{}} {
+		logger := New()
+		logger.Out = ioutil.Discard
+		logger.Formatter = formatter
+
+		allocs := testing.AllocsPerRun(100, func() {
+			logger.Info("no fields")
+		})
+		if allocs != 0 {
+			t.Errorf("%T: expected no allocation, got %v", formatter, allocs)
+		}
+	}
+}
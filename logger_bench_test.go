@@ -1,6 +1,8 @@
 package logrus
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
 	"testing"
 )
@@ -59,3 +61,77 @@ func doLoggerBenchmarkNoLock(b *testing.B, out *os.File, formatter Formatter, fi
 		}
 	})
 }
+
+// expensiveFields stands for fields costly to compute, which are wasted when
+// the entry is filtered out by the level.
+func expensiveFields() Fields {
+	fields := make(Fields, len(largeFields))
+	for k, v := range largeFields {
+		fields[k] = fmt.Sprint(v)
+	}
+	return fields
+}
+
+func BenchmarkSuppressedDebug(b *testing.B) {
+	logger := New()
+	logger.Out = ioutil.Discard
+	for i := 0; i < b.N; i++ {
+		logger.WithFields(expensiveFields()).Debug("suppressed")
+	}
+}
+
+func BenchmarkSuppressedDebugGuarded(b *testing.B) {
+	logger := New()
+	logger.Out = ioutil.Discard
+	for i := 0; i < b.N; i++ {
+		if logger.IsLevelEnabled(DebugLevel) {
+			logger.WithFields(expensiveFields()).Debug("suppressed")
+		}
+	}
+}
+
+// BenchmarkWithFields measures an entry created for a single log call, compare
+// with BenchmarkWithFieldsReleased.
+func BenchmarkWithFields(b *testing.B) {
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.Formatter = new(JSONFormatter)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.WithFields(smallFields).Info("handled")
+	}
+}
+
+// BenchmarkWithFieldsReleased measures the allocations saved by releasing the
+// entries, see Entry.Release.
+func BenchmarkWithFieldsReleased(b *testing.B) {
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.Formatter = new(JSONFormatter)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		entry := logger.WithFields(smallFields)
+		entry.Info("handled")
+		entry.Release()
+	}
+}
+
+func BenchmarkTextWithoutFields(b *testing.B) {
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.Formatter = &TextFormatter{DisableColors: true}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Info("handled")
+	}
+}
+
+func BenchmarkJSONWithoutFields(b *testing.B) {
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.Formatter = new(JSONFormatter)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Info("handled")
+	}
+}
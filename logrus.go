@@ -9,12 +9,22 @@ import (
 // Fields type, used to pass to `WithFields`.
 type Fields map[string]interface{}
 
+// MergeFields copies the fields of src into dst, the values of src override
+// the ones of dst for the keys in both. dst must not be nil.
+func MergeFields(dst, src Fields) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
 // Level type
 type Level uint32
 
 // Convert the Level to a string. E.g. PanicLevel becomes "panic".
 func (level Level) String() string {
 	switch level {
+	case TraceLevel:
+		return "trace"
 	case DebugLevel:
 		return "debug"
 	case InfoLevel:
@@ -33,13 +43,14 @@ func (level Level) String() string {
 }
 
 // ParseLevel takes a string level and returns the Logrus log level constant.
+// It is case insensitive and accepts the "warn" and "err" aliases.
 func ParseLevel(lvl string) (Level, error) {
-	switch strings.ToLower(lvl) {
+	switch strings.ToLower(strings.TrimSpace(lvl)) {
 	case "panic":
 		return PanicLevel, nil
 	case "fatal":
 		return FatalLevel, nil
-	case "error":
+	case "error", "err":
 		return ErrorLevel, nil
 	case "warn", "warning":
 		return WarnLevel, nil
@@ -47,12 +58,37 @@ func ParseLevel(lvl string) (Level, error) {
 		return InfoLevel, nil
 	case "debug":
 		return DebugLevel, nil
+	case "trace":
+		return TraceLevel, nil
 	}
 
 	var l Level
 	return l, fmt.Errorf("not a valid logrus Level: %q", lvl)
 }
 
+// MarshalText implements encoding.TextMarshaler, so that levels can be
+// written to configuration files, e.g. in JSON or YAML.
+func (level Level) MarshalText() ([]byte, error) {
+	switch level {
+	case TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel, PanicLevel:
+		return []byte(level.String()), nil
+	}
+
+	return nil, fmt.Errorf("not a valid logrus Level: %d", level)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the names
+// ParseLevel does.
+func (level *Level) UnmarshalText(text []byte) error {
+	l, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+
+	*level = l
+	return nil
+}
+
 // A constant exposing all logging levels
 var AllLevels = []Level{
 	PanicLevel,
@@ -61,6 +97,7 @@ var AllLevels = []Level{
 	WarnLevel,
 	InfoLevel,
 	DebugLevel,
+	TraceLevel,
 }
 
 // These are the different logging levels. You can set the logging level to log
@@ -69,7 +106,7 @@ const (
 	// PanicLevel level, highest level of severity. Logs and then calls panic with the
 	// message passed to Debug, Info, ...
 	PanicLevel Level = iota
-	// FatalLevel level. Logs and then calls `os.Exit(1)`. It will exit even if the
+	// FatalLevel level. Logs and then calls `logger.Exit(1)`. It will exit even if the
 	// logging level is set to Panic.
 	FatalLevel
 	// ErrorLevel level. Logs. Used for errors that should definitely be noted.
@@ -82,6 +119,8 @@ const (
 	InfoLevel
 	// DebugLevel level. Usually only enabled when debugging. Very verbose logging.
 	DebugLevel
+	// TraceLevel level. Designates finer-grained informational events than the Debug.
+	TraceLevel
 )
 
 // Won't compile if StdLogger can't be realized by a log.Logger
@@ -89,6 +128,9 @@ var (
 	_ StdLogger = &log.Logger{}
 	_ StdLogger = &Entry{}
 	_ StdLogger = &Logger{}
+
+	_ Ext1FieldLogger = &Entry{}
+	_ Ext1FieldLogger = &Logger{}
 )
 
 // StdLogger is what your logrus-enabled library should take, that way
@@ -141,3 +183,13 @@ type FieldLogger interface {
 	Fatalln(args ...interface{})
 	Panicln(args ...interface{})
 }
+
+// Ext1FieldLogger (the first extension to FieldLogger) is implemented by
+// the Entry and Logger types, adding the Trace level to FieldLogger without
+// breaking its other implementations.
+type Ext1FieldLogger interface {
+	FieldLogger
+	Tracef(format string, args ...interface{})
+	Trace(args ...interface{})
+	Traceln(args ...interface{})
+}
@@ -3,6 +3,10 @@ package logrus
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -242,6 +246,7 @@ func TestDoubleLoggingDoesntPrefixPreviousFields(t *testing.T) {
 }
 
 func TestConvertLevelToString(t *testing.T) {
+	assert.Equal(t, "trace", TraceLevel.String())
 	assert.Equal(t, "debug", DebugLevel.String())
 	assert.Equal(t, "info", InfoLevel.String())
 	assert.Equal(t, "warning", WarnLevel.String())
@@ -307,6 +312,30 @@ func TestParseLevel(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, DebugLevel, l)
 
+	l, err = ParseLevel("trace")
+	assert.Nil(t, err)
+	assert.Equal(t, TraceLevel, l)
+
+	l, err = ParseLevel("TRACE")
+	assert.Nil(t, err)
+	assert.Equal(t, TraceLevel, l)
+
+	l, err = ParseLevel("err")
+	assert.Nil(t, err)
+	assert.Equal(t, ErrorLevel, l)
+
+	l, err = ParseLevel("Err")
+	assert.Nil(t, err)
+	assert.Equal(t, ErrorLevel, l)
+
+	l, err = ParseLevel("WaRnInG")
+	assert.Nil(t, err)
+	assert.Equal(t, WarnLevel, l)
+
+	l, err = ParseLevel(" info ")
+	assert.Nil(t, err)
+	assert.Equal(t, InfoLevel, l)
+
 	l, err = ParseLevel("invalid")
 	assert.Equal(t, "not a valid logrus Level: \"invalid\"", err.Error())
 }
@@ -360,9 +389,8 @@ func TestLogrusInterface(t *testing.T) {
 	fn(e)
 }
 
-// Implements io.Writer using channels for synchronization, so we can wait on
-// the Entry.Writer goroutine to write in a non-racey way. This does assume that
-// there is a single call to Logger.Out for each message.
+// Implements io.Writer using channels for synchronization. This does assume
+// that there is a single call to Logger.Out for each message.
 type channelWriter chan []byte
 
 func (cw channelWriter) Write(p []byte) (int, error) {
@@ -384,3 +412,259 @@ func TestEntryWriter(t *testing.T) {
 	assert.Equal(t, fields["foo"], "bar")
 	assert.Equal(t, fields["level"], "warning")
 }
+
+func TestSetLevelOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	logger := New()
+	logger.Out = &stdout
+	logger.Formatter = &TextFormatter{TerminalChecker: func(w io.Writer) bool { return w == &stderr }}
+	logger.SetLevelOutput(ErrorLevel, &stderr)
+
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	assert.Contains(t, stdout.String(), "info")
+	assert.Contains(t, stdout.String(), "warn")
+	assert.NotContains(t, stdout.String(), "error")
+	assert.Contains(t, stderr.String(), "error")
+	assert.NotContains(t, stderr.String(), "info")
+
+	// Terminal detection must follow the writer the entry ends up on.
+	assert.NotContains(t, stdout.String(), "\x1b[")
+	assert.Contains(t, stderr.String(), "\x1b[")
+
+	logger.SetLevelOutput(ErrorLevel, nil)
+	logger.Error("back to out")
+
+	assert.Contains(t, stdout.String(), "back to out")
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// captureStderr returns what fn wrote to os.Stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	f, err := ioutil.TempFile("", "logrus-stderr")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	stderr := os.Stderr
+	os.Stderr = f
+	defer func() { os.Stderr = stderr }()
+	fn()
+
+	b, err := ioutil.ReadFile(f.Name())
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func TestWriteErrorDrop(t *testing.T) {
+	logger := New()
+	logger.Out = failingWriter{}
+
+	stderr := captureStderr(t, func() {
+		logger.Info("first")
+		logger.Info("second")
+	})
+
+	assert.Equal(t, 1, strings.Count(stderr, "Failed to write to log, disk full"))
+	assert.NotContains(t, stderr, "first")
+}
+
+func TestWriteErrorFallback(t *testing.T) {
+	logger := New()
+	logger.Out = failingWriter{}
+	logger.SetOnWriteError(WriteErrorFallback)
+
+	stderr := captureStderr(t, func() {
+		logger.Info("first")
+		logger.Info("second")
+	})
+
+	assert.Contains(t, stderr, "first")
+	assert.Contains(t, stderr, "second")
+}
+
+func TestWriteErrorPanic(t *testing.T) {
+	logger := New()
+	logger.Out = failingWriter{}
+	logger.SetOnWriteError(WriteErrorPanic)
+
+	defer func() {
+		p := recover()
+		assert.NotNil(t, p)
+		assert.Contains(t, p.(error).Error(), "disk full")
+	}()
+
+	logger.Info("first")
+	t.Error("expected a panic")
+}
+
+func TestIsLevelEnabled(t *testing.T) {
+	var buffer bytes.Buffer
+	formatter := &countingFormatter{}
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = formatter
+	logger.SetLevel(InfoLevel)
+
+	assert.True(t, logger.IsLevelEnabled(ErrorLevel))
+	assert.True(t, logger.IsLevelEnabled(InfoLevel))
+	assert.False(t, logger.IsLevelEnabled(DebugLevel))
+
+	logger.Debug("filtered")
+	logger.Debugf("filtered %d", 1)
+	logger.WithField("foo", "bar").Debugln("filtered")
+	assert.Equal(t, 0, formatter.calls)
+	assert.Equal(t, "", buffer.String())
+
+	logger.SetLevel(DebugLevel)
+	assert.True(t, logger.IsLevelEnabled(DebugLevel))
+	logger.Debug("logged")
+	assert.Equal(t, 1, formatter.calls)
+}
+
+func TestTraceLevel(t *testing.T) {
+	assert.True(t, TraceLevel > DebugLevel)
+	assert.Equal(t, TraceLevel, AllLevels[len(AllLevels)-1])
+
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &JSONFormatter{DisableTimestamp: true}
+
+	logger.SetLevel(DebugLevel)
+	logger.Trace("filtered")
+	logger.WithField("foo", "bar").Tracef("filtered %d", 1)
+	assert.Equal(t, "", buffer.String())
+
+	logger.SetLevel(TraceLevel)
+	logger.Trace("traced")
+	logger.WithField("foo", "bar").Tracef("traced %d", 2)
+	logger.Traceln("traced", 3)
+	logger.Debug("debugged")
+
+	var levels, messages []string
+	for _, line := range strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n") {
+		var fields Fields
+		assert.NoError(t, json.Unmarshal([]byte(line), &fields))
+		levels = append(levels, fields["level"].(string))
+		messages = append(messages, fields["msg"].(string))
+	}
+	assert.Equal(t, []string{"trace", "trace", "trace", "debug"}, levels)
+	assert.Equal(t, []string{"traced", "traced 2", "traced 3", "debugged"}, messages)
+}
+
+func TestReportSequence(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &JSONFormatter{DisableTimestamp: true}
+	logger.ReportSequence = true
+
+	const goroutines, entries = 8, 100
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			entry := logger.WithField("goroutine", g)
+			for i := 0; i < entries; i++ {
+				entry.Info("sequenced")
+				entry.Debug("filtered out, no sequence number")
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	seen := make(map[uint64]bool)
+	for _, line := range strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n") {
+		var fields Fields
+		assert.NoError(t, json.Unmarshal([]byte(line), &fields))
+		seq := uint64(fields[SequenceKey].(float64))
+		assert.False(t, seen[seq], "duplicate sequence number %d", seq)
+		seen[seq] = true
+	}
+	assert.Len(t, seen, goroutines*entries)
+	for seq := uint64(1); seq <= goroutines*entries; seq++ {
+		assert.True(t, seen[seq], "missing sequence number %d", seq)
+	}
+}
+
+func TestLevelMarshalText(t *testing.T) {
+	for _, level := range AllLevels {
+		text, err := level.MarshalText()
+		assert.NoError(t, err)
+
+		var parsed Level
+		assert.NoError(t, parsed.UnmarshalText(text))
+		assert.Equal(t, level, parsed)
+	}
+
+	_, err := Level(42).MarshalText()
+	assert.Error(t, err)
+
+	var config struct {
+		Level Level `json:"level"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"level":"WARN"}`), &config))
+	assert.Equal(t, WarnLevel, config.Level)
+
+	encoded, err := json.Marshal(config)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"level":"warning"}`, string(encoded))
+
+	config.Level = DebugLevel
+	err = json.Unmarshal([]byte(`{"level":"verbose"}`), &config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `not a valid logrus Level: "verbose"`)
+	assert.Equal(t, DebugLevel, config.Level, "level must be left untouched on error")
+}
+
+func TestAddOutput(t *testing.T) {
+	var file, stderr, all bytes.Buffer
+
+	logger := New()
+	logger.Out = &file
+	logger.Formatter = &JSONFormatter{DisableTimestamp: true}
+	logger.AddOutput(&stderr, &TextFormatter{DisableColors: true, DisableTimestamp: true, DisableRuntimeFields: true}, ErrorLevel, FatalLevel)
+	logger.AddOutput(&all, nil)
+
+	logger.WithField("foo", "bar").Info("info")
+	logger.WithField("foo", "bar").Error("failure")
+
+	lines := strings.Split(strings.TrimSuffix(file.String(), "\n"), "\n")
+	assert.Equal(t, 2, len(lines))
+	for _, line := range lines {
+		var fields Fields
+		assert.NoError(t, json.Unmarshal([]byte(line), &fields))
+		assert.Equal(t, "bar", fields["foo"])
+	}
+
+	assert.Equal(t, "[error] bar failure \n", stderr.String())
+	assert.Equal(t, file.String(), all.String(), "outputs without formatter use the logger's one")
+}
+
+func TestAddOutputAsync(t *testing.T) {
+	var out, extra bytes.Buffer
+
+	logger := New()
+	logger.Out = &out
+	logger.Formatter = &JSONFormatter{DisableTimestamp: true}
+	logger.AddOutput(&extra, nil, WarnLevel)
+	logger.SetAsync(8, Block)
+
+	for i := 0; i < 20; i++ {
+		logger.Warn("async")
+	}
+	assert.Nil(t, logger.Close())
+
+	assert.Equal(t, 20, strings.Count(out.String(), "\n"))
+	assert.Equal(t, out.String(), extra.String())
+}
@@ -0,0 +1,59 @@
+package logrus
+
+// Defines the key when adding a metric sample using WithMetric.
+var MetricKey = "metric"
+
+// Metric is a numeric sample carried by an entry, e.g. the duration of a
+// request, for metrics pipelines scraping the logs. The JSONFormatter
+// renders it as a nested object, the TextFormatter as the fields
+// `metric=<name> value=<value>` followed by its tags.
+type Metric struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+	Tags  Fields  `json:"tags,omitempty"`
+}
+
+// fields returns the fields the metric is rendered as in text. The value and
+// tags are left out when they would clash with a key already in data.
+func (m Metric) fields(data Fields) Fields {
+	fields := Fields{MetricKey: m.Name}
+	if _, ok := data["value"]; !ok {
+		fields["value"] = m.Value
+	}
+	for k, v := range m.Tags {
+		if _, ok := data[k]; !ok {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// Add a metric sample to the Entry, under the key defined in MetricKey:
+//
+//    entry.WithMetric("request_duration", 0.123, log.Fields{"route": "/"}).Info("request served")
+func (entry *Entry) WithMetric(name string, value float64, tags Fields) *Entry {
+	return entry.WithField(MetricKey, Metric{Name: name, Value: value, Tags: tags})
+}
+
+// MetricHook forwards the metric samples carried by entries of any level to
+// a metrics client.
+type MetricHook struct {
+	record func(Metric)
+}
+
+// NewMetricHook returns a hook calling record with the metric of every entry
+// added with WithMetric.
+func NewMetricHook(record func(Metric)) *MetricHook {
+	return &MetricHook{record: record}
+}
+
+func (hook *MetricHook) Levels() []Level {
+	return AllLevels
+}
+
+func (hook *MetricHook) Fire(entry *Entry) error {
+	if m, ok := entry.Data[MetricKey].(Metric); ok {
+		hook.record(m)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricText(t *testing.T) {
+	tf := &TextFormatter{ForceColors: true, DisableTimestamp: true}
+
+	entry := WithField("route", "/").WithMetric("request_duration", 0.123, Fields{"route": "/home", "code": 200})
+	b, _ := tf.Format(entry)
+
+	assert.Contains(t, string(b), "metric\x1b[0m=request_duration")
+	assert.Contains(t, string(b), "value\x1b[0m=0.123")
+	assert.Contains(t, string(b), "code\x1b[0m=200")
+	assert.Contains(t, string(b), "route\x1b[0m=/ ", "existing fields must win over tags")
+}
+
+func TestMetricJSON(t *testing.T) {
+	formatter := &JSONFormatter{}
+
+	b, err := formatter.Format(WithField("foo", "bar").WithMetric("request_duration", 0.123, Fields{"route": "/"}))
+	assert.NoError(t, err)
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(b, &entry))
+	assert.Equal(t, map[string]interface{}{
+		"name":  "request_duration",
+		"value": 0.123,
+		"tags":  map[string]interface{}{"route": "/"},
+	}, entry["metric"])
+}
+
+func TestMetricHook(t *testing.T) {
+	var metrics []Metric
+	logger := New()
+	logger.Out = &bytes.Buffer{}
+	logger.AddHook(NewMetricHook(func(m Metric) {
+		metrics = append(metrics, m)
+	}))
+
+	logger.Info("no metric")
+	logger.WithField("foo", "bar").WithMetric("queue_depth", 7, nil).Warn("queue filling up")
+
+	assert.Equal(t, []Metric{{Name: "queue_depth", Value: 7}}, metrics)
+}
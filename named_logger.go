@@ -0,0 +1,124 @@
+package logrus
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// namedLevels maps the names of loggers to their level, see `SetLevels`.
+var namedLevels atomic.Value
+
+func init() {
+	namedLevels.Store(map[string]Level{})
+}
+
+// SetLevels sets the levels of the named loggers from a comma separated list
+// of name=level pairs, so that the verbosity of each subsystem of a program
+// can be configured separately:
+//
+//    logrus.SetLevels("db=debug,http=warn,*=info")
+//
+// The level of a logger is the one of its name, or else the one of the
+// closest of its parents, e.g. "db" for "db.pool", or else the one of "*".
+// Loggers without a name, and named loggers matching no pair, keep their own
+// level. Each call replaces the levels set by the previous one, an empty list
+// clears them. Nothing is changed if the list is invalid.
+func SetLevels(levels string) error {
+	parsed := make(map[string]Level)
+	for _, pair := range strings.Split(levels, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		i := strings.IndexByte(pair, '=')
+		if i < 0 {
+			return fmt.Errorf("not a valid name=level pair: %q", pair)
+		}
+		name := strings.TrimSpace(pair[:i])
+		if name == "" {
+			return fmt.Errorf("missing name in %q", pair)
+		}
+		level, err := ParseLevel(strings.TrimSpace(pair[i+1:]))
+		if err != nil {
+			return err
+		}
+		parsed[name] = level
+	}
+	namedLevels.Store(parsed)
+	return nil
+}
+
+// namedLevel returns the level set with SetLevels for the logger with the
+// given name, if any.
+func namedLevel(name string) (Level, bool) {
+	levels := namedLevels.Load().(map[string]Level)
+	if len(levels) == 0 {
+		return 0, false
+	}
+	for {
+		if level, ok := levels[name]; ok {
+			return level, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	level, ok := levels["*"]
+	return level, ok
+}
+
+// Named returns a logger for a component of the program, e.g. "db", whose
+// level can be set separately with `SetLevels` and which can be muted with
+// `SetQuiet`. The name of a logger derived from a named one is prefixed with
+// the name of its parent and a dot, e.g. "db.pool".
+//
+// The new logger starts with a copy of the configuration of logger, later
+// changes to one aren't seen by the other. Both share the same outputs, the
+// lock serializing the writes to them, the queue set with `SetAsync` and the
+// sequence numbers of `ReportSequence`.
+func (logger *Logger) Named(name string) *Logger {
+	if logger.Name != "" {
+		name = logger.Name + "." + name
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	hooks := make(LevelHooks, len(logger.Hooks))
+	for level, levelHooks := range logger.Hooks {
+		hooks[level] = append([]Hook(nil), levelHooks...)
+	}
+	named := &Logger{
+		Out:              logger.Out,
+		Hooks:            hooks,
+		Formatter:        logger.Formatter,
+		Level:            Level(atomic.LoadUint32((*uint32)(&logger.Level))),
+		Name:             name,
+		ReportSequence:   logger.ReportSequence,
+		CallerSkip:       logger.CallerSkip,
+		ContextExtractor: logger.ContextExtractor,
+		ExitFunc:         logger.ExitFunc,
+		ErrorCauses:      logger.ErrorCauses,
+		ErrorStackTrace:  logger.ErrorStackTrace,
+		paused:           atomic.LoadUint32(&logger.paused),
+		reportCaller:     atomic.LoadUint32(&logger.reportCaller),
+		onWriteError:     WriteErrorPolicy(atomic.LoadUint32((*uint32)(&logger.onWriteError))),
+		root:             logger.core(),
+	}
+	named.mu.disabled = logger.mu.disabled
+	for _, setting := range []struct{ from, to *atomic.Value }{
+		{&logger.levelOutputs, &named.levelOutputs},
+		{&logger.outputs, &named.outputs},
+		{&logger.rateLimit, &named.rateLimit},
+		{&logger.sample, &named.sample},
+		{&logger.redactor, &named.redactor},
+		{&logger.hookErrorHandler, &named.hookErrorHandler},
+	} {
+		if value := setting.from.Load(); value != nil {
+			setting.to.Store(value)
+		}
+	}
+	return named
+}
@@ -0,0 +1,134 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLevels(t *testing.T) {
+	defer SetLevels("")
+	assert.NoError(t, SetLevels(" db=debug, http=warn ,*=error"))
+
+	testCases := []struct {
+		name     string
+		expected Level
+	}{
+		{"db", DebugLevel},
+		{"db.pool", DebugLevel},
+		{"http", WarnLevel},
+		{"cache", ErrorLevel},
+		{"dbx", ErrorLevel},
+		{"", InfoLevel},
+	}
+	for _, tc := range testCases {
+		logger := New()
+		logger.Name = tc.name
+		assert.Equal(t, tc.expected, logger.level(), tc.name)
+	}
+
+	assert.Error(t, SetLevels("db=debug,http"))
+	assert.Error(t, SetLevels("=debug"))
+	assert.Error(t, SetLevels("db=loud"))
+	logger := New()
+	logger.Name = "db"
+	assert.Equal(t, DebugLevel, logger.level(), "invalid levels must not change anything")
+
+	assert.NoError(t, SetLevels(""))
+	assert.Equal(t, InfoLevel, logger.level())
+}
+
+func TestNamed(t *testing.T) {
+	defer SetLevels("")
+	var buffer bytes.Buffer
+
+	logger := New()
+	logger.Out = &buffer
+	logger.SetLevel(WarnLevel)
+	logger.SetReportCaller(true)
+
+	db := logger.Named("db")
+	pool := db.Named("pool")
+	assert.Equal(t, "db", db.Name)
+	assert.Equal(t, "db.pool", pool.Name)
+	assert.Equal(t, WarnLevel, pool.level())
+	assert.True(t, pool.ReportsCaller())
+	assert.Equal(t, "", logger.Name)
+
+	assert.NoError(t, SetLevels("db=debug"))
+	pool.Debug("pool debug")
+	logger.Debug("root debug")
+	assert.Contains(t, buffer.String(), "pool debug")
+	assert.NotContains(t, buffer.String(), "root debug")
+
+	pool.AddHook(new(TestHook))
+	assert.Len(t, logger.Hooks[InfoLevel], 0)
+	assert.Len(t, db.Hooks[InfoLevel], 0)
+}
+
+// unsafeWriter corrupts the lines written concurrently, as a writer without
+// locking would.
+type unsafeWriter struct {
+	lines []string
+	line  []byte
+}
+
+func (w *unsafeWriter) Write(p []byte) (int, error) {
+	for _, c := range p {
+		w.line = append(w.line, c)
+		if c == '\n' {
+			w.lines = append(w.lines, string(w.line))
+			w.line = w.line[:0]
+		}
+	}
+	return len(p), nil
+}
+
+func TestNamedSharesWrites(t *testing.T) {
+	out := new(unsafeWriter)
+	logger := New()
+	logger.Out = out
+	logger.Formatter = &JSONFormatter{DisableTimestamp: true}
+	logger.ReportSequence = true
+	named := logger.Named("db")
+
+	var wg sync.WaitGroup
+	for _, l := range []*Logger{logger, named, named.Named("pool")} {
+		wg.Add(1)
+		go func(l *Logger) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				l.Info("concurrent")
+			}
+		}(l)
+	}
+	wg.Wait()
+
+	assert.Len(t, out.lines, 300)
+	seen := make(map[float64]bool)
+	for _, line := range out.lines {
+		var fields Fields
+		if !assert.NoError(t, json.Unmarshal([]byte(line), &fields), "got %q", line) {
+			continue
+		}
+		seq := fields[SequenceKey].(float64)
+		assert.False(t, seen[seq], "duplicate sequence number %v", seq)
+		seen[seq] = true
+	}
+
+	var buffer bytes.Buffer
+	logger.Out = &buffer
+	named = logger.Named("db")
+	named.Out = &buffer
+	logger.SetAsync(10, Block)
+	named.Info("queued")
+	logger.Close()
+	named.Info("after close")
+	assert.Contains(t, buffer.String(), "queued")
+	assert.Contains(t, buffer.String(), "after close")
+	assert.Equal(t, 2, strings.Count(buffer.String(), "\n"))
+}
@@ -0,0 +1,37 @@
+package logrus
+
+import "sync"
+
+var (
+	quietMu    sync.RWMutex
+	quietNames = make(map[string]bool)
+)
+
+// SetQuiet mutes or unmutes the loggers with the given names. Entries of a
+// muted logger are dropped regardless of its level, which is useful to silence
+// noisy subsystems centrally, e.g. during maintenance:
+//
+//    logrus.SetQuiet([]string{"db", "cache"}, true)
+//    defer logrus.SetQuiet([]string{"db", "cache"}, false)
+//
+// Loggers without a name can't be muted.
+func SetQuiet(names []string, quiet bool) {
+	quietMu.Lock()
+	defer quietMu.Unlock()
+	for _, name := range names {
+		if quiet {
+			quietNames[name] = true
+		} else {
+			delete(quietNames, name)
+		}
+	}
+}
+
+func isQuiet(name string) bool {
+	if name == "" {
+		return false
+	}
+	quietMu.RLock()
+	defer quietMu.RUnlock()
+	return quietNames[name]
+}
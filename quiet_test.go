@@ -0,0 +1,45 @@
+package logrus
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetQuiet(t *testing.T) {
+	var dbBuffer, httpBuffer bytes.Buffer
+
+	db := New()
+	db.Name = "db"
+	db.Out = &dbBuffer
+
+	http := New()
+	http.Name = "http"
+	http.Out = &httpBuffer
+
+	SetQuiet([]string{"db"}, true)
+	db.Error("muted")
+	http.Info("not muted")
+
+	assert.Equal(t, 0, dbBuffer.Len())
+	assert.Contains(t, httpBuffer.String(), "not muted")
+
+	SetQuiet([]string{"db"}, false)
+	db.Info("unmuted")
+
+	assert.Contains(t, dbBuffer.String(), "unmuted")
+}
+
+func TestSetQuietIgnoresUnnamedLoggers(t *testing.T) {
+	var buffer bytes.Buffer
+
+	logger := New()
+	logger.Out = &buffer
+
+	SetQuiet([]string{""}, true)
+	defer SetQuiet([]string{""}, false)
+	logger.Info("not muted")
+
+	assert.Contains(t, buffer.String(), "not muted")
+}
@@ -0,0 +1,113 @@
+package logrus
+
+import (
+	"sync"
+	"time"
+)
+
+// Defines the key of the number of entries dropped by the rate limit of a
+// logger, see `SetRateLimit`.
+var DroppedKey = "dropped"
+
+// tokenBucket allows perSecond events per second on average, with bursts of
+// up to perSecond events.
+type tokenBucket struct {
+	perSecond float64
+	tokens    float64
+	last      time.Time
+}
+
+// refill adds the tokens accumulated since the last call.
+func (b *tokenBucket) refill(now time.Time) {
+	if b.last.IsZero() {
+		b.tokens = b.perSecond
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * b.perSecond
+		if b.tokens > b.perSecond {
+			b.tokens = b.perSecond
+		}
+	}
+	b.last = now
+}
+
+// take takes a token if one is available at now.
+func (b *tokenBucket) take(now time.Time) bool {
+	b.refill(now)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// wait returns how long until a token is available, as of the last refill.
+func (b *tokenBucket) wait() time.Duration {
+	return time.Duration((1 - b.tokens) / b.perSecond * float64(time.Second))
+}
+
+// rateLimiter caps the number of entries written by a logger per second.
+type rateLimiter struct {
+	policy OverflowPolicy
+	// Writes the summary of the entries dropped, at t.
+	report func(dropped uint64, t time.Time)
+
+	mu      sync.Mutex
+	bucket  tokenBucket
+	dropped uint64
+	// Set while a summary is scheduled, see reportDropped.
+	scheduled bool
+
+	// Replaced in tests.
+	now       func() time.Time
+	sleep     func(time.Duration)
+	afterFunc func(time.Duration, func())
+}
+
+func newRateLimiter(perSecond int, policy OverflowPolicy, report func(dropped uint64, t time.Time)) *rateLimiter {
+	return &rateLimiter{
+		policy:    policy,
+		report:    report,
+		bucket:    tokenBucket{perSecond: float64(perSecond)},
+		now:       time.Now,
+		sleep:     time.Sleep,
+		afterFunc: func(d time.Duration, f func()) { time.AfterFunc(d, f) },
+	}
+}
+
+// allow reports whether an entry may be written, waiting for the bucket to
+// refill with the Block policy. When it is, it also returns the number of
+// entries dropped since the previous one. The first entry dropped schedules
+// a summary a second later, in case no entry is let through meanwhile.
+func (rl *rateLimiter) allow() (bool, uint64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for !rl.bucket.take(rl.now()) {
+		if rl.policy != Block {
+			rl.dropped++
+			if !rl.scheduled {
+				rl.scheduled = true
+				rl.afterFunc(time.Second, rl.reportDropped)
+			}
+			return false, 0
+		}
+		rl.sleep(rl.bucket.wait())
+	}
+	dropped := rl.dropped
+	rl.dropped = 0
+	return true, dropped
+}
+
+// reportDropped writes the summary of the entries dropped since the last
+// one, unless an entry let through reported them already.
+func (rl *rateLimiter) reportDropped() {
+	rl.mu.Lock()
+	dropped := rl.dropped
+	rl.dropped = 0
+	rl.scheduled = false
+	t := rl.now()
+	rl.mu.Unlock()
+
+	if dropped > 0 {
+		rl.report(dropped, t)
+	}
+}
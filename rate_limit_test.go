@@ -0,0 +1,118 @@
+package logrus
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRateLimit(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &JSONFormatter{DisableTimestamp: true}
+	logger.SetRateLimit(2, DropNewest)
+
+	now := time.Unix(1500000000, 0)
+	var scheduled []func()
+	limiter := logger.rateLimiter()
+	limiter.now = func() time.Time { return now }
+	limiter.afterFunc = func(d time.Duration, f func()) {
+		assert.Equal(t, time.Second, d)
+		scheduled = append(scheduled, f)
+	}
+
+	for i := 0; i < 5; i++ {
+		logger.WithField("i", i).Info("burst")
+	}
+	entries := decodeLines(t, &buffer)
+	assert.Len(t, entries, 2)
+
+	buffer.Reset()
+	now = now.Add(500 * time.Millisecond)
+	logger.Info("after refill")
+	entries = decodeLines(t, &buffer)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "warning", entries[0]["level"])
+	assert.Equal(t, float64(3), entries[0][DroppedKey])
+	assert.Equal(t, "after refill", entries[1]["msg"])
+
+	// The summary was written already
+	buffer.Reset()
+	assert.Len(t, scheduled, 1)
+	scheduled[0]()
+	assert.Equal(t, "", buffer.String())
+
+	buffer.Reset()
+	now = now.Add(time.Second)
+	logger.Info("no drops")
+	entries = decodeLines(t, &buffer)
+	assert.Len(t, entries, 1)
+	assert.Nil(t, entries[0][DroppedKey])
+
+	logger.SetRateLimit(0, DropNewest)
+	buffer.Reset()
+	for i := 0; i < 5; i++ {
+		logger.Info("unlimited")
+	}
+	assert.Len(t, decodeLines(t, &buffer), 5)
+}
+
+func TestSetRateLimitBlock(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &JSONFormatter{DisableTimestamp: true}
+	logger.SetRateLimit(4, Block)
+
+	now := time.Unix(1500000000, 0)
+	var slept time.Duration
+	limiter := logger.rateLimiter()
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(d time.Duration) {
+		slept += d
+		now = now.Add(d)
+	}
+
+	for i := 0; i < 6; i++ {
+		logger.Info("blocking")
+	}
+	assert.Len(t, decodeLines(t, &buffer), 6)
+	assert.Equal(t, 500*time.Millisecond, slept)
+}
+
+func TestSetRateLimitSummaryWithoutEntries(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &JSONFormatter{DisableTimestamp: true}
+	logger.SetRateLimit(1, DropNewest)
+
+	now := time.Unix(1500000000, 0)
+	var scheduled []func()
+	limiter := logger.rateLimiter()
+	limiter.now = func() time.Time { return now }
+	limiter.afterFunc = func(d time.Duration, f func()) { scheduled = append(scheduled, f) }
+
+	for i := 0; i < 4; i++ {
+		logger.Info("burst")
+	}
+	assert.Len(t, scheduled, 1, "a single summary is scheduled per window")
+	buffer.Reset()
+	now = now.Add(time.Second)
+	scheduled[0]()
+	entries := decodeLines(t, &buffer)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, float64(3), entries[0][DroppedKey])
+
+	// Fatal and panic entries are never dropped
+	buffer.Reset()
+	logger.Info("allowed")
+	assert.Panics(t, func() { logger.Panic("kept") })
+	entries = decodeLines(t, &buffer)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "kept", entries[1]["msg"])
+	assert.Len(t, scheduled, 1)
+}
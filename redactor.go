@@ -0,0 +1,223 @@
+package logrus
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Redactor masks secrets and personal data in the entries of a logger, see
+// `SetRedactor`:
+//
+//    logger.SetRedactor(&logrus.Redactor{
+//      Keys:          []string{"password", "token"},
+//      ValuePatterns: []*regexp.Regexp{regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)},
+//    })
+//
+// The whole value of the fields selected by Keys, KeyPatterns or Match is
+// replaced, while only the parts of the string values and of the message
+// matching ValuePatterns are. The values nested in maps, structs and slices
+// are redacted too: a field holding such a value with something to redact
+// is replaced by a copy of it, as rendered in JSON, with the nested fields
+// selected by key and the parts of the nested strings redacted.
+type Redactor struct {
+	// Keys lists the keys of the fields to redact, matched case
+	// insensitively.
+	Keys []string
+
+	// KeyPatterns select the fields to redact by key.
+	KeyPatterns []*regexp.Regexp
+
+	// ValuePatterns select the parts of the string values, errors, byte
+	// slices and fmt.Stringer values included, and of the message to
+	// redact.
+	ValuePatterns []*regexp.Regexp
+
+	// Match, if set, selects the fields to redact by key and value, e.g.
+	// values of a given type.
+	Match func(key string, value interface{}) bool
+
+	// Hash replaces the redacted values by an HMAC-SHA256 of them keyed
+	// with HashKey, e.g. "hmac:a9c5855444345e10", so that equal values can
+	// still be told apart, instead of Replacement.
+	Hash bool
+
+	// HashKey is the key of the HMAC of Hash. Without it, hashes can't be
+	// reversed with a dictionary by whoever reads the logs. If it is empty a
+	// random key is generated, and hashes can only be compared within the
+	// process.
+	HashKey []byte
+
+	// Replacement replaces the redacted values, defaults to "[REDACTED]".
+	Replacement string
+
+	randomKeyOnce sync.Once
+	randomKey     []byte
+}
+
+// redact returns a copy of data with the selected fields redacted, or data
+// itself if there is nothing to redact, since it may be shared with other
+// entries.
+func (r *Redactor) redact(data Fields) Fields {
+	var redacted Fields
+	for k, v := range data {
+		value, changed := r.redactField(k, v)
+		if !changed {
+			continue
+		}
+		if redacted == nil {
+			redacted = make(Fields, len(data))
+			MergeFields(redacted, data)
+		}
+		redacted[k] = value
+	}
+	if redacted == nil {
+		return data
+	}
+	return redacted
+}
+
+func (r *Redactor) redactField(key string, value interface{}) (interface{}, bool) {
+	if r.selects(key, value) {
+		return r.mask(fmt.Sprint(value)), true
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return value, false
+	case string:
+		return r.redactString(value, v)
+	case []byte:
+		return r.redactString(value, string(v))
+	case error, fmt.Stringer:
+		// fmt renders the nil pointers implementing them as <nil>
+		if redacted, changed := r.redactString(value, fmt.Sprint(v)); changed {
+			return redacted, true
+		}
+	}
+	return r.redactNested(value)
+}
+
+// redactString returns text redacted if ValuePatterns match it, or else
+// value.
+func (r *Redactor) redactString(value interface{}, text string) (interface{}, bool) {
+	if redacted := r.redactText(text); redacted != text {
+		return redacted, true
+	}
+	return value, false
+}
+
+// redactNested redacts the values nested in maps, structs, slices and
+// arrays, as the JSONFormatter renders them. Values which can't be rendered
+// in JSON are redacted as text.
+func (r *Redactor) redactNested(value interface{}) (interface{}, bool) {
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Map, reflect.Struct, reflect.Slice, reflect.Array:
+	default:
+		return value, false
+	}
+
+	var tree interface{}
+	b, err := json.Marshal(value)
+	if err == nil {
+		decoder := json.NewDecoder(bytes.NewReader(b))
+		decoder.UseNumber()
+		err = decoder.Decode(&tree)
+	}
+	if err != nil {
+		return r.redactString(value, fmt.Sprint(value))
+	}
+	if redacted, changed := r.redactTree(tree); changed {
+		return redacted, true
+	}
+	return value, false
+}
+
+// redactTree redacts the value decoded from JSON in place.
+func (r *Redactor) redactTree(value interface{}) (interface{}, bool) {
+	var changed bool
+	switch v := value.(type) {
+	case string:
+		redacted := r.redactText(v)
+		return redacted, redacted != v
+	case map[string]interface{}:
+		for k, nested := range v {
+			if r.selects(k, nested) {
+				v[k] = r.mask(fmt.Sprint(nested))
+				changed = true
+			} else if redacted, ok := r.redactTree(nested); ok {
+				v[k] = redacted
+				changed = true
+			}
+		}
+	case []interface{}:
+		for i, nested := range v {
+			if redacted, ok := r.redactTree(nested); ok {
+				v[i] = redacted
+				changed = true
+			}
+		}
+	}
+	return value, changed
+}
+
+// selects reports whether the whole value of the field is redacted.
+func (r *Redactor) selects(key string, value interface{}) bool {
+	for _, k := range r.Keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	for _, pattern := range r.KeyPatterns {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+	return r.Match != nil && r.Match(key, value)
+}
+
+// redactText masks the parts of text matching ValuePatterns.
+func (r *Redactor) redactText(text string) string {
+	for _, pattern := range r.ValuePatterns {
+		text = pattern.ReplaceAllStringFunc(text, r.mask)
+	}
+	return text
+}
+
+func (r *Redactor) mask(text string) string {
+	if r.Hash {
+		if key := r.hashKey(); key != nil {
+			mac := hmac.New(sha256.New, key)
+			mac.Write([]byte(text))
+			return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+		}
+	}
+	if r.Replacement == "" {
+		return "[REDACTED]"
+	}
+	return r.Replacement
+}
+
+// hashKey returns HashKey, or else the random key of the redactor. It
+// returns nil if the random key can't be generated, the values are then
+// replaced rather than hashed.
+func (r *Redactor) hashKey() []byte {
+	if len(r.HashKey) > 0 {
+		return r.HashKey
+	}
+	r.randomKeyOnce.Do(func() {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err == nil {
+			r.randomKey = key
+		}
+	})
+	return r.randomKey
+}
@@ -0,0 +1,121 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type apiKey string
+
+func TestRedactor(t *testing.T) {
+	var buffer bytes.Buffer
+	var fields Fields
+
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = new(JSONFormatter)
+	hook := new(captureHook)
+	logger.AddHook(hook)
+	logger.SetRedactor(&Redactor{
+		Keys:          []string{"password"},
+		KeyPatterns:   []*regexp.Regexp{regexp.MustCompile(`(?i)token$`)},
+		ValuePatterns: []*regexp.Regexp{regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)},
+		Match: func(key string, value interface{}) bool {
+			_, ok := value.(apiKey)
+			return ok
+		},
+	})
+
+	entry := logger.WithFields(Fields{
+		"Password":    "hunter2",
+		"accessToken": "abc",
+		"key":         apiKey("k-123"),
+		"user":        "contact: jane@example.com",
+		"error":       errors.New("unknown user bob@example.org"),
+		"count":       3,
+	})
+	entry.Info("sent to jane@example.com")
+
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &fields))
+	assert.Equal(t, "[REDACTED]", fields["Password"])
+	assert.Equal(t, "[REDACTED]", fields["accessToken"])
+	assert.Equal(t, "[REDACTED]", fields["key"])
+	assert.Equal(t, "contact: [REDACTED]", fields["user"])
+	assert.Equal(t, "unknown user [REDACTED]", fields["error"])
+	assert.Equal(t, 3.0, fields["count"])
+	assert.Equal(t, "sent to [REDACTED]", fields["msg"])
+
+	assert.Equal(t, "[REDACTED]", hook.entry.Data["Password"], "hooks must see redacted fields")
+	assert.Equal(t, "sent to [REDACTED]", hook.entry.Message)
+	assert.Equal(t, "hunter2", entry.Data["Password"], "entry data must not be modified")
+}
+
+func TestRedactorNested(t *testing.T) {
+	type credentials struct {
+		User     string `json:"user"`
+		Password string `json:"password"`
+	}
+	r := &Redactor{
+		Keys:          []string{"password"},
+		ValuePatterns: []*regexp.Regexp{regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)},
+	}
+
+	data := Fields{
+		"map":      map[string]interface{}{"contact": []string{"jane@example.com"}, "count": 3},
+		"struct":   &credentials{User: "jane", Password: "hunter2"},
+		"bytes":    []byte("from bob@example.org"),
+		"stringer": upperStringer("jane@example.com"),
+		"nil":      (*credentials)(nil),
+		"clean":    map[string]int{"count": 3},
+	}
+	redacted := r.redact(data)
+
+	assert.Equal(t, map[string]interface{}{"contact": []interface{}{"[REDACTED]"}, "count": json.Number("3")}, redacted["map"])
+	assert.Equal(t, map[string]interface{}{"user": "jane", "password": "[REDACTED]"}, redacted["struct"])
+	assert.Equal(t, "from [REDACTED]", redacted["bytes"])
+	assert.Equal(t, "([REDACTED])", redacted["stringer"])
+	assert.Equal(t, data["nil"], redacted["nil"])
+	assert.Equal(t, data["clean"], redacted["clean"])
+	assert.Equal(t, "hunter2", data["struct"].(*credentials).Password, "nested values must not be modified")
+}
+
+func TestRedactorHash(t *testing.T) {
+	r := &Redactor{Keys: []string{"password"}, Hash: true, HashKey: []byte("secret")}
+	first := r.redact(Fields{"password": "hunter2"})
+	second := r.redact(Fields{"password": "hunter2"})
+	other := r.redact(Fields{"password": "letmein"})
+
+	assert.Equal(t, "hmac:a9c5855444345e10", first["password"])
+	assert.Equal(t, first["password"], second["password"])
+	assert.NotEqual(t, first["password"], other["password"])
+
+	// Without a key, the hashes can be compared within the process only
+	r = &Redactor{Keys: []string{"password"}, Hash: true}
+	first = r.redact(Fields{"password": "hunter2"})
+	assert.Equal(t, first["password"], r.redact(Fields{"password": "hunter2"})["password"])
+	assert.NotEqual(t, "hmac:a9c5855444345e10", first["password"])
+
+	r = &Redactor{Keys: []string{"password"}, Replacement: "***"}
+	assert.Equal(t, "***", r.redact(Fields{"password": "hunter2"})["password"])
+
+	data := Fields{"user": "jane"}
+	assert.Equal(t, data, r.redact(data))
+}
+
+type captureHook struct {
+	entry *Entry
+}
+
+func (hook *captureHook) Levels() []Level {
+	return AllLevels
+}
+
+func (hook *captureHook) Fire(entry *Entry) error {
+	hook.entry = entry
+	return nil
+}
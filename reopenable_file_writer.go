@@ -0,0 +1,116 @@
+package logrus
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// ReopenableFileWriter is an io.WriteCloser writing to a file which can be
+// reopened, for external tools such as logrotate which rename the file and
+// then signal the process to reopen it:
+//
+//    out := &logrus.ReopenableFileWriter{Filename: "/var/log/app.log"}
+//    logger.Out = out
+//    defer logrus.ReopenOnSignal(out)()
+//
+// It is safe for concurrent use, writes made while the file is reopened go
+// either to the old or to the new file.
+type ReopenableFileWriter struct {
+	// Filename is the file written to, created if needed.
+	Filename string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Write writes p to the file, opening it first if needed.
+func (w *ReopenableFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		file, err := w.open()
+		if err != nil {
+			return 0, err
+		}
+		w.file = file
+	}
+	return w.file.Write(p)
+}
+
+// Reopen closes the file and opens Filename again, creating it if it was
+// renamed or removed. The new file is opened before the old one is closed,
+// so the old file is kept if opening fails.
+func (w *ReopenableFileWriter) Reopen() error {
+	file, err := w.open()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	old := w.file
+	w.file = file
+	w.mu.Unlock()
+
+	if old == nil {
+		return nil
+	}
+	return old.Close()
+}
+
+// Close closes the file, it is opened again on the next write.
+func (w *ReopenableFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *ReopenableFileWriter) open() (*os.File, error) {
+	return os.OpenFile(w.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+// ReopenOnSignal reopens w whenever the process receives one of signals,
+// SIGHUP by default, or none on js where there is no SIGHUP. Failures to
+// reopen are reported on stderr and the old file is kept. The returned
+// function stops handling the signals.
+func ReopenOnSignal(w *ReopenableFileWriter, signals ...os.Signal) func() {
+	if len(signals) == 0 {
+		signals = hangupSignals
+	}
+	if len(signals) == 0 {
+		// signal.Notify would relay all the signals
+		return func() {}
+	}
+
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, signals...)
+	go func() {
+		for {
+			select {
+			case <-c:
+				if err := w.Reopen(); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to reopen log file, %v\n", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package logrus
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReopenableFileWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "app.log")
+	w := &ReopenableFileWriter{Filename: name}
+	defer w.Close()
+
+	_, err = w.Write([]byte("before\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, os.Rename(name, name+".1"))
+
+	_, err = w.Write([]byte("renamed\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Reopen())
+	_, err = w.Write([]byte("after\n"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "before\nrenamed\n", readFile(t, name+".1"))
+	assert.Equal(t, "after\n", readFile(t, name))
+}
+
+func TestReopenableFileWriterConcurrentReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "app.log")
+	w := &ReopenableFileWriter{Filename: name}
+	defer w.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, err := w.Write([]byte("line\n"))
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, w.Reopen())
+	}
+	wg.Wait()
+
+	assert.Equal(t, 400*len("line\n"), len(readFile(t, name)))
+}
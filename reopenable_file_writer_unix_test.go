@@ -0,0 +1,40 @@
+//go:build !windows && !js
+// +build !windows,!js
+
+package logrus
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReopenOnSignal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "app.log")
+	w := &ReopenableFileWriter{Filename: name}
+	defer w.Close()
+	stop := ReopenOnSignal(w, syscall.SIGUSR1)
+	defer stop()
+
+	_, err = w.Write([]byte("before\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, os.Rename(name, name+".1"))
+	assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !exists(name) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, err = w.Write([]byte("after\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "after\n", readFile(t, name))
+}
@@ -0,0 +1,195 @@
+package logrus
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RetryHook fires a hook again when it fails, waiting for a backoff doubled
+// after every failure, e.g. for hooks shipping entries over the network:
+//
+//    logger.AddHook(logrus.NewRetryHook(httpHook, 3, 100*time.Millisecond))
+//
+// By default only the first attempt is made by the logging goroutine, the
+// retries are made by a background goroutine, one entry after the other, and
+// the entry is dropped for the hook if they all fail. The entries failing
+// while the queue of QueueSize entries waiting for their retries is full are
+// dropped, and counted by Dropped. Set Block to make the retries in the
+// logging goroutine instead, which holds up logging until the hook succeeds
+// or gives up. The last error is reported to the handler set with
+// `SetHookErrorHandler`. `Logger.Flush`, called on Fatal and Exit, waits for
+// the queued retries with Flush, Close stops the background goroutine.
+type RetryHook struct {
+	hook     Hook
+	attempts int
+	backoff  time.Duration
+
+	// Block makes the retries in the logging goroutine.
+	Block bool
+
+	// QueueSize is the number of entries waiting for their retries in the
+	// background, 100 if not set. It must be set before the hook is fired.
+	QueueSize int
+
+	start   sync.Once
+	queue   chan retriedEntry
+	dropped uint64
+
+	// Guards closed, Fire holds it for reading so that the queue isn't
+	// closed during a send.
+	closeMu sync.RWMutex
+	closed  bool
+	done    chan struct{}
+
+	// Number of queued entries not yet retried, for Flush.
+	pendingMu sync.Mutex
+	pending   int
+	flushed   *sync.Cond
+
+	// Replaced in tests.
+	sleep func(time.Duration)
+}
+
+// retriedEntry is an entry queued for its retries, with the error of its
+// first attempt.
+type retriedEntry struct {
+	entry *Entry
+	err   error
+}
+
+// NewRetryHook returns a hook firing hook up to attempts times, waiting
+// backoff before the first retry.
+func NewRetryHook(hook Hook, attempts int, backoff time.Duration) *RetryHook {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &RetryHook{hook: hook, attempts: attempts, backoff: backoff, sleep: time.Sleep}
+}
+
+func (hook *RetryHook) Levels() []Level {
+	return hook.hook.Levels()
+}
+
+func (hook *RetryHook) Fire(entry *Entry) error {
+	err := fireHook(hook.hook, entry)
+	if err == nil || hook.attempts == 1 {
+		return err
+	}
+	if hook.Block || entry.Logger == nil {
+		return hook.retry(err, func() error { return fireHook(hook.hook, entry) })
+	}
+
+	hook.closeMu.RLock()
+	defer hook.closeMu.RUnlock()
+	if hook.closed {
+		return err
+	}
+	hook.start.Do(func() {
+		size := hook.QueueSize
+		if size < 1 {
+			size = 100
+		}
+		hook.queue = make(chan retriedEntry, size)
+		hook.done = make(chan struct{})
+		go hook.run()
+	})
+	// The entry is reused by the logger once the hooks return
+	hook.addPending(1)
+	select {
+	case hook.queue <- retriedEntry{entry.Dup(), err}:
+		return nil
+	default:
+		hook.addPending(-1)
+		atomic.AddUint64(&hook.dropped, 1)
+		return err
+	}
+}
+
+// Flush waits until the entries queued so far are retried.
+func (hook *RetryHook) Flush() {
+	hook.pendingMu.Lock()
+	defer hook.pendingMu.Unlock()
+	for hook.pending > 0 {
+		hook.flushedCond().Wait()
+	}
+}
+
+// Close retries the remaining queued entries and stops the background
+// goroutine. The entries failing afterwards aren't retried. The wrapped hook
+// isn't closed.
+func (hook *RetryHook) Close() error {
+	hook.closeMu.Lock()
+	if hook.closed {
+		hook.closeMu.Unlock()
+		return nil
+	}
+	hook.closed = true
+	started := true
+	hook.start.Do(func() { started = false })
+	if started {
+		close(hook.queue)
+	}
+	hook.closeMu.Unlock()
+
+	if started {
+		<-hook.done
+	}
+	return nil
+}
+
+func (hook *RetryHook) addPending(n int) {
+	hook.pendingMu.Lock()
+	defer hook.pendingMu.Unlock()
+	hook.pending += n
+	if hook.pending == 0 {
+		hook.flushedCond().Broadcast()
+	}
+}
+
+// flushedCond returns the condition signaled when no entries are pending,
+// it is called with pendingMu locked.
+func (hook *RetryHook) flushedCond() *sync.Cond {
+	if hook.flushed == nil {
+		hook.flushed = sync.NewCond(&hook.pendingMu)
+	}
+	return hook.flushed
+}
+
+// Dropped returns the number of entries dropped without being retried
+// because the queue was full.
+func (hook *RetryHook) Dropped() uint64 {
+	return atomic.LoadUint64(&hook.dropped)
+}
+
+func (hook *RetryHook) run() {
+	defer close(hook.done)
+	for retried := range hook.queue {
+		entry, logger := retried.entry, retried.entry.Logger
+		err := hook.retry(retried.err, func() error {
+			// Hooks are always fired holding the lock of the logger
+			logger.mu.Lock()
+			defer logger.mu.Unlock()
+			return fireHook(hook.hook, entry)
+		})
+		if err != nil {
+			logger.handleHookError(err, entry)
+		}
+		hook.addPending(-1)
+	}
+}
+
+// retry calls fire again until it succeeds or the attempts are exhausted,
+// after the first attempt failed with err.
+func (hook *RetryHook) retry(err error, fire func() error) error {
+	backoff := hook.backoff
+	for attempt := 2; attempt <= hook.attempts; attempt++ {
+		hook.sleep(backoff)
+		backoff *= 2
+		if err = fire(); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%v, after %d attempts", err, hook.attempts)
+}
@@ -0,0 +1,178 @@
+package logrus
+
+import (
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyHook fails the given number of times before succeeding.
+type flakyHook struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	messages []string
+}
+
+func (hook *flakyHook) Levels() []Level {
+	return AllLevels
+}
+
+func (hook *flakyHook) Fire(entry *Entry) error {
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	hook.calls++
+	if hook.calls <= hook.failures {
+		return errors.New("unavailable")
+	}
+	hook.messages = append(hook.messages, entry.Message)
+	return nil
+}
+
+func TestRetryHookBlock(t *testing.T) {
+	inner := &flakyHook{failures: 2}
+	var waits []time.Duration
+	hook := NewRetryHook(inner, 3, 10*time.Millisecond)
+	hook.Block = true
+	hook.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+	logger.Info("shipped")
+
+	assert.Equal(t, 3, inner.calls)
+	assert.Equal(t, []string{"shipped"}, inner.messages)
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, waits)
+}
+
+func TestRetryHookGivesUp(t *testing.T) {
+	inner := &flakyHook{failures: 5}
+	hook := NewRetryHook(inner, 3, 0)
+	hook.Block = true
+	hook.sleep = func(time.Duration) {}
+
+	var handled []error
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+	logger.SetHookErrorHandler(func(err error, entry *Entry) {
+		assert.Equal(t, "lost", entry.Message)
+		handled = append(handled, err)
+	})
+	logger.Info("lost")
+
+	assert.Equal(t, 3, inner.calls)
+	assert.Equal(t, 1, len(handled))
+	assert.Equal(t, "unavailable, after 3 attempts", handled[0].Error())
+}
+
+func TestRetryHookBackground(t *testing.T) {
+	inner := &flakyHook{failures: 1}
+	hook := NewRetryHook(inner, 2, 0)
+	hook.sleep = func(time.Duration) {}
+
+	handled := make(chan error, 1)
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+	logger.SetHookErrorHandler(func(err error, entry *Entry) { handled <- err })
+	logger.Info("retried")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		inner.mu.Lock()
+		done := len(inner.messages) > 0
+		inner.mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	inner.mu.Lock()
+	assert.Equal(t, []string{"retried"}, inner.messages)
+	inner.mu.Unlock()
+	select {
+	case err := <-handled:
+		t.Fatal("unexpected hook error", err)
+	default:
+	}
+}
+
+func TestRetryHookQueueFull(t *testing.T) {
+	inner := &flakyHook{failures: 3}
+	hook := NewRetryHook(inner, 2, 0)
+	hook.QueueSize = 1
+	sleeping := make(chan struct{}, 1)
+	gate := make(chan struct{})
+	hook.sleep = func(time.Duration) {
+		sleeping <- struct{}{}
+		<-gate
+	}
+
+	var handled []string
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+	logger.SetHookErrorHandler(func(err error, entry *Entry) {
+		handled = append(handled, entry.Message)
+	})
+
+	logger.Info("retrying")
+	<-sleeping
+	logger.Info("queued")
+	logger.Info("dropped")
+	assert.Equal(t, uint64(1), hook.Dropped())
+	assert.Equal(t, []string{"dropped"}, handled)
+
+	close(gate)
+	logger.Flush()
+	inner.mu.Lock()
+	assert.Equal(t, []string{"retrying", "queued"}, inner.messages)
+	inner.mu.Unlock()
+}
+
+func TestRetryHookClose(t *testing.T) {
+	inner := &flakyHook{failures: 1}
+	hook := NewRetryHook(inner, 2, 0)
+	hook.sleep = func(time.Duration) {}
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+
+	logger.Info("retried")
+	assert.NoError(t, hook.Close())
+	assert.Equal(t, []string{"retried"}, inner.messages, "queued retries are made before closing")
+
+	inner.failures = inner.calls + 1
+	logger.SetHookErrorHandler(func(err error, entry *Entry) {})
+	logger.Info("not retried")
+	assert.Equal(t, []string{"retried"}, inner.messages)
+	assert.NoError(t, hook.Close())
+
+	assert.NoError(t, NewRetryHook(inner, 2, 0).Close())
+}
+
+func TestHookErrorHandler(t *testing.T) {
+	first := &flakyHook{failures: 1}
+	second := &flakyHook{}
+
+	var handled []string
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(first)
+	logger.AddHook(second)
+	logger.SetHookErrorHandler(func(err error, entry *Entry) {
+		handled = append(handled, err.Error())
+		// The lock of the logger isn't held
+		logger.WithField("handler", true).Debug("hook failed")
+	})
+	logger.Info("hello")
+
+	assert.Equal(t, []string{"unavailable"}, handled)
+	assert.Equal(t, []string{"hello"}, second.messages, "hooks following a failing one must be fired")
+}
@@ -0,0 +1,161 @@
+package logrus
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RFC5424Formatter formats logs into syslog messages as defined by RFC 5424,
+// see https://tools.ietf.org/html/rfc5424:
+//
+//    <12>1 2017-07-14T02:40:00.250000Z node1 app 1234 - [fields@32473 disk="/dev/sda1"] disk almost full
+//
+// Levels are reported as syslog severities and the fields of the entry as
+// the parameters of a single structured data element, sorted by key.
+type RFC5424Formatter struct {
+	// Facility is the syslog facility code the priority is computed from,
+	// defaults to 1 (user-level messages).
+	Facility int
+
+	// Host reported as the source of the messages, defaults to the hostname
+	// of the machine.
+	Host string
+
+	// AppName identifies the application, defaults to the name of the
+	// executable.
+	AppName string
+
+	// MsgID identifies the type of the messages, omitted by default.
+	MsgID string
+
+	// StructuredDataID is the ID of the structured data element holding the
+	// fields, defaults to "fields@32473", 32473 being the enterprise number
+	// reserved for documentation.
+	StructuredDataID string
+
+	// LevelNumbers customizes the severities reported for the levels,
+	// defaults to SyslogLevelNumbers.
+	LevelNumbers LevelNumbers
+}
+
+// Maximum lengths of the header fields, in bytes.
+const (
+	rfc5424MaxHostname = 255
+	rfc5424MaxAppName  = 48
+	rfc5424MaxProcID   = 128
+	rfc5424MaxMsgID    = 32
+	rfc5424MaxParam    = 32
+)
+
+// Format renders a single log entry
+func (f *RFC5424Formatter) Format(entry *Entry) ([]byte, error) {
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	facility := f.Facility
+	if facility == 0 {
+		facility = 1
+	}
+	levelNumbers := f.LevelNumbers
+	if levelNumbers == nil {
+		levelNumbers = SyslogLevelNumbers
+	}
+	host := f.Host
+	if host == "" {
+		host = getHostname()
+	}
+	appName := f.AppName
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+
+	fmt.Fprintf(b, "<%d>1 ", facility*8+levelNumbers.number(entry.Level))
+	b.WriteString(entry.Time.Format("2006-01-02T15:04:05.000000Z07:00"))
+	for _, field := range []struct {
+		value  string
+		maxLen int
+	}{
+		{host, rfc5424MaxHostname},
+		{appName, rfc5424MaxAppName},
+		{strconv.Itoa(getpid()), rfc5424MaxProcID},
+		{f.MsgID, rfc5424MaxMsgID},
+	} {
+		b.WriteByte(' ')
+		b.WriteString(rfc5424Name(field.value, field.maxLen))
+	}
+	b.WriteByte(' ')
+	f.appendStructuredData(b, entry.Data)
+	if entry.Message != "" {
+		b.WriteByte(' ')
+		b.WriteString(entry.Message)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// appendStructuredData writes the fields as an SD-ELEMENT, or the nil value
+// if there are none.
+func (f *RFC5424Formatter) appendStructuredData(b *bytes.Buffer, data Fields) {
+	if len(data) == 0 {
+		b.WriteByte('-')
+		return
+	}
+	id := f.StructuredDataID
+	if id == "" {
+		id = "fields@32473"
+	}
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b.WriteByte('[')
+	b.WriteString(rfc5424Name(id, rfc5424MaxParam))
+	for _, k := range keys {
+		var value string
+		switch v := data[k].(type) {
+		case string:
+			value = v
+		case error:
+			value = v.Error()
+		default:
+			value = fmt.Sprint(v)
+		}
+		fmt.Fprintf(b, " %s=\"%s\"", rfc5424Name(k, rfc5424MaxParam), rfc5424ParamEscaper.Replace(value))
+	}
+	b.WriteByte(']')
+}
+
+// Escapes the characters which can't appear as is in PARAM-VALUE.
+var rfc5424ParamEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// rfc5424Name returns value as a valid header field or SD-NAME, replacing
+// the characters other than printable ASCII by underscores and truncating it
+// to maxLen bytes. The characters "=", "]" and '"', not allowed in SD-NAMEs,
+// are replaced as well. Empty values are replaced by the nil value "-".
+func rfc5424Name(value string, maxLen int) string {
+	if value == "" {
+		return "-"
+	}
+	name := []byte(value)
+	if len(name) > maxLen {
+		name = name[:maxLen]
+	}
+	for i, c := range name {
+		if c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			name[i] = '_'
+		}
+	}
+	return string(name)
+}
@@ -0,0 +1,54 @@
+package logrus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRFC5424Formatter(t *testing.T) {
+	formatter := &RFC5424Formatter{Host: "node1", AppName: "app", MsgID: "DISK"}
+	entry := &Entry{
+		Time:    time.Unix(1500000000, 250000000).UTC(),
+		Level:   WarnLevel,
+		Message: "disk almost full",
+		Data:    Fields{"disk": "/dev/sda1", "error": errors.New("ENOSPC"), "used": 97},
+	}
+
+	b, err := formatter.Format(entry)
+	assert.NoError(t, err)
+	expected := fmt.Sprintf(`<12>1 2017-07-14T02:40:00.250000Z node1 app %d DISK [fields@32473 disk="/dev/sda1" error="ENOSPC" used="97"] disk almost full`+"\n", getpid())
+	assert.Equal(t, expected, string(b))
+}
+
+func TestRFC5424FormatterDefaults(t *testing.T) {
+	formatter := &RFC5424Formatter{Facility: 16, Host: "node1", AppName: "app", StructuredDataID: "app@1234"}
+
+	b, _ := formatter.Format(&Entry{Level: ErrorLevel, Message: "failure", Data: Fields{}})
+	assert.True(t, strings.HasPrefix(string(b), "<131>1 "), string(b))
+	assert.True(t, strings.HasSuffix(string(b), " - - failure\n"), "nil MSGID and structured data expected, got %q", string(b))
+
+	b, _ = formatter.Format(&Entry{Level: DebugLevel, Data: Fields{"a": 1}})
+	assert.True(t, strings.HasPrefix(string(b), "<135>1 "), string(b))
+	assert.True(t, strings.HasSuffix(string(b), ` - [app@1234 a="1"]`+"\n"), string(b))
+}
+
+func TestRFC5424FormatterEscaping(t *testing.T) {
+	formatter := &RFC5424Formatter{Host: "my host", AppName: "app"}
+	entry := &Entry{Level: InfoLevel, Data: Fields{
+		"quote":     `say "hi"`,
+		"path":      `C:\logs]`,
+		"bad key=x": "v",
+	}}
+
+	b, _ := formatter.Format(entry)
+	assert.Contains(t, string(b), " my_host app ")
+	assert.Contains(t, string(b), `quote="say \"hi\""`)
+	assert.Contains(t, string(b), `path="C:\\logs\]"`)
+	assert.Contains(t, string(b), `bad_key_x="v"`)
+	assert.Equal(t, strings.Repeat("a", 32), rfc5424Name(strings.Repeat("a", 40), rfc5424MaxParam))
+}
@@ -0,0 +1,73 @@
+package logrus
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RingBufferHook keeps the last entries logged in memory, formatted, e.g. to
+// include them in a support bundle or to expose them on an admin endpoint
+// regardless of where the logger writes:
+//
+//    hook := logrus.NewRingBufferHook(1000)
+//    logger.AddHook(hook)
+//    ...
+//    lines := hook.Snapshot()
+type RingBufferHook struct {
+	// Formatter used for the kept entries, defaults to the formatter of the
+	// logger of each entry.
+	Formatter Formatter
+
+	mu      sync.Mutex
+	entries []string
+	next    int
+	full    bool
+}
+
+// NewRingBufferHook returns a hook keeping the last capacity entries of any
+// level.
+func NewRingBufferHook(capacity int) *RingBufferHook {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &RingBufferHook{entries: make([]string, capacity)}
+}
+
+func (hook *RingBufferHook) Levels() []Level {
+	return AllLevels
+}
+
+func (hook *RingBufferHook) Fire(entry *Entry) error {
+	formatter := hook.Formatter
+	if formatter == nil {
+		formatter = entry.Logger.Formatter
+	}
+	serialized, err := formatter.Format(entry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to obtain reader, %v\n", err)
+		return nil
+	}
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	hook.entries[hook.next] = string(serialized)
+	hook.next++
+	if hook.next == len(hook.entries) {
+		hook.next = 0
+		hook.full = true
+	}
+	return nil
+}
+
+// Snapshot returns the kept entries, from the oldest to the newest.
+func (hook *RingBufferHook) Snapshot() []string {
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if !hook.full {
+		return append([]string(nil), hook.entries[:hook.next]...)
+	}
+	snapshot := make([]string, 0, len(hook.entries))
+	snapshot = append(snapshot, hook.entries[hook.next:]...)
+	return append(snapshot, hook.entries[:hook.next]...)
+}
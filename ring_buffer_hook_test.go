@@ -0,0 +1,70 @@
+package logrus
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type messageFormatter struct{}
+
+func (messageFormatter) Format(entry *Entry) ([]byte, error) {
+	return []byte(entry.Message), nil
+}
+
+func TestRingBufferHookWraparound(t *testing.T) {
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.Formatter = messageFormatter{}
+	hook := NewRingBufferHook(3)
+	logger.AddHook(hook)
+
+	assert.Empty(t, hook.Snapshot())
+
+	logger.Info("1")
+	logger.Info("2")
+	assert.Equal(t, []string{"1", "2"}, hook.Snapshot())
+
+	logger.Info("3")
+	assert.Equal(t, []string{"1", "2", "3"}, hook.Snapshot())
+
+	logger.Info("4")
+	logger.Info("5")
+	assert.Equal(t, []string{"3", "4", "5"}, hook.Snapshot())
+
+	hook.Formatter = &JSONFormatter{DisableTimestamp: true}
+	logger.Warn("6")
+	assert.Equal(t, `{"level":"warning","msg":"6"}`+"\n", hook.Snapshot()[2])
+}
+
+func TestRingBufferHookConcurrent(t *testing.T) {
+	logger := New()
+	logger.Out = ioutil.Discard
+	logger.Formatter = messageFormatter{}
+	hook := NewRingBufferHook(50)
+	logger.AddHook(hook)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				logger.Info(fmt.Sprintf("%d-%d", g, i))
+				hook.Snapshot()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	snapshot := hook.Snapshot()
+	assert.Len(t, snapshot, 50)
+	seen := make(map[string]bool)
+	for _, line := range snapshot {
+		assert.False(t, seen[line], "duplicate entry %s", line)
+		seen[line] = true
+	}
+}
@@ -0,0 +1,230 @@
+package logrus
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// RotatingFileWriter is an io.WriteCloser writing to a file which is rotated
+// once it reaches a size or an age, to be used as the output of a logger:
+//
+//    logger.Out = &logrus.RotatingFileWriter{
+//      Filename:   "/var/log/app.log",
+//      MaxSize:    100 << 20,
+//      Daily:      true,
+//      MaxBackups: 7,
+//      Compress:   true,
+//    }
+//
+// Rotated files are renamed app.log.1, app.log.2 and so on, the most recent
+// being app.log.1, with a .gz extension when compressed. It is safe for
+// concurrent use. Not being a terminal, the TextFormatter never colors what
+// is written to it.
+type RotatingFileWriter struct {
+	// Filename is the file written to, created if needed.
+	Filename string
+
+	// MaxSize rotates the file before a write would make it exceed MaxSize
+	// bytes. 0 disables rotating by size.
+	MaxSize int64
+
+	// MaxAge rotates the file once it has been written to for MaxAge. 0
+	// disables rotating by age.
+	MaxAge time.Duration
+
+	// Daily rotates the file on the first write of every day, in local
+	// time. A file left by a previous run is rotated if it was last written
+	// to on a previous day.
+	Daily bool
+
+	// MaxBackups is the number of rotated files kept, the oldest ones are
+	// removed. 0 keeps all of them.
+	MaxBackups int
+
+	// Compress gzips the rotated files.
+	Compress bool
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+	// Day of the entries in the file, with Daily.
+	day time.Time
+
+	// Returns the current time, replaced in tests.
+	now func() time.Time
+}
+
+// Write writes p to the file, rotating it first if needed.
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file regardless of its size and age.
+func (w *RotatingFileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rotate()
+}
+
+// Close closes the file, it is opened again on the next write.
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.close()
+}
+
+func (w *RotatingFileWriter) currentTime() time.Time {
+	if w.now == nil {
+		return now()
+	}
+	return w.now()
+}
+
+func (w *RotatingFileWriter) open() error {
+	file, err := os.OpenFile(w.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	w.opened = w.currentTime()
+	w.day = w.opened
+	if w.size > 0 {
+		w.day = info.ModTime()
+	}
+	return nil
+}
+
+func (w *RotatingFileWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *RotatingFileWriter) shouldRotate(n int) bool {
+	if w.MaxSize > 0 && w.size > 0 && w.size+int64(n) > w.MaxSize {
+		return true
+	}
+	t := w.currentTime()
+	if w.Daily && !sameDay(w.day, t) {
+		return true
+	}
+	return w.MaxAge > 0 && t.Sub(w.opened) >= w.MaxAge
+}
+
+// sameDay reports whether a and b fall on the same day in local time.
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Local().Date()
+	by, bm, bd := b.Local().Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// backupName returns the name of the i-th most recent rotated file.
+func (w *RotatingFileWriter) backupName(i int) string {
+	name := fmt.Sprintf("%s.%d", w.Filename, i)
+	if w.Compress {
+		name += ".gz"
+	}
+	return name
+}
+
+func (w *RotatingFileWriter) rotate() error {
+	if w.file != nil {
+		if err := w.file.Sync(); err != nil {
+			return err
+		}
+	}
+	if err := w.close(); err != nil {
+		return err
+	}
+
+	last := 0
+	for exists(w.backupName(last + 1)) {
+		last++
+	}
+	for i := last; i > 0; i-- {
+		if w.MaxBackups > 0 && i >= w.MaxBackups {
+			if err := os.Remove(w.backupName(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.Rename(w.backupName(i), w.backupName(i+1)); err != nil {
+			return err
+		}
+	}
+
+	if exists(w.Filename) {
+		var err error
+		if w.Compress {
+			err = compressFile(w.Filename, w.backupName(1))
+		} else {
+			err = os.Rename(w.Filename, w.backupName(1))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return w.open()
+}
+
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// compressFile gzips src into dst and removes src.
+func compressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,135 @@
+package logrus
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readGzip(t *testing.T, name string) string {
+	file, err := os.Open(name)
+	assert.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	assert.NoError(t, err)
+	content, err := ioutil.ReadAll(gz)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func readFile(t *testing.T, name string) string {
+	content, err := ioutil.ReadFile(name)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func TestRotatingFileWriterSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "app.log")
+	w := &RotatingFileWriter{Filename: name, MaxSize: 10, Compress: true}
+	defer w.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		n, err := w.Write([]byte(line))
+		assert.NoError(t, err)
+		assert.Equal(t, len(line), n)
+	}
+
+	assert.Equal(t, "fourth\n", readFile(t, name))
+	assert.Equal(t, "third\n", readGzip(t, name+".1.gz"))
+	assert.Equal(t, "second\n", readGzip(t, name+".2.gz"))
+	assert.Equal(t, "first\n", readGzip(t, name+".3.gz"))
+	assert.False(t, exists(name+".1"), "uncompressed segment must be removed")
+}
+
+func TestRotatingFileWriterMaxBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "app.log")
+	w := &RotatingFileWriter{Filename: name, MaxSize: 1, MaxBackups: 2}
+	defer w.Close()
+
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n"} {
+		_, err := w.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, "4\n", readFile(t, name))
+	assert.Equal(t, "3\n", readFile(t, name+".1"))
+	assert.Equal(t, "2\n", readFile(t, name+".2"))
+	assert.False(t, exists(name+".3"))
+}
+
+func TestRotatingFileWriterAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Unix(1500000000, 0)
+	name := filepath.Join(dir, "app.log")
+	w := &RotatingFileWriter{Filename: name, MaxAge: time.Hour, now: func() time.Time { return now }}
+	defer w.Close()
+
+	w.Write([]byte("old\n"))
+	now = now.Add(30 * time.Minute)
+	w.Write([]byte("still old\n"))
+	now = now.Add(30 * time.Minute)
+	w.Write([]byte("new\n"))
+
+	assert.Equal(t, "new\n", readFile(t, name))
+	assert.Equal(t, "old\nstill old\n", readFile(t, name+".1"))
+}
+
+func TestRotatingFileWriterIsNotTerminal(t *testing.T) {
+	w := &RotatingFileWriter{Filename: filepath.Join(os.TempDir(), "unused.log")}
+	assert.False(t, checkIfTerminal(w))
+	assert.False(t, (&TextFormatter{}).isTerminal(w))
+}
+
+func TestRotatingFileWriterDaily(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2018, 3, 4, 23, 0, 0, 0, time.Local)
+	name := filepath.Join(dir, "app.log")
+	w := &RotatingFileWriter{Filename: name, Daily: true, now: func() time.Time { return now }}
+	defer w.Close()
+
+	w.Write([]byte("monday\n"))
+	now = now.Add(59 * time.Minute)
+	w.Write([]byte("still monday\n"))
+	now = now.Add(2 * time.Minute)
+	w.Write([]byte("tuesday\n"))
+
+	assert.Equal(t, "tuesday\n", readFile(t, name))
+	assert.Equal(t, "monday\nstill monday\n", readFile(t, name+".1"))
+}
+
+func TestRotatingFileWriterDailyExistingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "app.log")
+	assert.NoError(t, ioutil.WriteFile(name, []byte("yesterday\n"), 0644))
+	yesterday := time.Now().Add(-24 * time.Hour)
+	assert.NoError(t, os.Chtimes(name, yesterday, yesterday))
+
+	w := &RotatingFileWriter{Filename: name, Daily: true}
+	defer w.Close()
+	w.Write([]byte("today\n"))
+
+	assert.Equal(t, "today\n", readFile(t, name))
+	assert.Equal(t, "yesterday\n", readFile(t, name+".1"))
+}
@@ -0,0 +1,79 @@
+package logrus
+
+import (
+	"os"
+	"runtime"
+	"sync/atomic"
+)
+
+// RuntimeInfo provides the process ID, thread ID and OS printed by the
+// formatters, see `SetRuntimeInfo`.
+type RuntimeInfo interface {
+	// PID returns the ID of the process.
+	PID() int
+	// TID returns the ID of the OS thread running the calling goroutine.
+	TID() int
+	// OS returns the name of the OS, as runtime.GOOS.
+	OS() string
+}
+
+// systemRuntimeInfo is the default RuntimeInfo. The process ID is looked up
+// once since it can't change, the thread ID is looked up on every call with
+// the GetCurrentThreadId of the platform.
+type systemRuntimeInfo struct {
+	pid int
+}
+
+func (info systemRuntimeInfo) PID() int {
+	return info.pid
+}
+
+func (info systemRuntimeInfo) TID() int {
+	return GetCurrentThreadId()
+}
+
+func (info systemRuntimeInfo) OS() string {
+	return runtime.GOOS
+}
+
+// runtimeInfoHolder wraps the RuntimeInfo, atomic.Value requires a
+// consistent concrete type.
+type runtimeInfoHolder struct {
+	RuntimeInfo
+}
+
+var (
+	defaultRuntimeInfo = systemRuntimeInfo{pid: os.Getpid()}
+
+	runtimeInfo atomic.Value
+)
+
+func init() {
+	runtimeInfo.Store(runtimeInfoHolder{defaultRuntimeInfo})
+}
+
+// SetRuntimeInfo replaces the provider of the process ID, thread ID and OS
+// of all the formatters, e.g. to report the ones of a sandboxed process or to
+// get stable output in tests. A nil info restores the default provider.
+func SetRuntimeInfo(info RuntimeInfo) {
+	if info == nil {
+		info = defaultRuntimeInfo
+	}
+	runtimeInfo.Store(runtimeInfoHolder{info})
+}
+
+func currentRuntimeInfo() RuntimeInfo {
+	return runtimeInfo.Load().(runtimeInfoHolder).RuntimeInfo
+}
+
+func getpid() int {
+	return currentRuntimeInfo().PID()
+}
+
+func getCurrentThreadID() int {
+	return currentRuntimeInfo().TID()
+}
+
+func getOS() string {
+	return currentRuntimeInfo().OS()
+}
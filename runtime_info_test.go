@@ -0,0 +1,47 @@
+package logrus
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubRuntimeInfo returns fixed values.
+type stubRuntimeInfo struct {
+	pid, tid int
+	os       string
+}
+
+func (info stubRuntimeInfo) PID() int   { return info.pid }
+func (info stubRuntimeInfo) TID() int   { return info.tid }
+func (info stubRuntimeInfo) OS() string { return info.os }
+
+// panickingRuntimeInfo panics when the process or thread ID is requested.
+type panickingRuntimeInfo struct{}
+
+func (panickingRuntimeInfo) PID() int   { panic("PID called") }
+func (panickingRuntimeInfo) TID() int   { panic("TID called") }
+func (panickingRuntimeInfo) OS() string { return runtime.GOOS }
+
+func TestDefaultRuntimeInfo(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	assert.Equal(t, os.Getpid(), getpid())
+	assert.Equal(t, GetCurrentThreadId(), getCurrentThreadID())
+	assert.Equal(t, runtime.GOOS, getOS())
+}
+
+func TestSetRuntimeInfo(t *testing.T) {
+	defer SetRuntimeInfo(nil)
+	SetRuntimeInfo(stubRuntimeInfo{pid: 10, tid: 20, os: "windows"})
+
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true}
+	b, _ := tf.Format(&Entry{Level: InfoLevel, Message: "msg", Data: Fields{}})
+	assert.Contains(t, string(b), "[info] [pid 10] [tid 20] [W] msg")
+
+	SetRuntimeInfo(nil)
+	assert.Equal(t, os.Getpid(), getpid())
+}
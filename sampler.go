@@ -0,0 +1,136 @@
+package logrus
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Sampler decides which entries a logger keeps, see `SetSampler`. Sample is
+// called with the level and the message of the entry set, before the hooks
+// are fired and before the entry is formatted, and may be called
+// concurrently.
+type Sampler interface {
+	Sample(entry *Entry) bool
+}
+
+// Number of distinct levels and messages tracked by the included samplers,
+// past which the state of the least recently logged one is forgotten, which
+// keeps the memory used bounded. A message logged again after being
+// forgotten is sampled as if it were new.
+const samplerKeys = 4096
+
+type sampleKey struct {
+	level   Level
+	message string
+}
+
+// sampleStates holds the sampling state of each level and message, created
+// with newState, in the order they were last logged.
+type sampleStates struct {
+	newState func() interface{}
+	states   map[sampleKey]*list.Element
+	order    *list.List
+}
+
+type keyedState struct {
+	key   sampleKey
+	state interface{}
+}
+
+func newSampleStates(newState func() interface{}) *sampleStates {
+	return &sampleStates{
+		newState: newState,
+		states:   make(map[sampleKey]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get returns the state of the level and message of entry, creating it if
+// they haven't been logged yet or were forgotten.
+func (s *sampleStates) get(entry *Entry) interface{} {
+	key := sampleKey{level: entry.Level, message: entry.Message}
+	if e, ok := s.states[key]; ok {
+		s.order.MoveToFront(e)
+		return e.Value.(*keyedState).state
+	}
+	if s.order.Len() == samplerKeys {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.states, oldest.Value.(*keyedState).key)
+	}
+	state := s.newState()
+	s.states[key] = s.order.PushFront(&keyedState{key: key, state: state})
+	return state
+}
+
+// TokenBucketSampler keeps at most a given number of entries per second for
+// each message and level, with bursts of up to that number of entries.
+type TokenBucketSampler struct {
+	mu      sync.Mutex
+	buckets *sampleStates
+}
+
+// NewTokenBucketSampler returns a sampler keeping at most perSecond entries
+// per second of each message and level:
+//
+//    logger.SetSampler(logrus.NewTokenBucketSampler(10))
+func NewTokenBucketSampler(perSecond int) *TokenBucketSampler {
+	return &TokenBucketSampler{buckets: newSampleStates(func() interface{} {
+		return &tokenBucket{perSecond: float64(perSecond)}
+	})}
+}
+
+func (s *TokenBucketSampler) Sample(entry *Entry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buckets.get(entry).(*tokenBucket).take(now())
+}
+
+// FirstNSampler keeps, for each message and level, the first entries logged
+// in every tick, then one in every given number of entries until the next
+// tick, like zap's sampler.
+type FirstNSampler struct {
+	tick       time.Duration
+	first      uint64
+	thereafter uint64
+
+	mu       sync.Mutex
+	counters *sampleStates
+}
+
+type sampleCounter struct {
+	resetAt time.Time
+	n       uint64
+}
+
+// NewFirstNSampler returns a sampler keeping the first entries of each
+// message and level logged every tick, then every thereafter-th one. A
+// thereafter of 0 drops all the others:
+//
+//    logger.SetSampler(logrus.NewFirstNSampler(time.Second, 100, 100))
+func NewFirstNSampler(tick time.Duration, first, thereafter int) *FirstNSampler {
+	return &FirstNSampler{
+		tick:       tick,
+		first:      uint64(first),
+		thereafter: uint64(thereafter),
+		counters:   newSampleStates(func() interface{} { return new(sampleCounter) }),
+	}
+}
+
+func (s *FirstNSampler) Sample(entry *Entry) bool {
+	t := now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counter := s.counters.get(entry).(*sampleCounter)
+	if !t.Before(counter.resetAt) {
+		counter.resetAt = t.Add(s.tick)
+		counter.n = 0
+	}
+	counter.n++
+	if counter.n <= s.first {
+		return true
+	}
+	return s.thereafter > 0 && (counter.n-s.first)%s.thereafter == 0
+}
@@ -0,0 +1,111 @@
+package logrus
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingHook struct {
+	fired int
+}
+
+func (hook *countingHook) Levels() []Level {
+	return AllLevels
+}
+
+func (hook *countingHook) Fire(entry *Entry) error {
+	hook.fired++
+	return nil
+}
+
+func TestFirstNSampler(t *testing.T) {
+	clock := time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)
+	defer func(saved func() time.Time) { now = saved }(now)
+	now = func() time.Time { return clock }
+
+	s := NewFirstNSampler(time.Second, 2, 3)
+	warning := &Entry{Level: WarnLevel, Message: "disk almost full"}
+
+	var kept []int
+	for i := 1; i <= 10; i++ {
+		if s.Sample(warning) {
+			kept = append(kept, i)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 5, 8}, kept)
+
+	assert.True(t, s.Sample(&Entry{Level: WarnLevel, Message: "other"}), "messages are sampled separately")
+	assert.True(t, s.Sample(&Entry{Level: ErrorLevel, Message: "disk almost full"}), "levels are sampled separately")
+
+	clock = clock.Add(time.Second)
+	assert.True(t, s.Sample(warning), "counts restart every tick")
+
+	s = NewFirstNSampler(time.Second, 1, 0)
+	assert.True(t, s.Sample(warning))
+	assert.False(t, s.Sample(warning))
+}
+
+func TestSamplerKeys(t *testing.T) {
+	clock := time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)
+	defer func(saved func() time.Time) { now = saved }(now)
+	now = func() time.Time { return clock }
+
+	s := NewFirstNSampler(time.Minute, 1, 0)
+	for i := 0; i < samplerKeys; i++ {
+		assert.True(t, s.Sample(&Entry{Level: InfoLevel, Message: fmt.Sprintf("message %d", i)}), "messages must not share their state")
+	}
+	assert.False(t, s.Sample(&Entry{Level: InfoLevel, Message: "message 0"}))
+
+	assert.True(t, s.Sample(&Entry{Level: InfoLevel, Message: "one more"}))
+	assert.True(t, s.Sample(&Entry{Level: InfoLevel, Message: "message 1"}), "the least recently logged message is forgotten")
+	assert.False(t, s.Sample(&Entry{Level: InfoLevel, Message: "message 0"}), "recently logged messages are kept")
+}
+
+func TestTokenBucketSampler(t *testing.T) {
+	clock := time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)
+	defer func(saved func() time.Time) { now = saved }(now)
+	now = func() time.Time { return clock }
+
+	s := NewTokenBucketSampler(2)
+	warning := &Entry{Level: WarnLevel, Message: "disk almost full"}
+
+	assert.True(t, s.Sample(warning))
+	assert.True(t, s.Sample(warning))
+	assert.False(t, s.Sample(warning))
+	assert.True(t, s.Sample(&Entry{Level: WarnLevel, Message: "other"}))
+
+	clock = clock.Add(500 * time.Millisecond)
+	assert.True(t, s.Sample(warning))
+	assert.False(t, s.Sample(warning))
+}
+
+func TestLoggerSetSampler(t *testing.T) {
+	var buffer bytes.Buffer
+	hook := new(countingHook)
+
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &TextFormatter{DisableColors: true, DisableTimestamp: true, DisableRuntimeFields: true}
+	logger.AddHook(hook)
+	logger.SetSampler(NewFirstNSampler(time.Minute, 1, 0))
+
+	for i := 0; i < 5; i++ {
+		logger.Warn("repeated")
+	}
+	logger.Info("once")
+	assert.Equal(t, 2, strings.Count(buffer.String(), "\n"))
+	assert.Equal(t, 2, hook.fired, "hooks must not fire for sampled out entries")
+
+	assert.Panics(t, func() { logger.Panic("repeated") })
+	assert.Panics(t, func() { logger.Panic("repeated") })
+	assert.Equal(t, 2, strings.Count(buffer.String(), "[panic]"), "panic entries must not be sampled")
+
+	logger.SetSampler(nil)
+	logger.Warn("repeated")
+	assert.Equal(t, 5, strings.Count(buffer.String(), "\n"))
+}
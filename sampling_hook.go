@@ -0,0 +1,112 @@
+package logrus
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defines the key of the number of entries suppressed by a RateLimitHook
+// since the previous entry it let through.
+var SuppressedKey = "suppressed"
+
+// SamplingHook keeps only 1 in N entries of a level, the others are dropped
+// before being formatted. Kept entries carry the rate N in the SampleRateKey
+// field so that counts can be corrected downstream.
+type SamplingHook struct {
+	level  Level
+	everyN uint64
+	seen   uint64
+}
+
+// NewSamplingHook returns a hook keeping 1 in everyN entries of level:
+//
+//    logger.AddHook(logrus.NewSamplingHook(logrus.DebugLevel, 100))
+func NewSamplingHook(level Level, everyN int) *SamplingHook {
+	if everyN < 1 {
+		everyN = 1
+	}
+	return &SamplingHook{level: level, everyN: uint64(everyN)}
+}
+
+func (hook *SamplingHook) Levels() []Level {
+	return []Level{hook.level}
+}
+
+func (hook *SamplingHook) Fire(entry *Entry) error {
+	n := atomic.AddUint64(&hook.seen, 1)
+	if (n-1)%hook.everyN != 0 {
+		entry.Drop()
+		return nil
+	}
+	setField(entry, SampleRateKey, int(hook.everyN))
+	return nil
+}
+
+// setField adds a field to a copy of the data of entry, since the data may
+// be shared with the entry it was logged from, and with other goroutines.
+func setField(entry *Entry, key string, value interface{}) {
+	data := make(Fields, len(entry.Data)+1)
+	MergeFields(data, entry.Data)
+	data[key] = value
+	entry.Data = data
+}
+
+// Suppressed returns the number of entries dropped by the hook so far.
+func (hook *SamplingHook) Suppressed() uint64 {
+	seen := atomic.LoadUint64(&hook.seen)
+	return seen - (seen+hook.everyN-1)/hook.everyN
+}
+
+// RateLimitHook lets at most a given number of entries of a level per second
+// through, using a token bucket, the others are dropped before being
+// formatted. The next entry let through carries the number of entries
+// suppressed in between in the SuppressedKey field.
+type RateLimitHook struct {
+	level Level
+
+	mu         sync.Mutex
+	bucket     tokenBucket
+	suppressed uint64
+	total      uint64
+
+	// Returns the current time, replaced in tests.
+	now func() time.Time
+}
+
+// NewRateLimitHook returns a hook letting at most perSecond entries of level
+// through every second:
+//
+//    logger.AddHook(logrus.NewRateLimitHook(logrus.WarnLevel, 100))
+func NewRateLimitHook(level Level, perSecond int) *RateLimitHook {
+	return &RateLimitHook{level: level, bucket: tokenBucket{perSecond: float64(perSecond)}, now: time.Now}
+}
+
+func (hook *RateLimitHook) Levels() []Level {
+	return []Level{hook.level}
+}
+
+func (hook *RateLimitHook) Fire(entry *Entry) error {
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+
+	if !hook.bucket.take(hook.now()) {
+		hook.suppressed++
+		hook.total++
+		entry.Drop()
+		return nil
+	}
+
+	if hook.suppressed > 0 {
+		setField(entry, SuppressedKey, hook.suppressed)
+		hook.suppressed = 0
+	}
+	return nil
+}
+
+// Suppressed returns the number of entries dropped by the hook so far.
+func (hook *RateLimitHook) Suppressed() uint64 {
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	return hook.total
+}
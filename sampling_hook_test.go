@@ -0,0 +1,118 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeLines(t *testing.T, buffer *bytes.Buffer) []Fields {
+	var entries []Fields
+	for _, line := range strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		var fields Fields
+		assert.Nil(t, json.Unmarshal([]byte(line), &fields))
+		entries = append(entries, fields)
+	}
+	return entries
+}
+
+func TestSamplingHook(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = new(JSONFormatter)
+	logger.Level = DebugLevel
+
+	hook := NewSamplingHook(DebugLevel, 3)
+	logger.AddHook(hook)
+
+	for i := 0; i < 10; i++ {
+		logger.WithField("i", i).Debug("sampled")
+		logger.Info("not sampled")
+	}
+
+	var kept []float64
+	for _, fields := range decodeLines(t, &buffer) {
+		if fields["msg"] == "sampled" {
+			kept = append(kept, fields["i"].(float64))
+			assert.Equal(t, float64(3), fields["sample_rate"])
+		} else {
+			assert.Nil(t, fields["sample_rate"])
+		}
+	}
+
+	assert.Equal(t, []float64{0, 3, 6, 9}, kept)
+	assert.Equal(t, uint64(6), hook.Suppressed())
+
+	// Fields added by the hook must not leak into reused entries.
+	buffer.Reset()
+	logger.Hooks = make(LevelHooks)
+	logger.AddHook(NewSamplingHook(DebugLevel, 1))
+	logger.Debug("sampled")
+	logger.Info("not sampled")
+	entries := decodeLines(t, &buffer)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, float64(1), entries[0]["sample_rate"])
+	assert.Nil(t, entries[1]["sample_rate"])
+}
+
+func TestRateLimitHook(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = new(JSONFormatter)
+
+	now := time.Date(2018, time.June, 22, 7, 27, 57, 0, time.UTC)
+	hook := NewRateLimitHook(WarnLevel, 2)
+	hook.now = func() time.Time { return now }
+	logger.AddHook(hook)
+
+	for i := 0; i < 5; i++ {
+		logger.Warn("burst")
+	}
+	now = now.Add(time.Second)
+	for i := 0; i < 5; i++ {
+		logger.Warn("next second")
+	}
+	now = now.Add(250 * time.Millisecond)
+	logger.Warn("too early")
+
+	entries := decodeLines(t, &buffer)
+	assert.Equal(t, 4, len(entries))
+	assert.Equal(t, "burst", entries[0]["msg"])
+	assert.Nil(t, entries[0]["suppressed"])
+	assert.Equal(t, "burst", entries[1]["msg"])
+	assert.Equal(t, "next second", entries[2]["msg"])
+	assert.Equal(t, float64(3), entries[2]["suppressed"])
+	assert.Equal(t, "next second", entries[3]["msg"])
+	assert.Nil(t, entries[3]["suppressed"])
+	assert.Equal(t, uint64(7), hook.Suppressed())
+}
+
+func TestSamplingHooksDontModifySharedData(t *testing.T) {
+	data := Fields{SuppressedKey: "user value"}
+
+	entry := &Entry{Data: data}
+	assert.Nil(t, NewSamplingHook(InfoLevel, 2).Fire(entry))
+	assert.Equal(t, 2, entry.Data[SampleRateKey])
+
+	hook := NewRateLimitHook(InfoLevel, 1)
+	now := time.Date(2018, time.June, 22, 7, 27, 57, 0, time.UTC)
+	hook.now = func() time.Time { return now }
+	entry = &Entry{Data: data}
+	assert.Nil(t, hook.Fire(entry))
+	assert.Nil(t, hook.Fire(&Entry{Data: data}))
+	now = now.Add(time.Second)
+	entry = &Entry{Data: data}
+	assert.Nil(t, hook.Fire(entry))
+	assert.Equal(t, uint64(1), entry.Data[SuppressedKey])
+
+	assert.Equal(t, Fields{SuppressedKey: "user value"}, data)
+}
@@ -0,0 +1,119 @@
+//go:build go1.21
+// +build go1.21
+
+package logrus
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// SlogHandler is a slog.Handler logging the records with a Logger, so that
+// code using log/slog goes through the formatters and hooks of the logger:
+//
+//    slog.SetDefault(slog.New(logrus.NewSlogHandler(logger)))
+//
+// The attributes become fields, the keys of the attributes of groups being
+// prefixed with the names of the groups and a dot, e.g. "request.id". The
+// levels of slog are mapped to the closest logrus level: below Debug to
+// Trace, and Error and above to Error, slog having no fatal level.
+type SlogHandler struct {
+	logger *Logger
+	fields Fields
+	// Names of the open groups, each followed by a dot.
+	prefix string
+}
+
+// NewSlogHandler returns a slog.Handler logging with logger.
+func NewSlogHandler(logger *Logger) *SlogHandler {
+	return &SlogHandler{logger: logger}
+}
+
+// Enabled reports whether the logger logs entries of the logrus level of
+// level.
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.IsLevelEnabled(levelFromSlog(level))
+}
+
+// Handle logs the record, with its time and, if the logger reports the
+// caller, the caller recorded by slog.
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	level := levelFromSlog(record.Level)
+	if !h.logger.IsLevelEnabled(level) {
+		return nil
+	}
+	entry := newPooledEntry(h.logger, len(h.fields)+record.NumAttrs())
+	for k, v := range h.fields {
+		entry.Data[k] = v
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		addSlogAttr(entry.Data, h.prefix, attr)
+		return true
+	})
+	entry.Context = ctx
+	entry.Time = record.Time
+	if record.PC != 0 && h.logger.ReportsCaller() {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.Caller = &frame
+	}
+	entry.log(level, record.Message)
+	entry.Release()
+	return nil
+}
+
+// WithAttrs returns a handler adding attrs to the fields of the records.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, attr := range attrs {
+		addSlogAttr(fields, h.prefix, attr)
+	}
+	return &SlogHandler{logger: h.logger, fields: fields, prefix: h.prefix}
+}
+
+// WithGroup returns a handler prefixing the keys of the attributes added
+// next with name and a dot.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{logger: h.logger, fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+// addSlogAttr adds attr to fields, following the rules of slog.Handler:
+// empty attributes are left out and the attributes of groups without key
+// are inlined.
+func addSlogAttr(fields Fields, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() != slog.KindGroup {
+		fields[prefix+attr.Key] = attr.Value.Any()
+		return
+	}
+	if attr.Key != "" {
+		prefix += attr.Key + "."
+	}
+	for _, attr := range attr.Value.Group() {
+		addSlogAttr(fields, prefix, attr)
+	}
+}
+
+// levelFromSlog returns the logrus level closest to level.
+func levelFromSlog(level slog.Level) Level {
+	switch {
+	case level < slog.LevelDebug:
+		return TraceLevel
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slog.LevelError:
+		return WarnLevel
+	}
+	return ErrorLevel
+}
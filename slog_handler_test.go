@@ -0,0 +1,82 @@
+//go:build go1.21
+// +build go1.21
+
+package logrus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = new(JSONFormatter)
+	logger.SetLevel(DebugLevel)
+
+	hook := new(TestHook)
+	logger.AddHook(hook)
+
+	log := slog.New(NewSlogHandler(logger)).With("service", "billing").WithGroup("request")
+	log.Debug("handled", "id", 42, slog.Group("user", "name", "ada"), slog.Group("", "inlined", true), slog.Attr{})
+	assert.True(t, hook.Fired)
+
+	var fields Fields
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &fields))
+	assert.Equal(t, Fields{
+		"level":             "debug",
+		"msg":               "handled",
+		"time":              fields["time"],
+		"service":           "billing",
+		"request.id":        float64(42),
+		"request.user.name": "ada",
+		"request.inlined":   true,
+	}, fields)
+
+	buffer.Reset()
+	log.Log(context.Background(), slog.LevelDebug-1, "trace")
+	assert.Equal(t, 0, buffer.Len())
+
+	slog.New(NewSlogHandler(logger)).Error("failed", "error", errors.New("timeout"))
+	assert.Contains(t, buffer.String(), `"error":"timeout","level":"error"`)
+}
+
+func TestSlogHandlerKeepsTimeAndCaller(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &LogfmtFormatter{TimestampFormat: time.RFC3339}
+	logger.SetReportCaller(true)
+
+	handler := NewSlogHandler(logger)
+	record := slog.NewRecord(time.Date(2020, 2, 1, 12, 0, 0, 0, time.UTC), slog.LevelWarn, "late", 0)
+	assert.NoError(t, handler.Handle(context.Background(), record))
+	assert.Contains(t, buffer.String(), "time=2020-02-01T12:00:00Z ")
+
+	buffer.Reset()
+	slog.New(handler).Info("called")
+	assert.True(t, strings.Contains(buffer.String(), "slog_handler_test.go"), buffer.String())
+}
+
+func TestLevelFromSlog(t *testing.T) {
+	for level, expected := range map[slog.Level]Level{
+		slog.LevelDebug - 4: TraceLevel,
+		slog.LevelDebug:     DebugLevel,
+		slog.LevelInfo:      InfoLevel,
+		slog.LevelInfo + 2:  InfoLevel,
+		slog.LevelWarn:      WarnLevel,
+		slog.LevelError:     ErrorLevel,
+		slog.LevelError + 4: ErrorLevel,
+	} {
+		assert.Equal(t, expected, levelFromSlog(level), level.String())
+	}
+}
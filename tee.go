@@ -0,0 +1,35 @@
+package logrus
+
+import "io"
+
+// TeeOutput is a destination of the entries of a logger created with
+// NewTeeLogger, with its own formatter.
+type TeeOutput struct {
+	Out       io.Writer
+	Formatter Formatter
+}
+
+// NewTeeLogger returns a logger writing each entry to several outputs, each
+// formatted with the formatter of the output, e.g. colored text to the
+// terminal and JSON to a file:
+//
+//    logger := logrus.NewTeeLogger(
+//      logrus.TeeOutput{Out: os.Stderr, Formatter: new(logrus.TextFormatter)},
+//      logrus.TeeOutput{Out: file, Formatter: new(logrus.JSONFormatter)},
+//    )
+//
+// Unlike setting Out to an io.MultiWriter, for which the TextFormatter never
+// colors the output since it can't tell whether all of its writers are
+// terminals, colors are decided for each output separately.
+func NewTeeLogger(outputs ...TeeOutput) *Logger {
+	logger := New()
+	if len(outputs) == 0 {
+		return logger
+	}
+	logger.Out = outputs[0].Out
+	logger.Formatter = outputs[0].Formatter
+	for _, output := range outputs[1:] {
+		logger.AddOutput(output.Out, output.Formatter)
+	}
+	return logger
+}
@@ -0,0 +1,44 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiWriterIsNotTerminal(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.False(t, checkIfTerminal(io.MultiWriter(os.Stdout, &buffer)))
+
+	var other bytes.Buffer
+	logger := New()
+	logger.Out = io.MultiWriter(&buffer, &other)
+	logger.Formatter = &TextFormatter{DisableTimestamp: true}
+	logger.Info("plain")
+	assert.NotContains(t, buffer.String(), "\x1b[")
+}
+
+func TestTeeLogger(t *testing.T) {
+	var terminal, file bytes.Buffer
+	logger := NewTeeLogger(
+		TeeOutput{Out: &terminal, Formatter: &TextFormatter{DisableTimestamp: true, TerminalChecker: func(w io.Writer) bool { return w == &terminal }}},
+		TeeOutput{Out: &file, Formatter: &TextFormatter{DisableTimestamp: true, TerminalChecker: func(w io.Writer) bool { return w == &terminal }}},
+		TeeOutput{Out: &file, Formatter: &JSONFormatter{DisableTimestamp: true}},
+	)
+
+	logger.WithField("foo", "bar").Warn("teed")
+	logger.Debug("filtered")
+
+	assert.Equal(t, "\x1b[33mWARN\x1b[0m teed"+string(bytes.Repeat([]byte(" "), 40))+"  \x1b[33mfoo\x1b[0m=bar\n", terminal.String())
+
+	lines := bytes.SplitN(file.Bytes(), []byte("\n"), 2)
+	assert.NotContains(t, string(lines[0]), "\x1b[", "file output must not be colored")
+	assert.Contains(t, string(lines[0]), "[warning]")
+	var fields Fields
+	assert.NoError(t, json.Unmarshal(lines[1], &fields))
+	assert.Equal(t, Fields{"level": "warning", "msg": "teed", "foo": "bar"}, fields)
+}
@@ -0,0 +1,31 @@
+// +build !appengine,!gopherjs,windows
+
+package logrus
+
+import (
+	"io"
+	"os"
+	"unsafe"
+)
+
+// checkIfTerminal reports whether w is a console. Virtual terminal processing
+// is enabled on the console so that the ANSI colors of printColored are
+// interpreted, consoles not supporting it (before Windows 10) are treated as
+// not being a terminal so that no colors are written to them.
+func checkIfTerminal(w io.Writer) bool {
+	switch v := w.(type) {
+	case *os.File:
+		var mode uint32
+		r, _, _ := procGetConsoleMode.Call(v.Fd(), uintptr(unsafe.Pointer(&mode)))
+		if r == 0 {
+			return false
+		}
+		if mode&enableVirtualTerminalProcessing != 0 {
+			return true
+		}
+		r, _, _ = procSetConsoleMode.Call(v.Fd(), uintptr(mode|enableVirtualTerminalProcessing))
+		return r != 0
+	default:
+		return false
+	}
+}
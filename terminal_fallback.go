@@ -0,0 +1,9 @@
+// +build appengine gopherjs js
+
+package logrus
+
+// GetCurrentThreadId returns 0 on the platforms where no OS thread id is
+// available, such as App Engine, GopherJS and WebAssembly.
+func GetCurrentThreadId() int {
+	return 0
+}
@@ -9,12 +9,12 @@ package logrus
 
 import "golang.org/x/sys/unix"
 
-import "syscall"
-
 const ioctlReadTermios = unix.TCGETS
 
 type Termios unix.Termios
 
+// GetCurrentThreadId returns the kernel ID of the thread running the calling
+// goroutine.
 func GetCurrentThreadId() int {
-	return syscall.Gettid()
+	return unix.Gettid()
 }
@@ -0,0 +1,5 @@
+package logrus
+
+// TextFormatter asks for the thread id of every entry, so each supported
+// platform must provide GetCurrentThreadId. This fails to compile otherwise.
+var _ func() int = GetCurrentThreadId
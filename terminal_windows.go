@@ -3,85 +3,45 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// +build windows,!appengine
+// +build windows,!appengine,!gopherjs
 
 package logrus
 
 import (
-	"bytes"
-	"errors"
 	"io"
 	"os"
-	"os/exec"
-	"strconv"
-	"strings"
 	"syscall"
 	"unsafe"
-	"golang.org/x/sys/windows"
 )
 
 var kernel32 = syscall.NewLazyDLL("kernel32.dll")
 
 var (
-	procGetConsoleMode = kernel32.NewProc("GetConsoleMode")
-	procSetConsoleMode = kernel32.NewProc("SetConsoleMode")
+	procGetConsoleMode     = kernel32.NewProc("GetConsoleMode")
+	procSetConsoleMode     = kernel32.NewProc("SetConsoleMode")
+	procGetCurrentThreadId = kernel32.NewProc("GetCurrentThreadId")
 )
 
-const (
-	enableProcessedOutput           = 0x0001
-	enableWrapAtEolOutput           = 0x0002
-	enableVirtualTerminalProcessing = 0x0004
-)
-
-func getVersion() (float64, error) {
-	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
-	cmd := exec.Command("cmd", "ver")
-	cmd.Stdout = stdout
-	cmd.Stderr = stderr
-	err := cmd.Run()
-	if err != nil {
-		return -1, err
-	}
-	
-	// The output should be like "Microsoft Windows [Version XX.X.XXXXXX]"
-	version := strings.Replace(stdout.String(), "\n", "", -1)
-	version = strings.Replace(version, "\r\n", "", -1)
-
-	x1 := strings.Index(version, "[Version")
-
-	if x1 == -1 || strings.Index(version, "]") == -1 {
-		return -1, errors.New("Can't determine Windows version")
-	}
-
-	return strconv.ParseFloat(version[x1+9:x1+13], 64)
-}
-
-func init() {
-	ver, err := getVersion()
-	if err != nil {
-		return
-	}
-
-	// Activate Virtual Processing for Windows CMD
-	// Info: https://msdn.microsoft.com/en-us/library/windows/desktop/ms686033(v=vs.85).aspx
-	if ver >= 10 {
-		handle := syscall.Handle(os.Stderr.Fd())
-		procSetConsoleMode.Call(uintptr(handle), enableProcessedOutput|enableWrapAtEolOutput|enableVirtualTerminalProcessing)
-	}
-}
+// Virtual terminal processing makes the console interpret the ANSI escape
+// sequences of the colors, it is enabled by checkIfTerminal on the consoles
+// supporting it (Windows 10 and later).
+const enableVirtualTerminalProcessing = 0x0004
 
-// IsTerminal returns true if stderr's file descriptor is a terminal.
+// IsTerminal returns true if f is a console.
 func IsTerminal(f io.Writer) bool {
 	switch v := f.(type) {
 	case *os.File:
-		var st uint32
-		r, _, e := syscall.Syscall(procGetConsoleMode.Addr(), 2, uintptr(v.Fd()), uintptr(unsafe.Pointer(&st)), 0)
-		return r != 0 && e == 0
+		var mode uint32
+		r, _, _ := procGetConsoleMode.Call(v.Fd(), uintptr(unsafe.Pointer(&mode)))
+		return r != 0
 	default:
 		return false
 	}
 }
 
+// GetCurrentThreadId returns the id of the OS thread running the calling
+// goroutine.
 func GetCurrentThreadId() int {
-	return int(windows.GetCurrentThreadId())
+	id, _, _ := procGetCurrentThreadId.Call()
+	return int(id)
 }
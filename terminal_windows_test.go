@@ -0,0 +1,25 @@
+// +build windows,!appengine
+
+package logrus
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCurrentThreadId(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	id := GetCurrentThreadId()
+	assert.NotEqual(t, 0, id)
+	assert.Equal(t, id, GetCurrentThreadId())
+}
+
+func TestCheckIfTerminal(t *testing.T) {
+	assert.Equal(t, false, checkIfTerminal(&bytes.Buffer{}))
+	assert.Equal(t, false, IsTerminal(&bytes.Buffer{}))
+}
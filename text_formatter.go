@@ -2,14 +2,20 @@ package logrus
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
-	"runtime"
+	"unicode/utf8"
 	"strconv"
-	"syscall"
 )
 
 const (
@@ -19,15 +25,112 @@ const (
 	yellow  = 33
 	blue    = 36
 	gray    = 37
+
+	darkGray = 90
+)
+
+// MessagePlacement tells where the message is printed relatively to the
+// fields.
+type MessagePlacement int
+
+// Message placements for TextFormatter.MessagePlacement
+const (
+	// MessageDefault prints the message before the fields in colored output
+	// and after them otherwise.
+	MessageDefault MessagePlacement = iota
+	// MessageFirst prints the message before the fields.
+	MessageFirst
+	// MessageLast prints the message after the fields.
+	MessageLast
+)
+
+// LevelCase is the casing of the level text.
+type LevelCase int
+
+// Level casings for TextFormatter.LevelCase
+const (
+	// CaseDefault prints the level in uppercase in colored output and as is
+	// otherwise.
+	CaseDefault LevelCase = iota
+	// CaseUpper prints the level in uppercase, e.g. "INFO".
+	CaseUpper
+	// CaseLower prints the level in lowercase, e.g. "info".
+	CaseLower
+	// CaseAsIs prints the level as returned by Level.String.
+	CaseAsIs
+)
+
+// SourcePathStyle tells how the paths of source files are printed.
+type SourcePathStyle int
+
+// Source path styles for TextFormatter.SourcePath
+const (
+	// SourcePathDefault prints the base name of the file of the source_file
+	// field in plain output, and full paths otherwise.
+	SourcePathDefault SourcePathStyle = iota
+	// SourcePathFull prints full paths.
+	SourcePathFull
+	// SourcePathBase prints the base name of the files, e.g. "conn.go:42".
+	SourcePathBase
+	// SourcePathTrimmed prints the paths relative to TrimPathPrefix, or
+	// else the directory of the file followed by its name, e.g.
+	// "db/conn.go:42".
+	SourcePathTrimmed
+)
+
+// FieldOrderRest stands for the fields not listed in TextFormatter.FieldOrder.
+const FieldOrderRest = "..."
+
+// Precision is the number of fractional second digits of a timestamp.
+type Precision int
+
+// Timestamp precisions for TextFormatter.TimestampPrecision
+const (
+	PrecisionSeconds Precision = 0
+	PrecisionMillis  Precision = 3
+	PrecisionNanos   Precision = 9
 )
 
 var (
 	baseTimestamp time.Time
 	emptyFieldMap FieldMap
+
+	hostname     string
+	hostnameOnce sync.Once
+
+	// Aliases of the thread IDs, with AliasThreadIDs, and the ring of the
+	// IDs aliased, the oldest being forgotten past maxThreadAliases.
+	threadAliases   sync.Map
+	threadAliasMu   sync.Mutex
+	lastThreadAlias int
+	aliasedThreads  []int
+
+	// Indirection over the hostname lookup, so that tests can stub it. The
+	// process ID, thread ID and OS are stubbed with SetRuntimeInfo.
+	lookupHostname = os.Hostname
+
+	// Names of the runtime fields printed without colors, in their default
+	// order, see TextFormatter.FieldOrder.
+	runtimeFieldNames = []string{"process ID", "thread ID", "OS"}
+
+	// Keys of the fields printed with brackets by default, and printed as
+	// key=value pairs with PlainDecorations.
+	undecoratedKeys = map[string]string{
+		"level":       "level",
+		"process ID":  "process_id",
+		"thread ID":   "thread_id",
+		"pid/tid":     "pid_tid",
+		"OS":          "os",
+		"source_file": "source_file",
+	}
+
+	sgrPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+	urlPattern = regexp.MustCompile(`https?://[^\s<>"'\x1b]*[^\s<>"'\x1b.,;:!?)\]]`)
 )
 
 func init() {
-	baseTimestamp = time.Now()
+	baseTimestamp = now()
 }
 
 // TextFormatter formats logs into text
@@ -38,6 +141,16 @@ type TextFormatter struct {
 	// Force disabling colors.
 	DisableColors bool
 
+	// LinePrefix is written at the very beginning of every line, before the
+	// timestamp and the level, e.g. "[billing] " to tag the subsystem of
+	// the entries. The lines following the first one of an entry, such as
+	// the ones of multi-line messages and stack traces, are prefixed too.
+	LinePrefix string
+
+	// ColorLinePrefix colors the LinePrefix with the level color in colored
+	// output.
+	ColorLinePrefix bool
+
 	// Disable timestamp logging. useful when output is redirected to logging
 	// system that already adds timestamps.
 	DisableTimestamp bool
@@ -46,22 +159,277 @@ type TextFormatter struct {
 	// the time passed since beginning of execution.
 	FullTimestamp bool
 
+	// RelativeTimestamp prints the seconds elapsed since the beginning of
+	// execution, e.g. "[0042]", instead of the date when the output is not
+	// colored, like the colored output does without FullTimestamp.
+	RelativeTimestamp bool
+
 	// TimestampFormat to use for display when a full timestamp is printed
 	TimestampFormat string
 
+	// TimeLocation, if set, is the time zone the timestamp is printed in,
+	// e.g. the local zone of the reader of logs stored in UTC. The time of
+	// the entry itself is left unchanged.
+	TimeLocation *time.Location
+
 	// The fields are sorted by default for a consistent output. For applications
 	// that log extremely frequently and don't use the JSON formatter this may not
 	// be desired.
 	DisableSorting bool
 
+	// LevelCase sets the casing of the level text, for both colored and
+	// plain output.
+	LevelCase LevelCase
+
 	// Disables the truncation of the level text to 4 characters.
 	DisableLevelTruncation bool
 
+	// MessagePlacement tells whether the message is printed before or after
+	// the fields. By default colored output prints it first and plain output
+	// last, set MessageFirst or MessageLast for both to agree.
+	MessagePlacement MessagePlacement
+
+	// ColorValues colors the values of the fields with the level color as
+	// well, not only their keys, so that errors stand out in colored output.
+	ColorValues bool
+
+	// FieldColors maps the keys of fields to the SGR color code used for
+	// them in colored output instead of the level color, e.g. 31 for red,
+	// so that some fields are always highlighted. Their values are colored
+	// as well with ColorValues.
+	FieldColors map[string]int
+
+	// ColorScheme sets the colors of the levels, of the keys of the fields
+	// and of the timestamp in colored output. Its Fields take precedence
+	// over FieldColors. Defaults to the level colors for everything but the
+	// timestamp.
+	ColorScheme *ColorScheme
+
+	// ColorFullLine colors the whole line of the entries with the level
+	// color in colored output, not only the level text. Fields with their
+	// own color keep it, on the background of the level color if it has one.
+	ColorFullLine bool
+
+	// HighlightFatal prints the level text of fatal and panic entries in
+	// bold white on a red background in colored output, or their whole line
+	// with ColorFullLine, so that they stand out in long sessions. Colors set
+	// for these levels by the ColorScheme take precedence.
+	HighlightFatal bool
+
+	// OmitEmptyMessage leaves out the message column of colored output for
+	// entries without message, as plain output always does, rather than
+	// printing a blank padded message.
+	OmitEmptyMessage bool
+
+	// MessagePadding pads the message with spaces to the given width so that
+	// the fields following it are aligned, longer messages are left as is.
+	// Defaults to no padding without colors and to 44 with colors.
+	MessagePadding int
+
+	// DisableMessagePadding leaves the message unpadded, overriding
+	// MessagePadding and the default padding of colored output.
+	DisableMessagePadding bool
+
+	// LevelSeparator is written between the level and the timestamp or the
+	// message in colored output, e.g. " " or ": ". Defaults to nothing.
+	LevelSeparator string
+
 	// QuoteEmptyFields will wrap empty fields in quotes if true
 	QuoteEmptyFields bool
 
-	// Whether the logger's out is to a terminal
-	isTerminal bool
+	// NilValueText is printed as the value of the fields which are nil,
+	// including nil pointers, chans and funcs. Defaults to "<nil>".
+	NilValueText string
+
+	// OmitNilFields leaves out the fields whose value is nil, as defined for
+	// NilValueText.
+	OmitNilFields bool
+
+	// JSONEncodeComplexValues prints the values of the fields which are
+	// slices, arrays, maps or structs, or pointers to them, as compact JSON,
+	// quoted as a whole,
+	// instead of with Go's syntax. Values failing to marshal are printed as
+	// usual.
+	JSONEncodeComplexValues bool
+
+	// ValueFormatters renders the values of the fields of some types, e.g.
+	// durations, see StandardValueFormatters.
+	ValueFormatters ValueFormatters
+
+	// IndentComplexValues prints the fields whose values are slices, arrays,
+	// maps or structs, or pointers to them, below the entry, as indented JSON blocks introduced by
+	// their key, so that nested payloads stay readable. The entry itself
+	// stays on a single line. Values whose JSON fits on a line, or failing
+	// to marshal, are printed as usual.
+	IndentComplexValues bool
+
+	// StripValueColors removes the ANSI color escape sequences embedded in
+	// the string values of the fields, e.g. in the output of another tool,
+	// so that they don't corrupt plain log files.
+	StripValueColors bool
+
+	// QuoteWhitespace quotes the values made of whitespace only, which
+	// otherwise can't be told apart from the separators when printed
+	// without colors, even if QuoteEmptyFields isn't set.
+	QuoteWhitespace bool
+
+	// FieldSeparator is written between fields, defaults to a space.
+	FieldSeparator string
+
+	// QuoteCharacter is the character used to quote values, defaults to a
+	// double quote. Occurrences of it in values are escaped with a backslash.
+	QuoteCharacter string
+
+	// PreserveTimezone keeps the zone designator ("Z" or "+05:30") after the
+	// clock when the timestamp is printed without colors. By default it is
+	// truncated to HH:MM:SS.
+	PreserveTimezone bool
+
+	// TimestampPrecision sets how many fractional second digits are kept
+	// when the timestamp is printed without colors, defaults to
+	// PrecisionSeconds. The fraction is taken from TimestampFormat, which
+	// defaults to time.RFC3339Nano when a precision is set.
+	TimestampPrecision Precision
+
+	// ReportHostname adds the machine's hostname to every entry, which helps
+	// telling apart logs aggregated from several nodes. The key can be
+	// changed with FieldKeyHostname in FieldMap.
+	ReportHostname bool
+
+	// ReportLevelNumber adds the numeric severity of the level next to the
+	// level text, under the FieldKeyLevelNum key.
+	ReportLevelNumber bool
+
+	// LevelNumbers customizes the severities reported with ReportLevelNumber,
+	// e.g. SyslogLevelNumbers. Defaults to the numeric value of the Level.
+	LevelNumbers LevelNumbers
+
+	// ReportFieldCount adds the number of fields of the entry, not counting
+	// the default ones, under the FieldKeyFieldCount key. This helps spotting
+	// accidental field explosions.
+	ReportFieldCount bool
+
+	// ReportEntryID adds a random ID of 16 hexadecimal characters, unique to
+	// each entry, under the FieldKeyEntryID key. It allows correlating a log
+	// line across systems which reorder the fields. An entry written to
+	// several outputs has the same ID in all of them.
+	ReportEntryID bool
+
+	// ProcessIDOverride and ThreadIDOverride, if set, are printed instead of
+	// the actual process and thread IDs, e.g. for reproducible test fixtures.
+	ProcessIDOverride *int
+	ThreadIDOverride  *int
+
+	// AliasThreadIDs prints small sequential aliases instead of the raw
+	// thread IDs, the first thread seen being 1. This keeps the large,
+	// ever-increasing goroutine IDs used on some platforms readable. Only
+	// the last 4096 threads seen keep their alias, a thread seen again
+	// after being forgotten gets a new one.
+	AliasThreadIDs bool
+
+	// DisableProcessID and DisableThreadID leave the process ID and the
+	// thread ID out of the output.
+	DisableProcessID bool
+	DisableThreadID  bool
+
+	// CombinePIDTID prints the process ID and the thread ID as a single
+	// "[pid/tid]" token, e.g. "[123/45]", which is also added to colored
+	// output. It is ignored if either ID is disabled.
+	CombinePIDTID bool
+
+	// DisableRuntimeFields leaves the process ID, the thread ID and the OS
+	// out of the output.
+	DisableRuntimeFields bool
+
+	// FieldOrder sets the sequence of what follows the level in the output
+	// printed without colors. It lists the runtime fields, named "process
+	// ID", "thread ID" and "OS", the message key and the keys of fields. The
+	// runtime fields it doesn't list are left out, the other fields are
+	// printed where FieldOrderRest is listed, or after the listed ones, and
+	// the message comes last unless listed. For instance, the message first
+	// and the runtime metadata last:
+	//
+	//    FieldOrder: []string{"msg", logrus.FieldOrderRest, "process ID", "thread ID", "OS"}
+	FieldOrder []string
+
+	// PlainDecorations prints the level, process ID, thread ID, OS and source
+	// file as key=value pairs, e.g. "process_id=123", instead of decorating
+	// them with brackets, e.g. "[pid 123]", when printed without colors.
+	PlainDecorations bool
+
+	// CompactHeader replaces the level, process ID, thread ID and OS tokens
+	// printed without colors by a single one such as "I/1234.5678.L": the
+	// first letter of the level followed by the IDs and the OS letter.
+	CompactHeader bool
+
+	// CompactHeaderDelimiter is written between the parts of the compact
+	// header following the level letter, defaults to ".".
+	CompactHeaderDelimiter string
+
+	// OSOncePerProcess leaves the OS out of the entries printed without
+	// colors. Instead, it is printed once by each formatter, in an info
+	// entry preceding the first one it formats.
+	OSOncePerProcess bool
+
+	// PrintStackTrace prints the stack trace of the errors among the fields
+	// below the entry, indented, when they carry one. Errors are recognized
+	// as carrying a stack trace when they implement fmt.Formatter, printing
+	// it with the "%+v" verb as github.com/pkg/errors does.
+	PrintStackTrace bool
+
+	// SandboxSafe restricts formatting to plain Go code for sandboxes where
+	// system calls may be blocked, e.g. by seccomp: the process ID, thread ID
+	// and hostname are left out and colors are disabled since detecting a
+	// terminal needs an ioctl.
+	SandboxSafe bool
+
+	// RedactKeys lists the keys of the fields whose value must never be
+	// printed, e.g. "password" or "authorization". Keys are matched case
+	// insensitively and their value is replaced by "***".
+	RedactKeys []string
+
+	// RedactFunc, if set, returns the value printed in place of the value of
+	// a field listed in RedactKeys, e.g. a hash of it.
+	RedactFunc func(key string, value interface{}) interface{}
+
+	// RedactMessagePattern, if set, replaces its matches in the message by
+	// "***".
+	RedactMessagePattern *regexp.Regexp
+
+	// CallerPrettyfier returns the function and the file, with its line, of
+	// the caller of the entries printed when the logger reports the caller,
+	// e.g. to print short names. An empty function or file is left out. By
+	// default they are printed as is, the file with the SourcePath style.
+	CallerPrettyfier func(frame *runtime.Frame) (function string, file string)
+
+	// SourcePath sets how the paths of the source_file field, set by the
+	// sourcefile hook, and of the file of the caller are printed.
+	SourcePath SourcePathStyle
+
+	// TrimPathPrefix is the prefix removed from the paths with
+	// SourcePathTrimmed, e.g. the root of the module.
+	TrimPathPrefix string
+
+	// LinkifyURLs wraps http(s) URLs found in the message in OSC 8 hyperlink
+	// escape sequences, making them clickable in terminals supporting them.
+	// Only applies to colored output.
+	LinkifyURLs bool
+
+	// TerminalChecker reports whether a writer is a terminal, deciding if the
+	// output is colored unless ForceColors or DisableColors is set. It allows
+	// testing colored output or handling writers wrapping a terminal.
+	// Defaults to detecting *os.File terminals, so that the output is never
+	// colored when written to an io.MultiWriter even if one of its writers
+	// is a terminal, see NewTeeLogger. Its result is cached per writer.
+	TerminalChecker func(io.Writer) bool
+
+	// Whether each of the outputs this formatter has written to is a
+	// terminal, keyed by writer.
+	terminals  map[io.Writer]bool
+	terminalMu sync.Mutex
+
+	// Set once the OS has been printed, with OSOncePerProcess.
+	osReported uint32
 
 	// FieldMap allows users to customize the names of keys for default fields.
 	// As an example:
@@ -71,22 +439,89 @@ type TextFormatter struct {
 	//         FieldKeyLevel: "@level",
 	//         FieldKeyMsg:   "@message"}}
 	FieldMap FieldMap
+}
 
-	sync.Once
+func (f *TextFormatter) fieldMap() FieldMap {
+	return f.FieldMap
 }
 
-func (f *TextFormatter) init(entry *Entry) {
-	if entry.Logger != nil {
-		f.isTerminal = checkIfTerminal(entry.Logger.Out)
+func (f *TextFormatter) reportsEntryID() bool {
+	return f.ReportEntryID
+}
+
+// isTerminal reports whether w is a terminal. The result is cached per writer
+// so that a formatter shared by loggers with different outputs makes an
+// independent decision for each of them.
+func (f *TextFormatter) isTerminal(w io.Writer) bool {
+	if w == nil {
+		return false
+	}
+	check := f.TerminalChecker
+	if check == nil {
+		check = checkIfTerminal
 	}
+	if !reflect.TypeOf(w).Comparable() {
+		return check(w)
+	}
+
+	f.terminalMu.Lock()
+	defer f.terminalMu.Unlock()
+	if isTerminal, ok := f.terminals[w]; ok {
+		return isTerminal
+	}
+	if f.terminals == nil {
+		f.terminals = make(map[io.Writer]bool)
+	}
+	isTerminal := check(w)
+	f.terminals[w] = isTerminal
+	return isTerminal
 }
 
 // Format renders a single log entry
 func (f *TextFormatter) Format(entry *Entry) ([]byte, error) {
-	prefixFieldClashes(entry.Data, f.FieldMap)
+	extra := nestedErrorFields(entry.Data)
+	if m, ok := entry.Data[MetricKey].(Metric); ok {
+		if extra == nil {
+			extra = make(Fields)
+		}
+		for k, v := range m.fields(entry.Data) {
+			extra[k] = v
+		}
+	}
+	// The data of the entry may be shared with other goroutines, it is
+	// copied rather than modified in place.
+	if len(extra) > 0 || hasFieldClashes(entry.Data, f.FieldMap, entry.HasCaller()) {
+		data := make(Fields, len(entry.Data)+len(extra))
+		MergeFields(data, entry.Data)
+		MergeFields(data, extra)
+		prefixFieldClashes(data, f.FieldMap, entry.HasCaller())
+		expanded := *entry
+		expanded.Data = data
+		entry = &expanded
+	}
+	if len(f.ValueFormatters) > 0 {
+		formatted := *entry
+		formatted.Data = f.ValueFormatters.formatValues(entry.Data)
+		entry = &formatted
+	}
+	if f.TimeLocation != nil {
+		located := *entry
+		located.Time = entry.Time.In(f.TimeLocation)
+		entry = &located
+	}
 
+	// The stack trace added by WithError is printed below the entry, so
+	// that the entry stays on a single line
+	var stack string
 	keys := make([]string, 0, len(entry.Data))
-	for k := range entry.Data {
+	for k, v := range entry.Data {
+		if f.OmitNilFields && isNil(v) {
+			continue
+		}
+		if s, ok := v.(string); ok && isErrorStackKey(k) {
+			stack = s
+			continue
+		}
 		keys = append(keys, k)
 	}
 
@@ -94,6 +529,11 @@ func (f *TextFormatter) Format(entry *Entry) ([]byte, error) {
 		sort.Strings(keys)
 	}
 
+	var blocks []string
+	if f.IndentComplexValues {
+		keys, blocks = f.indentComplexValues(entry.Data, keys)
+	}
+
 	var b *bytes.Buffer
 	if entry.Buffer != nil {
 		b = entry.Buffer
@@ -101,45 +541,293 @@ func (f *TextFormatter) Format(entry *Entry) ([]byte, error) {
 		b = &bytes.Buffer{}
 	}
 
-	f.Do(func() { f.init(entry) })
+	var out io.Writer
+	if entry.Logger != nil {
+		out = entry.Logger.levelOutput(entry.Level)
+	}
 
-	isColored := (f.ForceColors || f.isTerminal) && !f.DisableColors
+	isColored := false
+	if !f.DisableColors && !f.SandboxSafe {
+		isColored = f.ForceColors || f.isTerminal(out)
+	}
 
 	timestampFormat := f.TimestampFormat
 	if timestampFormat == "" {
 		timestampFormat = defaultTimestampFormat
 	}
+	// Beginning of the lines of the entry, after the startup entry
+	start := b.Len()
 	if isColored {
 		f.printColored(b, entry, keys, timestampFormat)
 	} else {
-		if !f.DisableTimestamp {
-			f.appendKeyValue(b, "time", entry.Time.Format(timestampFormat))
-		}
-		f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyLevel), entry.Level.String())
-		f.appendKeyValue(b, "process ID", strconv.Itoa(syscall.Getpid()))
-		f.appendKeyValue(b, "thread ID", strconv.Itoa(GetCurrentThreadId()))
-		f.appendKeyValue(b, "OS", detectOS())
-		
-		for _, key := range keys {
-			if key == "source_file" {
-				n := strings.LastIndexByte(entry.Data[key].(string), '/')
-				f.appendKeyValue(b, key, entry.Data[key].(string)[n+1:])
-			} else {
-				f.appendKeyValue(b, key, entry.Data[key])
+		if f.OSOncePerProcess && atomic.CompareAndSwapUint32(&f.osReported, 0, 1) {
+			startup, err := f.Format(&Entry{
+				Logger:  entry.Logger,
+				Time:    entry.Time,
+				Level:   InfoLevel,
+				Message: "running on " + getOS(),
+				Data:    Fields{"OS": detectOS()},
+			})
+			if err != nil {
+				return nil, err
 			}
+			b.Write(startup)
+			start = b.Len()
 		}
-		
-		if entry.Message != "" {
-			f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyMsg), entry.Message)
+		b.WriteString(f.LinePrefix)
+		if !f.DisableTimestamp && f.RelativeTimestamp {
+			fmt.Fprintf(b, "[%04d]", elapsedSeconds(entry.Time))
+			b.WriteString(f.fieldSeparator())
+		} else if !f.DisableTimestamp {
+			plainTimestampFormat := timestampFormat
+			if f.TimestampFormat == "" && f.TimestampPrecision > PrecisionSeconds {
+				plainTimestampFormat = time.RFC3339Nano
+			}
+			var scratch [64]byte
+			f.appendString(b, "time", string(entry.Time.AppendFormat(scratch[:0], plainTimestampFormat)))
+		}
+		if f.CompactHeader {
+			b.WriteString(f.compactHeader(entry.Level))
+			b.WriteString(f.fieldSeparator())
+		} else {
+			f.appendString(b, f.FieldMap.resolve(FieldKeyLevel), f.levelText(entry.Level, CaseAsIs))
+		}
+		if f.ReportLevelNumber {
+			f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyLevelNum), f.LevelNumbers.number(entry.Level))
+		}
+		if f.FieldOrder == nil {
+			for _, name := range runtimeFieldNames {
+				f.appendRuntimeField(b, name)
+			}
+		}
+		if f.ReportHostname && !f.SandboxSafe {
+			f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyHostname), getHostname())
+		}
+		if f.ReportFieldCount {
+			f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyFieldCount), len(entry.Data))
+		}
+		if f.ReportEntryID {
+			f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyEntryID), entry.entryID())
+		}
+		if entry.HasCaller() {
+			function, file := f.caller(entry.Caller)
+			if function != "" {
+				f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyFunc), function)
+			}
+			if file != "" {
+				f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyFile), file)
+			}
+		}
+
+		if f.FieldOrder != nil {
+			f.appendOrderedFields(b, entry, keys)
+		} else {
+			if entry.Message != "" && f.MessagePlacement == MessageFirst {
+				f.appendString(b, f.FieldMap.resolve(FieldKeyMsg), f.redactMessage(entry.Message))
+			}
+			for _, key := range keys {
+				f.appendField(b, key, entry.Data[key])
+			}
+			if entry.Message != "" && f.MessagePlacement != MessageFirst {
+				f.appendString(b, f.FieldMap.resolve(FieldKeyMsg), f.redactMessage(entry.Message))
+			}
 		}
 	}
 
 	b.WriteByte('\n')
+	appendIndented(b, stack)
+	for _, block := range blocks {
+		appendIndented(b, block)
+	}
+	if f.PrintStackTrace {
+		for _, key := range keys {
+			if key == ErrorKey && stack != "" {
+				continue
+			}
+			if err, ok := entry.Data[key].(error); ok {
+				appendIndented(b, stackTrace(err))
+			}
+		}
+	}
+	if f.LinePrefix != "" {
+		prefix := f.LinePrefix
+		if isColored && f.ColorLinePrefix {
+			var colored bytes.Buffer
+			appendColored(&colored, f.levelColor(entry.Level), prefix)
+			prefix = colored.String()
+		}
+		prefixContinuationLines(b, start, prefix)
+	}
 	return b.Bytes(), nil
 }
 
+// prefixContinuationLines writes prefix at the beginning of the lines
+// following the first one among the lines written to b from start, such as
+// the lines of multi-line messages and of stack traces.
+func prefixContinuationLines(b *bytes.Buffer, start int, prefix string) {
+	text := bytes.TrimSuffix(b.Bytes()[start:], []byte{'\n'})
+	if bytes.IndexByte(text, '\n') < 0 {
+		return
+	}
+	lines := bytes.SplitAfter(append([]byte(nil), b.Bytes()[start:]...), []byte{'\n'})
+	b.Truncate(start)
+	for i, line := range lines {
+		if i > 0 && len(line) > 0 {
+			b.WriteString(prefix)
+		}
+		b.Write(line)
+	}
+}
+
+// appendField writes a field of the entry printed without colors.
+func (f *TextFormatter) appendField(b *bytes.Buffer, key string, value interface{}) {
+	value = f.redact(key, value)
+	if isNil(value) {
+		value = f.nilValueText()
+	} else if key == "source_file" {
+		// The field is set by the sourcefile hook, but may be logged as
+		// anything
+		value = f.sourcePath(fmt.Sprint(value), SourcePathBase)
+	} else if s, ok := value.(string); ok && f.StripValueColors {
+		value = stripColors(s)
+	} else if encoded, ok := f.encodeComplexValue(value); ok {
+		value = f.quote(encoded)
+	}
+	f.appendKeyValue(b, key, value)
+}
+
+// caller returns the function and the file of the caller of an entry, see
+// CallerPrettyfier.
+func (f *TextFormatter) caller(frame *runtime.Frame) (function string, file string) {
+	if f.CallerPrettyfier != nil {
+		return f.CallerPrettyfier(frame)
+	}
+	return frame.Function, f.sourcePath(fmt.Sprintf("%s:%d", frame.File, frame.Line), SourcePathFull)
+}
+
+// sourcePath returns path printed with the SourcePath style, or with style
+// by default.
+func (f *TextFormatter) sourcePath(path string, style SourcePathStyle) string {
+	if f.SourcePath != SourcePathDefault {
+		style = f.SourcePath
+	}
+	switch style {
+	case SourcePathBase:
+		return path[strings.LastIndexByte(path, '/')+1:]
+	case SourcePathTrimmed:
+		if f.TrimPathPrefix != "" && strings.HasPrefix(path, f.TrimPathPrefix) {
+			return strings.TrimPrefix(path[len(f.TrimPathPrefix):], "/")
+		}
+		if n := strings.LastIndexByte(path, '/'); n > 0 {
+			return path[strings.LastIndexByte(path[:n], '/')+1:]
+		}
+	}
+	return path
+}
+
+// appendRuntimeField writes the process ID, the thread ID or the OS, as
+// named in runtimeFieldNames, unless it is disabled. The process ID is
+// written as the combined "[pid/tid]" token with CombinePIDTID.
+func (f *TextFormatter) appendRuntimeField(b *bytes.Buffer, name string) {
+	if f.CompactHeader || f.DisableRuntimeFields {
+		return
+	}
+	// The IDs are converted on the stack, see appendString
+	var digits [20]byte
+	switch name {
+	case "process ID":
+		if f.combinePIDTID() {
+			f.appendKeyValue(b, "pid/tid", f.pidTID())
+		} else if !f.SandboxSafe && !f.DisableProcessID {
+			f.appendString(b, name, string(strconv.AppendInt(digits[:0], int64(f.processID()), 10)))
+		}
+	case "thread ID":
+		if !f.combinePIDTID() && !f.SandboxSafe && !f.DisableThreadID {
+			f.appendString(b, name, string(strconv.AppendInt(digits[:0], int64(f.threadID()), 10)))
+		}
+	case "OS":
+		if !f.OSOncePerProcess {
+			f.appendString(b, name, detectOS())
+		}
+	}
+}
+
+// appendOrderedFields writes the runtime fields, the message and the fields
+// of the entry in the sequence given by FieldOrder.
+func (f *TextFormatter) appendOrderedFields(b *bytes.Buffer, entry *Entry, keys []string) {
+	msgKey := f.FieldMap.resolve(FieldKeyMsg)
+	listed := make(map[string]bool, len(f.FieldOrder))
+	for _, name := range f.FieldOrder {
+		listed[name] = true
+	}
+	printed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		printed[key] = false
+	}
+	appendRest := func() {
+		for _, key := range keys {
+			if !listed[key] {
+				f.appendField(b, key, entry.Data[key])
+			}
+		}
+	}
+
+	for _, name := range f.FieldOrder {
+		switch {
+		case name == FieldOrderRest:
+			appendRest()
+		case name == msgKey:
+			if entry.Message != "" {
+				f.appendKeyValue(b, msgKey, f.redactMessage(entry.Message))
+			}
+		case isRuntimeFieldName(name):
+			f.appendRuntimeField(b, name)
+		default:
+			if done, ok := printed[name]; ok && !done {
+				printed[name] = true
+				f.appendField(b, name, entry.Data[name])
+			}
+		}
+	}
+	if !listed[FieldOrderRest] {
+		appendRest()
+	}
+	if !listed[msgKey] && entry.Message != "" {
+		f.appendKeyValue(b, msgKey, f.redactMessage(entry.Message))
+	}
+}
+
+func isRuntimeFieldName(name string) bool {
+	for _, runtimeName := range runtimeFieldNames {
+		if name == runtimeName {
+			return true
+		}
+	}
+	return false
+}
+
+// appendIndented writes a stack trace or a field below an entry, with each line
+// indented by a tab.
+func appendIndented(b *bytes.Buffer, trace string) {
+	if trace == "" {
+		return
+	}
+	for _, line := range strings.Split(trace, "\n") {
+		b.WriteByte('\t')
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+}
+
+// elapsedSeconds returns the number of seconds between the beginning of
+// execution and t.
+func elapsedSeconds(t time.Time) int {
+	return int(t.Sub(baseTimestamp) / time.Second)
+}
+
+// detectOS returns the letter of the OS printed by the TextFormatter.
 func detectOS() string {
-	switch osplatform := runtime.GOOS; osplatform {
+	switch getOS() {
 	case "windows":
 		return "W"
 	case "darwin":
@@ -150,36 +838,300 @@ func detectOS() string {
 
 }
 
-func (f *TextFormatter) printColored(b *bytes.Buffer, entry *Entry, keys []string, timestampFormat string) {
-	var levelColor int
-	switch entry.Level {
-	case DebugLevel:
-		levelColor = gray
-	case WarnLevel:
-		levelColor = yellow
-	case ErrorLevel, FatalLevel, PanicLevel:
-		levelColor = red
+// levelText returns the text of level cased as set by LevelCase, using
+// defaultCase for CaseDefault.
+func (f *TextFormatter) levelText(level Level, defaultCase LevelCase) string {
+	levelCase := f.LevelCase
+	if levelCase == CaseDefault {
+		levelCase = defaultCase
+	}
+	switch levelCase {
+	case CaseUpper:
+		return strings.ToUpper(level.String())
+	case CaseLower:
+		return strings.ToLower(level.String())
 	default:
-		levelColor = blue
+		return level.String()
+	}
+}
+
+// compactHeader returns the single token standing for the level, process ID,
+// thread ID and OS when CompactHeader is set, e.g. "I/1234.5678.L".
+func (f *TextFormatter) compactHeader(level Level) string {
+	delimiter := f.CompactHeaderDelimiter
+	if delimiter == "" {
+		delimiter = "."
+	}
+	letter := strings.ToUpper(level.String()[:1])
+	if f.DisableRuntimeFields {
+		return letter
+	}
+	parts := make([]string, 0, 3)
+	if !f.SandboxSafe && !f.DisableProcessID {
+		parts = append(parts, strconv.Itoa(f.processID()))
+	}
+	if !f.SandboxSafe && !f.DisableThreadID {
+		parts = append(parts, strconv.Itoa(f.threadID()))
+	}
+	parts = append(parts, detectOS())
+	return letter + "/" + strings.Join(parts, delimiter)
+}
+
+// combinePIDTID reports whether the process and thread IDs are printed as a
+// single token.
+func (f *TextFormatter) combinePIDTID() bool {
+	return f.CombinePIDTID && !f.SandboxSafe && !f.DisableProcessID && !f.DisableThreadID && !f.DisableRuntimeFields
+}
+
+// pidTID returns the token printed with CombinePIDTID, e.g. "123/45".
+func (f *TextFormatter) pidTID() string {
+	return strconv.Itoa(f.processID()) + "/" + strconv.Itoa(f.threadID())
+}
+
+func (f *TextFormatter) processID() int {
+	if f.ProcessIDOverride != nil {
+		return *f.ProcessIDOverride
+	}
+	return getpid()
+}
+
+func (f *TextFormatter) threadID() int {
+	if f.ThreadIDOverride != nil {
+		return *f.ThreadIDOverride
+	}
+	if f.AliasThreadIDs {
+		return threadAlias(getCurrentThreadID())
+	}
+	return getCurrentThreadID()
+}
+
+// maxThreadAliases is the number of thread IDs whose alias is remembered, so
+// that the aliases of the goroutines that are gone don't pile up.
+const maxThreadAliases = 4096
+
+// threadAlias returns the alias of the thread ID id, assigning the next one
+// if the ID hasn't been seen yet.
+func threadAlias(id int) int {
+	if alias, ok := threadAliases.Load(id); ok {
+		return alias.(int)
+	}
+	threadAliasMu.Lock()
+	defer threadAliasMu.Unlock()
+	if alias, ok := threadAliases.Load(id); ok {
+		return alias.(int)
+	}
+	// The aliases being sequential, the ID of alias n is kept in the ring at
+	// (n-1) % maxThreadAliases, replacing the oldest.
+	if i := lastThreadAlias % maxThreadAliases; i < len(aliasedThreads) {
+		threadAliases.Delete(aliasedThreads[i])
+		aliasedThreads[i] = id
+	} else {
+		aliasedThreads = append(aliasedThreads, id)
+	}
+	lastThreadAlias++
+	threadAliases.Store(id, lastThreadAlias)
+	return lastThreadAlias
+}
+
+// redact returns the value to print for the field key, which is masked if the
+// key is listed in RedactKeys.
+func (f *TextFormatter) redact(key string, value interface{}) interface{} {
+	for _, k := range f.RedactKeys {
+		if strings.EqualFold(k, key) {
+			if f.RedactFunc != nil {
+				return f.RedactFunc(key, value)
+			}
+			return "***"
+		}
+	}
+	return value
+}
+
+func (f *TextFormatter) redactMessage(message string) string {
+	if f.RedactMessagePattern == nil {
+		return message
+	}
+	return f.RedactMessagePattern.ReplaceAllString(message, "***")
+}
+
+// getHostname returns the hostname of the machine, looked up on first use
+// only. It is "unknown" when the hostname can't be determined.
+func getHostname() string {
+	hostnameOnce.Do(func() {
+		name, err := lookupHostname()
+		if err != nil || name == "" {
+			name = "unknown"
+		}
+		hostname = name
+	})
+	return hostname
+}
+
+func (f *TextFormatter) printColored(b *bytes.Buffer, entry *Entry, keys []string, timestampFormat string) {
+	levelColor := f.levelColor(entry.Level)
+	if f.ColorFullLine && levelColor != NoColor {
+		start := b.Len()
+		defer colorLine(b, start, levelColor)
 	}
 
-	levelText := strings.ToUpper(entry.Level.String())
+	if f.LinePrefix != "" && f.ColorLinePrefix {
+		appendColored(b, levelColor, f.LinePrefix)
+	} else {
+		b.WriteString(f.LinePrefix)
+	}
+
+	levelText := f.levelText(entry.Level, CaseUpper)
 	if !f.DisableLevelTruncation {
 		levelText = levelText[0:4]
 	}
 
-	if f.DisableTimestamp {
-		fmt.Fprintf(b, "\x1b[%dm%s\x1b[0m %-44s ", levelColor, levelText, entry.Message)
-	} else if !f.FullTimestamp {
-		fmt.Fprintf(b, "\x1b[%dm%s\x1b[0m[%04d] %-44s ", levelColor, levelText, int(entry.Time.Sub(baseTimestamp)/time.Second), entry.Message)
-	} else {
-		fmt.Fprintf(b, "\x1b[%dm%s\x1b[0m[%s] %-44s ", levelColor, levelText, entry.Time.Format(timestampFormat), entry.Message)
+	printMessage := !f.OmitEmptyMessage || entry.Message != ""
+	message := f.redactMessage(entry.Message)
+	if f.MessagePlacement != MessageLast && !f.DisableMessagePadding {
+		padding := f.MessagePadding
+		if padding == 0 {
+			padding = 44
+		}
+		message = fmt.Sprintf("%-*s", padding, message)
+	}
+	if f.LinkifyURLs {
+		message = linkifyURLs(message)
+	}
+
+	appendColored(b, levelColor, levelText)
+	b.WriteString(f.LevelSeparator)
+	if !f.DisableTimestamp {
+		var timestamp string
+		if !f.FullTimestamp {
+			timestamp = fmt.Sprintf("[%04d]", elapsedSeconds(entry.Time))
+		} else {
+			timestamp = "[" + entry.Time.Format(timestampFormat) + "]"
+		}
+		var timestampColor Color
+		if f.ColorScheme != nil {
+			timestampColor = f.ColorScheme.Timestamp
+		}
+		appendColored(b, timestampColor, timestamp)
+	}
+	if f.MessagePlacement != MessageLast && printMessage {
+		fmt.Fprintf(b, " %s ", message)
+	}
+	if f.ReportLevelNumber {
+		f.appendColoredKeyValue(b, levelColor, f.FieldMap.resolve(FieldKeyLevelNum), f.LevelNumbers.number(entry.Level))
+	}
+	if f.combinePIDTID() {
+		f.appendColoredKeyValue(b, levelColor, "pid/tid", f.pidTID())
+	}
+	if f.ReportHostname {
+		f.appendColoredKeyValue(b, levelColor, f.FieldMap.resolve(FieldKeyHostname), getHostname())
+	}
+	if f.ReportFieldCount {
+		f.appendColoredKeyValue(b, levelColor, f.FieldMap.resolve(FieldKeyFieldCount), len(entry.Data))
+	}
+	if f.ReportEntryID {
+		f.appendColoredKeyValue(b, levelColor, f.FieldMap.resolve(FieldKeyEntryID), entry.entryID())
+	}
+	if entry.HasCaller() {
+		function, file := f.caller(entry.Caller)
+		if function != "" {
+			f.appendColoredKeyValue(b, levelColor, f.FieldMap.resolve(FieldKeyFunc), function)
+		}
+		if file != "" {
+			f.appendColoredKeyValue(b, levelColor, f.FieldMap.resolve(FieldKeyFile), file)
+		}
 	}
 	for _, k := range keys {
-		v := entry.Data[k]
-		fmt.Fprintf(b, " \x1b[%dm%s\x1b[0m=", levelColor, k)
-		f.appendValue(b, v)
+		value := f.redact(k, entry.Data[k])
+		if isNil(value) {
+			value = f.nilValueText()
+		} else if k == "source_file" {
+			value = f.sourcePath(fmt.Sprint(value), SourcePathFull)
+		}
+		f.appendColoredKeyValue(b, levelColor, k, value)
+	}
+	if f.MessagePlacement == MessageLast && printMessage {
+		b.WriteString(f.fieldSeparator())
+		b.WriteString(message)
+	}
+}
+
+func (f *TextFormatter) appendColoredKeyValue(b *bytes.Buffer, color Color, key string, value interface{}) {
+	color = f.keyColor(key, color)
+	b.WriteString(f.fieldSeparator())
+	appendColored(b, color, key)
+	b.WriteByte('=')
+	if !f.ColorValues || color == NoColor {
+		f.appendValue(b, value)
+		return
+	}
+	fmt.Fprintf(b, "\x1b[%sm", color)
+	f.appendValue(b, value)
+	b.WriteString("\x1b[0m")
+}
+
+// levelColor returns the color of the entries of level.
+func (f *TextFormatter) levelColor(level Level) Color {
+	if f.HighlightFatal && level <= FatalLevel {
+		if _, ok := f.ColorScheme.customLevelColor(level); !ok {
+			return Colors(ColorBold, ColorWhite, BgRed)
+		}
+	}
+	return f.ColorScheme.levelColor(level)
+}
+
+// colorLine colors what was written to b from start with c, restoring c
+// after every reset of the colors of the parts of the line.
+func colorLine(b *bytes.Buffer, start int, c Color) {
+	line := bytes.Replace(b.Bytes()[start:], []byte("\x1b[0m"), []byte("\x1b[0;"+string(c)+"m"), -1)
+	b.Truncate(start)
+	b.WriteString("\x1b[" + string(c) + "m")
+	b.Write(line)
+	b.WriteString("\x1b[0m")
+}
+
+// keyColor returns the color of the key of a field of an entry whose level
+// color is levelColor.
+func (f *TextFormatter) keyColor(key string, levelColor Color) Color {
+	if f.ColorScheme != nil {
+		if c, ok := f.ColorScheme.Fields[key]; ok && c != "" {
+			return c
+		}
+	}
+	if c, ok := f.FieldColors[key]; ok {
+		return sgrColor(c)
+	}
+	if f.ColorScheme != nil && f.ColorScheme.Key != "" {
+		return f.ColorScheme.Key
+	}
+	return levelColor
+}
+
+// linkifyURLs wraps the URLs of text in OSC 8 hyperlinks. Text already
+// containing hyperlinks is left untouched to avoid nesting them.
+func linkifyURLs(text string) string {
+	if strings.Contains(text, "\x1b]8;") {
+		return text
+	}
+	return urlPattern.ReplaceAllString(text, "\x1b]8;;$0\x1b\\$0\x1b]8;;\x1b\\")
+}
+
+func (f *TextFormatter) fieldSeparator() string {
+	if f.FieldSeparator == "" {
+		return " "
 	}
+	return f.FieldSeparator
+}
+
+// quote quotes text with QuoteCharacter, escaping it the way strconv.Quote
+// escapes double quotes.
+func (f *TextFormatter) quote(text string) string {
+	quoted := strconv.Quote(text)
+	if f.QuoteCharacter == "" || f.QuoteCharacter == `"` {
+		return quoted
+	}
+	inner := strings.Replace(quoted[1:len(quoted)-1], `\"`, `"`, -1)
+	inner = strings.Replace(inner, f.QuoteCharacter, `\`+f.QuoteCharacter, -1)
+	return f.QuoteCharacter + inner + f.QuoteCharacter
 }
 
 func (f *TextFormatter) needsQuoting(text string) bool {
@@ -197,65 +1149,286 @@ func (f *TextFormatter) needsQuoting(text string) bool {
 	return false
 }
 
+// indentComplexValues splits keys between the fields printed on the line of
+// the entry and the indented blocks of the fields printed below it, see
+// IndentComplexValues.
+func (f *TextFormatter) indentComplexValues(data Fields, keys []string) (inline []string, blocks []string) {
+	inline = keys[:0]
+	for _, key := range keys {
+		value := f.redact(key, data[key])
+		if isComplexValue(value) {
+			if encoded, err := json.MarshalIndent(value, "", "  "); err == nil && bytes.IndexByte(encoded, '\n') >= 0 {
+				blocks = append(blocks, key+"="+string(encoded))
+				continue
+			}
+		}
+		inline = append(inline, key)
+	}
+	return inline, blocks
+}
+
+// isComplexValue reports whether value is a slice, an array, a map or a
+// struct, or a pointer to one, errors and pointers implementing fmt.Stringer
+// excepted.
+func isComplexValue(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	if _, ok := value.(error); ok {
+		return false
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		if _, ok := value.(fmt.Stringer); ok {
+			return false
+		}
+	}
+	switch reflect.Indirect(v).Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		return true
+	}
+	return false
+}
+
+// encodeComplexValue returns the JSON encoding of value if it is a slice, an
+// array, a map or a struct and JSONEncodeComplexValues is set.
+func (f *TextFormatter) encodeComplexValue(value interface{}) (string, bool) {
+	if !f.JSONEncodeComplexValues || !isComplexValue(value) {
+		return "", false
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	return string(encoded), true
+}
+
+// isNil reports whether value is nil, or a nil pointer, chan or func which
+// fmt would print as "<nil>" as well.
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+func (f *TextFormatter) nilValueText() string {
+	if f.NilValueText == "" {
+		return "<nil>"
+	}
+	return f.NilValueText
+}
+
+// stripColors removes the ANSI SGR escape sequences from text.
+func stripColors(text string) string {
+	if !strings.Contains(text, "\x1b[") {
+		return text
+	}
+	return sgrPattern.ReplaceAllString(text, "")
+}
+
+// isWhitespace reports whether text is made of whitespace only, it is false
+// for an empty text.
+func isWhitespace(text string) bool {
+	return text != "" && strings.TrimSpace(text) == ""
+}
+
 func (f *TextFormatter) appendKeyValue(b *bytes.Buffer, key string, value interface{}) {
 	switch value := value.(type) {
 	case string:
-		if key == "time" {
-			arrstr := strings.Split(value, "T")
-			arr := strings.Split(arrstr[0], "-")
-			for i := len(arr) - 1; i >= 0; i-- {
-				if i == 0 {
-					fmt.Fprintf(b, "%s ", arr[i])
-					break
-				}
-				fmt.Fprintf(b, "%s"+"-", arr[i])
-			}
-			time := arrstr[1]
-			fmt.Fprintf(b, "%s", time[:8])
-			break
-		} else if key == "level" {
-			fmt.Fprintf(b, "[%s]", value)
-			break
-		} else if key == "process ID" {
-			fmt.Fprintf(b, "[pid %s]", value)
-			break
-		} else if key == "thread ID" {
-			fmt.Fprintf(b, "[tid %s]", value)
-			break
-		} else if key == "OS" {
-			fmt.Fprintf(b, "[%s]", value)
-			break
-		} else if key == "msg" {
-			fmt.Fprintf(b, "%s", value)
-			break
-		} else if key == "source_file" {
-			fmt.Fprintf(b, "[%s]", strings.Replace(value, ".go", "", -1))
-			break
-		}
-		fmt.Fprintf(b, "%s", value)
+		f.appendString(b, key, value)
+		return
 	case error:
 		errmsg := value.Error()
 		if !f.needsQuoting(errmsg) {
 			b.WriteString(errmsg)
 		} else {
-			fmt.Fprintf(b, "%q", errmsg)
+			b.WriteString(f.quote(errmsg))
 		}
 	default:
-		fmt.Fprint(b, value)
+		if !appendNumber(b, value) {
+			fmt.Fprint(b, value)
+		}
 	}
 
-	b.WriteByte(' ')
+	b.WriteString(f.fieldSeparator())
+}
+
+// appendString is appendKeyValue for string values. It writes directly to
+// the buffer, the value doesn't escape so that callers can pass strings
+// converted from bytes on their stack without allocating.
+func (f *TextFormatter) appendString(b *bytes.Buffer, key string, value string) {
+	defer b.WriteString(f.fieldSeparator())
+
+	if name, ok := undecoratedKeys[key]; ok && f.PlainDecorations {
+		if key == "source_file" {
+			value = strings.Replace(value, ".go", "", -1)
+		}
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(value)
+		return
+	}
+	switch key {
+	case "time":
+		f.appendTime(b, value)
+	case "level", "pid/tid", "OS":
+		b.WriteByte('[')
+		b.WriteString(value)
+		b.WriteByte(']')
+	case "process ID":
+		b.WriteString("[pid ")
+		b.WriteString(value)
+		b.WriteByte(']')
+	case "thread ID":
+		b.WriteString("[tid ")
+		b.WriteString(value)
+		b.WriteByte(']')
+	case "msg":
+		b.WriteString(value)
+		padding := f.MessagePadding
+		if padding < 0 {
+			padding = -padding
+		}
+		if f.DisableMessagePadding {
+			padding = 0
+		}
+		for n := utf8.RuneCountInString(value); n < padding; n++ {
+			b.WriteByte(' ')
+		}
+	case "source_file":
+		b.WriteByte('[')
+		b.WriteString(strings.Replace(value, ".go", "", -1))
+		b.WriteByte(']')
+	default:
+		if f.QuoteWhitespace && isWhitespace(value) {
+			b.WriteString(f.quote(value))
+		} else {
+			b.WriteString(value)
+		}
+	}
+}
+
+// appendTime writes an RFC3339-like timestamp with the date reversed, e.g.
+// "14-07-2017 02:40:00", other timestamps are written as is.
+func (f *TextFormatter) appendTime(b *bytes.Buffer, value string) {
+	t := strings.IndexByte(value, 'T')
+	if t < 0 {
+		b.WriteString(value)
+		return
+	}
+	date, clock := value[:t], value[t+1:]
+	if i := strings.IndexByte(clock, 'T'); i >= 0 {
+		clock = clock[:i]
+	}
+	if len(clock) < 8 {
+		// Not an RFC3339-like timestamp, print it as is.
+		b.WriteString(value)
+		return
+	}
+	for end := len(date); ; {
+		start := strings.LastIndexByte(date[:end], '-') + 1
+		b.WriteString(date[start:end])
+		if start == 0 {
+			b.WriteByte(' ')
+			break
+		}
+		b.WriteByte('-')
+		end = start - 1
+	}
+	b.WriteString(clock[:8])
+	if f.TimestampPrecision > PrecisionSeconds {
+		b.WriteString(fractionalSeconds(clock[8:], int(f.TimestampPrecision)))
+	}
+	if f.PreserveTimezone {
+		b.WriteString(timezoneSuffix(clock[8:]))
+	}
+}
+
+// fractionalSeconds returns the fractional seconds starting an RFC3339 clock
+// remainder, truncated or padded with zeros to the given number of digits,
+// e.g. ".12Z" becomes ".120" for 3 digits.
+func fractionalSeconds(rest string, digits int) string {
+	var fraction string
+	if strings.HasPrefix(rest, ".") {
+		fraction = rest[1:]
+		if end := strings.IndexFunc(fraction, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+			fraction = fraction[:end]
+		}
+	}
+	if len(fraction) > digits {
+		fraction = fraction[:digits]
+	} else {
+		fraction += strings.Repeat("0", digits-len(fraction))
+	}
+	return "." + fraction
+}
+
+// timezoneSuffix returns the zone designator of an RFC3339 clock remainder,
+// skipping any fractional seconds, e.g. ".123456789+05:30" becomes "+05:30".
+func timezoneSuffix(rest string) string {
+	return strings.TrimLeft(rest, ".0123456789")
+}
+
+// appendNumber writes numeric values straight into the buffer with strconv,
+// which unlike fmt doesn't allocate. It returns false if value isn't a number.
+func appendNumber(b *bytes.Buffer, value interface{}) bool {
+	var scratch [64]byte
+	var num []byte
+	switch v := value.(type) {
+	case int:
+		num = strconv.AppendInt(scratch[:0], int64(v), 10)
+	case int8:
+		num = strconv.AppendInt(scratch[:0], int64(v), 10)
+	case int16:
+		num = strconv.AppendInt(scratch[:0], int64(v), 10)
+	case int32:
+		num = strconv.AppendInt(scratch[:0], int64(v), 10)
+	case int64:
+		num = strconv.AppendInt(scratch[:0], v, 10)
+	case uint:
+		num = strconv.AppendUint(scratch[:0], uint64(v), 10)
+	case uint8:
+		num = strconv.AppendUint(scratch[:0], uint64(v), 10)
+	case uint16:
+		num = strconv.AppendUint(scratch[:0], uint64(v), 10)
+	case uint32:
+		num = strconv.AppendUint(scratch[:0], uint64(v), 10)
+	case uint64:
+		num = strconv.AppendUint(scratch[:0], v, 10)
+	case float32:
+		num = strconv.AppendFloat(scratch[:0], float64(v), 'g', -1, 32)
+	case float64:
+		num = strconv.AppendFloat(scratch[:0], v, 'g', -1, 64)
+	default:
+		return false
+	}
+	b.Write(num)
+	return true
 }
 
 func (f *TextFormatter) appendValue(b *bytes.Buffer, value interface{}) {
+	if appendNumber(b, value) {
+		return
+	}
+
 	stringVal, ok := value.(string)
-	if !ok {
+	if encoded, isComplex := f.encodeComplexValue(value); isComplex {
+		stringVal = encoded
+	} else if !ok {
 		stringVal = fmt.Sprint(value)
+	} else if f.StripValueColors {
+		stringVal = stripColors(stringVal)
 	}
 
 	if !f.needsQuoting(stringVal) {
 		b.WriteString(stringVal)
 	} else {
-		b.WriteString(fmt.Sprintf("%q", stringVal))
+		b.WriteString(f.quote(stringVal))
 	}
 }
@@ -4,7 +4,14 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -130,6 +137,841 @@ func TestTimestampFormat(t *testing.T) {
 	checkTimeStr("")
 }
 
+func TestFieldSeparator(t *testing.T) {
+	pid, tid := 1, 2
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, FieldSeparator: "\t", ProcessIDOverride: &pid, ThreadIDOverride: &tid}
+	b, _ := tf.Format(&Entry{Level: InfoLevel, Message: "msg", Data: Fields{"a": "x", "b": 1}})
+	assert.Equal(t, "[info]\t[pid 1]\t[tid 2]\t["+detectOS()+"]\tx\t1\tmsg\t\n", string(b))
+
+	tf = &TextFormatter{ForceColors: true, FieldSeparator: "\t"}
+	b, _ = tf.Format(&Entry{Data: Fields{"a": "x"}})
+	assert.Contains(t, string(b), "\t\x1b[31ma\x1b[0m=x")
+}
+
+func TestQuoteCharacter(t *testing.T) {
+	testCases := []struct {
+		quote    string
+		value    interface{}
+		expected string
+	}{
+		{"", "x y", `"x y"`},
+		{`"`, `say "hi"`, `"say \"hi\""`},
+		{"'", "x y", `'x y'`},
+		{"'", "it's", `'it\'s'`},
+		{"'", `say "hi"`, `'say "hi"'`},
+		{"'", "tab\there", `'tab\there'`},
+		{"'", errors.New("it's broken"), `'it\'s broken'`},
+	}
+
+	for _, tc := range testCases {
+		tf := &TextFormatter{ForceColors: true, QuoteCharacter: tc.quote}
+		b, _ := tf.Format(&Entry{Data: Fields{"test": tc.value}})
+		if !strings.Contains(string(b), "test\x1b[0m="+tc.expected) {
+			t.Errorf("quoting with %s expected for %q (result was %q instead of %s)", tc.quote, tc.value, string(b), tc.expected)
+		}
+	}
+
+	tf := &TextFormatter{DisableColors: true, QuoteCharacter: "'"}
+	b, _ := tf.Format(&Entry{Data: Fields{"test": errors.New("it's broken")}})
+	assert.Contains(t, string(b), ` 'it\'s broken' `)
+}
+
+func TestRelativeTimestamp(t *testing.T) {
+	tf := &TextFormatter{DisableColors: true, RelativeTimestamp: true}
+
+	b, _ := tf.Format(&Entry{Time: baseTimestamp.Add(time.Second), Message: "first", Data: Fields{}})
+	assert.True(t, strings.HasPrefix(string(b), "[0001] [panic]"), "got %q", string(b))
+
+	b, _ = tf.Format(&Entry{Time: baseTimestamp.Add(42 * time.Second), Message: "second", Data: Fields{}})
+	assert.True(t, strings.HasPrefix(string(b), "[0042] [panic]"), "got %q", string(b))
+}
+
+func TestOSOncePerProcess(t *testing.T) {
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, OSOncePerProcess: true}
+	os := "[" + detectOS() + "]"
+
+	b, _ := tf.Format(&Entry{Level: WarnLevel, Message: "first", Data: Fields{}})
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "[info]"), "got %q", lines[0])
+	assert.Contains(t, lines[0], os+" running on "+runtime.GOOS)
+	assert.True(t, strings.HasPrefix(lines[1], "[warning]"), "got %q", lines[1])
+	assert.NotContains(t, lines[1], os)
+
+	b, _ = tf.Format(&Entry{Level: WarnLevel, Message: "second", Data: Fields{}})
+	assert.Equal(t, 1, strings.Count(string(b), "\n"))
+	assert.NotContains(t, string(b), os)
+
+	other := &TextFormatter{DisableColors: true, DisableTimestamp: true, OSOncePerProcess: true}
+	b, _ = other.Format(&Entry{Level: WarnLevel, Message: "third", Data: Fields{}})
+	assert.Equal(t, 2, strings.Count(string(b), "\n"), "every formatter prints the OS")
+}
+
+// stackError mimics the errors of github.com/pkg/errors, printing their
+// stack trace with the "%+v" verb.
+type stackError struct {
+	msg    string
+	frames []string
+}
+
+func (e stackError) Error() string {
+	return e.msg
+}
+
+func (e stackError) Format(s fmt.State, verb rune) {
+	io.WriteString(s, e.msg)
+	if verb == 'v' && s.Flag('+') {
+		for _, frame := range e.frames {
+			io.WriteString(s, "\n"+frame)
+		}
+	}
+}
+
+func TestPrintStackTrace(t *testing.T) {
+	err := stackError{"boom", []string{"main.handler", "\t/app/main.go:42", "main.main", "\t/app/main.go:10"}}
+	entry := &Entry{Level: ErrorLevel, Message: "failed", Data: Fields{"error": err, "plain": errors.New("plain")}}
+
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true}
+	b, _ := tf.Format(entry)
+	assert.Equal(t, 1, strings.Count(string(b), "\n"), "stack trace must not be printed by default")
+
+	tf.PrintStackTrace = true
+	b, _ = tf.Format(entry)
+	lines := strings.Split(string(b), "\n")
+	assert.Len(t, lines, 6)
+	assert.Contains(t, lines[0], "boom")
+	assert.Equal(t, []string{"\tmain.handler", "\t\t/app/main.go:42", "\tmain.main", "\t\t/app/main.go:10", ""}, lines[1:])
+}
+
+func TestLevelSeparator(t *testing.T) {
+	tf := &TextFormatter{ForceColors: true, FullTimestamp: true, TimestampFormat: "15:04:05"}
+	entry := &Entry{Time: time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC), Level: InfoLevel, Message: "msg", Data: Fields{}}
+
+	b, _ := tf.Format(entry)
+	assert.True(t, strings.HasPrefix(string(b), "\x1b[36mINFO\x1b[0m[05:06:07] msg"), "got %q", string(b))
+
+	tf.LevelSeparator = " "
+	b, _ = tf.Format(entry)
+	assert.True(t, strings.HasPrefix(string(b), "\x1b[36mINFO\x1b[0m [05:06:07] msg"), "got %q", string(b))
+
+	tf.LevelSeparator = ": "
+	tf.DisableTimestamp = true
+	b, _ = tf.Format(entry)
+	assert.True(t, strings.HasPrefix(string(b), "\x1b[36mINFO\x1b[0m:  msg"), "got %q", string(b))
+}
+
+func TestAliasThreadIDs(t *testing.T) {
+	resetThreadAliases := func() {
+		threadAliases.Range(func(id, _ interface{}) bool {
+			threadAliases.Delete(id)
+			return true
+		})
+		lastThreadAlias = 0
+		aliasedThreads = nil
+	}
+	resetThreadAliases()
+	defer resetThreadAliases()
+
+	var wg sync.WaitGroup
+	aliases := make([]int, 8)
+	for i := range aliases {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			aliases[i] = threadAlias(1048576 + i)
+		}(i)
+	}
+	wg.Wait()
+
+	sorted := append([]int(nil), aliases...)
+	sort.Ints(sorted)
+	for i, alias := range sorted {
+		assert.Equal(t, i+1, alias, "aliases must be sequential and unique")
+	}
+	for i, alias := range aliases {
+		assert.Equal(t, alias, threadAlias(1048576+i), "aliases must be stable")
+	}
+
+	defer SetRuntimeInfo(nil)
+	SetRuntimeInfo(stubRuntimeInfo{pid: 1, tid: 2097152, os: "linux"})
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, AliasThreadIDs: true}
+	b, _ := tf.Format(&Entry{Level: InfoLevel, Message: "msg", Data: Fields{}})
+	assert.Contains(t, string(b), "[tid 9]")
+
+	tf.AliasThreadIDs = false
+	b, _ = tf.Format(&Entry{Level: InfoLevel, Message: "msg", Data: Fields{}})
+	assert.Contains(t, string(b), "[tid 2097152]")
+
+	for i := 0; i < maxThreadAliases; i++ {
+		threadAlias(4194304 + i)
+	}
+	assert.Equal(t, maxThreadAliases, len(aliasedThreads))
+	assert.Equal(t, maxThreadAliases+10, threadAlias(1048576), "forgotten threads get a new alias")
+	assert.Equal(t, maxThreadAliases+9, threadAlias(4194304+maxThreadAliases-1), "recent threads keep their alias")
+}
+
+func TestMessagePlacement(t *testing.T) {
+	entry := &Entry{Level: InfoLevel, Message: "the message", Data: Fields{"key": "the value"}}
+	order := func(tf *TextFormatter) string {
+		b, _ := tf.Format(entry)
+		msg, value := strings.Index(string(b), "the message"), strings.Index(string(b), "the value")
+		assert.True(t, msg >= 0 && value >= 0, "got %q", string(b))
+		if msg < value {
+			return "message first"
+		}
+		return "message last"
+	}
+
+	plain := &TextFormatter{DisableColors: true, DisableTimestamp: true}
+	colored := &TextFormatter{ForceColors: true, DisableTimestamp: true}
+	assert.Equal(t, "message last", order(plain))
+	assert.Equal(t, "message first", order(colored))
+
+	plain.MessagePlacement, colored.MessagePlacement = MessageFirst, MessageFirst
+	assert.Equal(t, "message first", order(plain))
+	assert.Equal(t, "message first", order(colored))
+
+	plain.MessagePlacement, colored.MessagePlacement = MessageLast, MessageLast
+	assert.Equal(t, "message last", order(plain))
+	assert.Equal(t, "message last", order(colored))
+
+	b, _ := colored.Format(entry)
+	assert.Equal(t, "\x1b[36mINFO\x1b[0m \x1b[36mkey\x1b[0m=\"the value\" the message\n", string(b))
+}
+
+func TestColorValues(t *testing.T) {
+	tf := &TextFormatter{ForceColors: true, DisableTimestamp: true}
+	entry := &Entry{Level: ErrorLevel, Message: "msg", Data: Fields{"error": "boom", "code": 500}}
+
+	b, _ := tf.Format(entry)
+	assert.Contains(t, string(b), " \x1b[31merror\x1b[0m=boom")
+
+	tf.ColorValues = true
+	b, _ = tf.Format(entry)
+	assert.Contains(t, string(b), " \x1b[31mcode\x1b[0m=\x1b[31m500\x1b[0m")
+	assert.True(t, strings.HasSuffix(string(b), " \x1b[31merror\x1b[0m=\x1b[31mboom\x1b[0m\n"), "got %q", string(b))
+}
+
+func TestMessagePadding(t *testing.T) {
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, MessagePlacement: MessageFirst, MessagePadding: 20}
+
+	short, _ := tf.Format(&Entry{Level: InfoLevel, Message: "short", Data: Fields{"key": "value"}})
+	long, _ := tf.Format(&Entry{Level: InfoLevel, Message: "a longer message", Data: Fields{"key": "value"}})
+	assert.Equal(t, strings.Index(string(short), "value"), strings.Index(string(long), "value"))
+
+	overflow, _ := tf.Format(&Entry{Level: InfoLevel, Message: "a message longer than the padding", Data: Fields{"key": "value"}})
+	assert.Contains(t, string(overflow), "a message longer than the padding value")
+
+	tf = &TextFormatter{ForceColors: true, DisableTimestamp: true, MessagePadding: 10}
+	b, _ := tf.Format(&Entry{Level: InfoLevel, Message: "short", Data: Fields{"key": "value"}})
+	assert.Contains(t, string(b), " short       \x1b[36mkey")
+
+	tf = &TextFormatter{ForceColors: true, DisableTimestamp: true, DisableMessagePadding: true}
+	b, _ = tf.Format(&Entry{Level: InfoLevel, Message: "short", Data: Fields{"key": "value"}})
+	assert.Contains(t, string(b), " short  \x1b[36mkey")
+
+	tf = &TextFormatter{DisableColors: true, DisableTimestamp: true, MessagePlacement: MessageFirst, MessagePadding: 20, DisableMessagePadding: true}
+	b, _ = tf.Format(&Entry{Level: InfoLevel, Message: "short", Data: Fields{"key": "value"}})
+	assert.Contains(t, string(b), "short value")
+}
+
+func TestQuoteWhitespace(t *testing.T) {
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true}
+	entry := &Entry{Level: InfoLevel, Message: "msg", Data: Fields{"a": " ", "b": "\t", "c": "", "d": "x y"}}
+
+	b, _ := tf.Format(entry)
+	assert.True(t, strings.HasSuffix(string(b), "   \t  x y msg \n"), "got %q", string(b))
+
+	tf.QuoteWhitespace = true
+	b, _ = tf.Format(entry)
+	assert.True(t, strings.HasSuffix(string(b), ` " " "\t"  x y msg `+"\n"), "got %q", string(b))
+
+	tf = &TextFormatter{ForceColors: true, DisableTimestamp: true}
+	b, _ = tf.Format(entry)
+	assert.Contains(t, string(b), "a\x1b[0m=\" \"")
+	assert.Contains(t, string(b), "b\x1b[0m=\"\\t\"")
+}
+
+func TestLevelCase(t *testing.T) {
+	entry := &Entry{Level: WarnLevel, Message: "msg", Data: Fields{}}
+	for _, tc := range []struct {
+		levelCase LevelCase
+		colored   string
+		plain     string
+	}{
+		{CaseDefault, "WARN", "[warning]"},
+		{CaseUpper, "WARN", "[WARNING]"},
+		{CaseLower, "warn", "[warning]"},
+		{CaseAsIs, "warn", "[warning]"},
+	} {
+		colored := &TextFormatter{ForceColors: true, DisableTimestamp: true, LevelCase: tc.levelCase}
+		b, _ := colored.Format(entry)
+		assert.True(t, strings.HasPrefix(string(b), "\x1b[33m"+tc.colored+"\x1b[0m "), "case %d: got %q", tc.levelCase, string(b))
+
+		plain := &TextFormatter{DisableColors: true, DisableTimestamp: true, LevelCase: tc.levelCase}
+		b, _ = plain.Format(entry)
+		assert.True(t, strings.HasPrefix(string(b), tc.plain+" "), "case %d: got %q", tc.levelCase, string(b))
+	}
+}
+
+func TestStripValueColors(t *testing.T) {
+	entry := &Entry{Level: InfoLevel, Message: "msg", Data: Fields{"output": "\x1b[31mred\x1b[0m"}}
+
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true}
+	b, _ := tf.Format(entry)
+	assert.Contains(t, string(b), "\x1b[31mred\x1b[0m msg")
+
+	tf.StripValueColors = true
+	b, _ = tf.Format(entry)
+	assert.Contains(t, string(b), "] red msg")
+
+	tf = &TextFormatter{ForceColors: true, DisableTimestamp: true, StripValueColors: true}
+	b, _ = tf.Format(entry)
+	assert.True(t, strings.HasSuffix(string(b), " \x1b[36moutput\x1b[0m=red\n"), "got %q", string(b))
+}
+
+func TestJSONEncodeComplexValues(t *testing.T) {
+	type point struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+	entry := &Entry{Level: InfoLevel, Message: "msg", Data: Fields{
+		"a": []string{"x", "y"},
+		"b": map[string]int{"n": 1},
+		"c": point{1, 2},
+		"d": 42,
+		"e": make(chan int),
+	}}
+
+	tf := &TextFormatter{ForceColors: true, DisableTimestamp: true}
+	b, _ := tf.Format(entry)
+	assert.Contains(t, string(b), "a\x1b[0m=\"[x y]\"")
+
+	tf.JSONEncodeComplexValues = true
+	b, _ = tf.Format(entry)
+	assert.Contains(t, string(b), `a`+"\x1b[0m="+`"[\"x\",\"y\"]"`)
+	assert.Contains(t, string(b), `b`+"\x1b[0m="+`"{\"n\":1}"`)
+	assert.Contains(t, string(b), `c`+"\x1b[0m="+`"{\"x\":1,\"y\":2}"`)
+	assert.Contains(t, string(b), "d\x1b[0m=42")
+	assert.Contains(t, string(b), "e\x1b[0m=0x", "values failing to marshal must fall back to fmt")
+
+	tf = &TextFormatter{DisableColors: true, DisableTimestamp: true, JSONEncodeComplexValues: true}
+	delete(entry.Data, "e")
+	b, _ = tf.Format(entry)
+	assert.Contains(t, string(b), `"[\"x\",\"y\"]" "{\"n\":1}" "{\"x\":1,\"y\":2}" 42 msg`)
+
+	u, _ := url.Parse("https://example.com/a")
+	entry = &Entry{Level: InfoLevel, Message: "msg", Data: Fields{
+		"a": &point{1, 2},
+		"b": &map[string]int{"n": 1},
+		"c": (*point)(nil),
+		"d": u,
+	}}
+	b, _ = tf.Format(entry)
+	assert.Contains(t, string(b), `"{\"x\":1,\"y\":2}" "{\"n\":1}" <nil> https://example.com/a msg`)
+}
+
+func TestTraceLevelColor(t *testing.T) {
+	tf := &TextFormatter{ForceColors: true, DisableTimestamp: true}
+
+	b, _ := tf.Format(&Entry{Level: TraceLevel, Message: "msg", Data: Fields{}})
+	assert.True(t, strings.HasPrefix(string(b), "\x1b[90mTRAC\x1b[0m "), "got %q", string(b))
+
+	b, _ = tf.Format(&Entry{Level: DebugLevel, Message: "msg", Data: Fields{}})
+	assert.True(t, strings.HasPrefix(string(b), "\x1b[37mDEBU\x1b[0m "), "got %q", string(b))
+}
+
+func TestTerminalChecker(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &TextFormatter{DisableTimestamp: true, TerminalChecker: func(w io.Writer) bool { return w == &buffer }}
+
+	logger.Info("colored")
+	assert.Contains(t, buffer.String(), "\x1b[36mINFO\x1b[0m")
+
+	buffer.Reset()
+	logger.Formatter = &TextFormatter{DisableTimestamp: true, DisableColors: true, TerminalChecker: func(io.Writer) bool { return true }}
+	logger.Info("plain")
+	assert.NotContains(t, buffer.String(), "\x1b[")
+
+	buffer.Reset()
+	logger.Formatter = &TextFormatter{DisableTimestamp: true, ForceColors: true, TerminalChecker: func(io.Writer) bool { return false }}
+	logger.Info("forced")
+	assert.Contains(t, buffer.String(), "\x1b[36mINFO\x1b[0m")
+}
+
+func TestOmitEmptyMessage(t *testing.T) {
+	entry := &Entry{Level: InfoLevel, Data: Fields{"key": "value"}}
+
+	tf := &TextFormatter{ForceColors: true, DisableTimestamp: true}
+	b, _ := tf.Format(entry)
+	assert.Equal(t, "\x1b[36mINFO\x1b[0m "+strings.Repeat(" ", 44)+"  \x1b[36mkey\x1b[0m=value\n", string(b))
+
+	tf.OmitEmptyMessage = true
+	b, _ = tf.Format(entry)
+	assert.Equal(t, "\x1b[36mINFO\x1b[0m \x1b[36mkey\x1b[0m=value\n", string(b))
+
+	tf.MessagePlacement = MessageLast
+	b, _ = tf.Format(entry)
+	assert.Equal(t, "\x1b[36mINFO\x1b[0m \x1b[36mkey\x1b[0m=value\n", string(b))
+
+	tf = &TextFormatter{DisableColors: true, DisableTimestamp: true, OmitEmptyMessage: true}
+	b, _ = tf.Format(entry)
+	assert.True(t, strings.HasSuffix(string(b), "] value \n"), "got %q", string(b))
+}
+
+// freezeClock makes now return t until the returned function is called, and
+// sets the start of the program to start.
+func freezeClock(t, start time.Time) func() {
+	savedNow, savedBase := now, baseTimestamp
+	now = func() time.Time { return t }
+	baseTimestamp = start
+	return func() {
+		now, baseTimestamp = savedNow, savedBase
+	}
+}
+
+func TestFrozenClock(t *testing.T) {
+	start := time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)
+	defer freezeClock(start.Add(42*time.Second+500*time.Millisecond), start)()
+
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &TextFormatter{ForceColors: true}
+	logger.Info("frozen")
+	assert.True(t, strings.HasPrefix(buffer.String(), "\x1b[36mINFO\x1b[0m[0042] frozen"), "got %q", buffer.String())
+
+	buffer.Reset()
+	logger.Formatter = &TextFormatter{ForceColors: true, FullTimestamp: true, TimestampFormat: time.RFC3339}
+	logger.Info("frozen")
+	assert.True(t, strings.HasPrefix(buffer.String(), "\x1b[36mINFO\x1b[0m[2018-03-04T05:06:49Z] frozen"), "got %q", buffer.String())
+}
+
+func TestLinePrefix(t *testing.T) {
+	entry := &Entry{Level: ErrorLevel, Message: "msg", Data: Fields{"key": "value"}}
+
+	tf := &TextFormatter{DisableColors: true, LinePrefix: "[billing] "}
+	b, _ := tf.Format(entry)
+	assert.True(t, strings.HasPrefix(string(b), "[billing] 01-01-0001 00:00:00 [error]"), "got %q", string(b))
+	assert.True(t, strings.HasSuffix(string(b), "] value msg \n"), "got %q", string(b))
+
+	tf = &TextFormatter{ForceColors: true, DisableTimestamp: true, LinePrefix: "[billing] "}
+	b, _ = tf.Format(entry)
+	assert.True(t, strings.HasPrefix(string(b), "[billing] \x1b[31mERRO\x1b[0m msg"), "got %q", string(b))
+	assert.True(t, strings.HasSuffix(string(b), " \x1b[31mkey\x1b[0m=value\n"), "got %q", string(b))
+
+	tf.ColorLinePrefix = true
+	b, _ = tf.Format(entry)
+	assert.True(t, strings.HasPrefix(string(b), "\x1b[31m[billing] \x1b[0m\x1b[31mERRO\x1b[0m msg"), "got %q", string(b))
+
+	entry = &Entry{Level: ErrorLevel, Message: "first\nsecond", Data: Fields{}}
+	b, _ = tf.Format(entry)
+	assert.True(t, strings.Contains(string(b), "first\n\x1b[31m[billing] \x1b[0msecond"), "got %q", string(b))
+
+	tf = &TextFormatter{DisableColors: true, DisableTimestamp: true, DisableRuntimeFields: true, LinePrefix: "> "}
+	entry = &Entry{Level: ErrorLevel, Message: "msg", Data: Fields{ErrorKey + "." + ErrorStackKey: "main.main\n\tmain.go:3"}}
+	b, _ = tf.Format(entry)
+	assert.Equal(t, "> [error] msg \n> \tmain.main\n> \t\tmain.go:3\n", string(b))
+}
+
+func TestLinePrefixStartupEntry(t *testing.T) {
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, DisableRuntimeFields: true, OSOncePerProcess: true, LinePrefix: "> "}
+	b, _ := tf.Format(&Entry{Level: InfoLevel, Message: "msg", Data: Fields{}})
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	assert.Equal(t, 2, len(lines), "got %q", string(b))
+	for _, line := range lines {
+		assert.True(t, strings.HasPrefix(line, "> [info] "), "got %q", line)
+	}
+}
+
+func TestPlainDecorations(t *testing.T) {
+	pid, tid := 123, 45
+	entry := &Entry{Level: InfoLevel, Message: "msg", Data: Fields{"source_file": "/src/connectivity/connectivity.go:676"}}
+
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, ProcessIDOverride: &pid, ThreadIDOverride: &tid}
+	b, _ := tf.Format(entry)
+	assert.Equal(t, "[info] [pid 123] [tid 45] ["+detectOS()+"] [connectivity:676] msg \n", string(b))
+
+	tf.PlainDecorations = true
+	b, _ = tf.Format(entry)
+	assert.Equal(t, "level=info process_id=123 thread_id=45 os="+detectOS()+" source_file=connectivity:676 msg \n", string(b))
+}
+
+func TestSourceFileNotString(t *testing.T) {
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, DisableRuntimeFields: true}
+
+	b, err := tf.Format(&Entry{Level: InfoLevel, Message: "msg", Data: Fields{"source_file": 42}})
+	assert.NoError(t, err)
+	assert.Equal(t, "[info] [42] msg \n", string(b))
+
+	tf.ForceColors = true
+	b, err = tf.Format(&Entry{Level: InfoLevel, Message: "msg", Data: Fields{"source_file": []string{"main.go:1"}}})
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "[main:1]")
+}
+
+func TestSourcePath(t *testing.T) {
+	entry := &Entry{Level: InfoLevel, Message: "msg", Data: Fields{"source_file": "/src/app/db/conn.go:42"}}
+	testCases := []struct {
+		style    SourcePathStyle
+		prefix   string
+		expected string
+	}{
+		{SourcePathDefault, "", "[conn:42]"},
+		{SourcePathBase, "", "[conn:42]"},
+		{SourcePathFull, "", "[/src/app/db/conn:42]"},
+		{SourcePathTrimmed, "", "[db/conn:42]"},
+		{SourcePathTrimmed, "/src/app", "[db/conn:42]"},
+		{SourcePathTrimmed, "/src/", "[app/db/conn:42]"},
+		{SourcePathTrimmed, "/elsewhere", "[db/conn:42]"},
+	}
+
+	for _, tc := range testCases {
+		tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, DisableRuntimeFields: true, SourcePath: tc.style, TrimPathPrefix: tc.prefix}
+		b, _ := tf.Format(entry)
+		assert.Equal(t, "[info] "+tc.expected+" msg \n", string(b), "style %d, prefix %q", tc.style, tc.prefix)
+	}
+
+	tf := &TextFormatter{ForceColors: true, DisableTimestamp: true, SourcePath: SourcePathTrimmed}
+	b, _ := tf.Format(entry)
+	assert.Contains(t, string(b), "=\"db/conn.go:42\"")
+}
+
+func TestCallerPrettyfier(t *testing.T) {
+	entry := &Entry{Level: InfoLevel, Message: "msg", Data: Fields{}, Caller: &runtime.Frame{Function: "github.com/org/app/db.Open", File: "/src/app/db/conn.go", Line: 42}}
+
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, DisableRuntimeFields: true, PlainDecorations: true}
+	b, _ := tf.Format(entry)
+	assert.Equal(t, "level=info github.com/org/app/db.Open /src/app/db/conn.go:42 msg \n", string(b))
+
+	tf.SourcePath = SourcePathBase
+	b, _ = tf.Format(entry)
+	assert.Equal(t, "level=info github.com/org/app/db.Open conn.go:42 msg \n", string(b))
+
+	tf.CallerPrettyfier = func(frame *runtime.Frame) (string, string) {
+		return "", fmt.Sprintf("%s:%d", path.Base(frame.File), frame.Line)
+	}
+	b, _ = tf.Format(entry)
+	assert.Equal(t, "level=info conn.go:42 msg \n", string(b))
+
+	tf.DisableColors, tf.ForceColors = false, true
+	b, _ = tf.Format(entry)
+	assert.NotContains(t, string(b), "func")
+	assert.Contains(t, string(b), "file\x1b[0m=\"conn.go:42\"")
+}
+
+func TestCombinePIDTID(t *testing.T) {
+	pid, tid := 123, 45
+	entry := &Entry{Level: InfoLevel, Message: "msg", Data: Fields{}}
+
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, CombinePIDTID: true, ProcessIDOverride: &pid, ThreadIDOverride: &tid}
+	b, _ := tf.Format(entry)
+	assert.Equal(t, "[info] [123/45] ["+detectOS()+"] msg \n", string(b))
+
+	tf.PlainDecorations = true
+	b, _ = tf.Format(entry)
+	assert.Equal(t, "level=info pid_tid=123/45 os="+detectOS()+" msg \n", string(b))
+
+	tf.PlainDecorations = false
+	tf.DisableThreadID = true
+	b, _ = tf.Format(entry)
+	assert.Equal(t, "[info] [pid 123] ["+detectOS()+"] msg \n", string(b), "combining must be ignored when an ID is disabled")
+
+	tf.DisableThreadID, tf.DisableProcessID = false, true
+	b, _ = tf.Format(entry)
+	assert.Equal(t, "[info] [tid 45] ["+detectOS()+"] msg \n", string(b))
+
+	tf = &TextFormatter{ForceColors: true, DisableTimestamp: true, CombinePIDTID: true, ProcessIDOverride: &pid, ThreadIDOverride: &tid}
+	b, _ = tf.Format(entry)
+	assert.True(t, strings.HasSuffix(string(b), " \x1b[36mpid/tid\x1b[0m=123/45\n"), "got %q", string(b))
+
+	tf.SandboxSafe = true
+	b, _ = tf.Format(&Entry{Level: InfoLevel, Message: "msg", Data: Fields{}})
+	assert.NotContains(t, string(b), "pid/tid")
+}
+
+func TestCompactHeader(t *testing.T) {
+	pid, tid := 1, 9
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, CompactHeader: true, ProcessIDOverride: &pid, ThreadIDOverride: &tid}
+
+	b, _ := tf.Format(&Entry{Level: InfoLevel, Message: "msg", Data: Fields{}})
+	assert.Equal(t, "I/1.9."+detectOS()+" msg \n", string(b))
+
+	tf.CompactHeaderDelimiter = ":"
+	b, _ = tf.Format(&Entry{Level: WarnLevel, Message: "msg", Data: Fields{}})
+	assert.Equal(t, "W/1:9:"+detectOS()+" msg \n", string(b))
+
+	tf.SandboxSafe = true
+	b, _ = tf.Format(&Entry{Level: ErrorLevel, Message: "msg", Data: Fields{}})
+	assert.Equal(t, "E/"+detectOS()+" msg \n", string(b))
+}
+
+func TestTextErrorFields(t *testing.T) {
+	tf := &TextFormatter{ForceColors: true}
+
+	err := contextError{map[string]interface{}{"code": 503, "host": "db1"}}
+	entry := WithField("error", err)
+	b, _ := tf.Format(entry)
+
+	assert.Contains(t, string(b), "error.code\x1b[0m=503")
+	assert.Contains(t, string(b), "error.host\x1b[0m=db1")
+	assert.Equal(t, 1, len(entry.Data), "entry data must not be modified")
+}
+
+func TestPreserveTimezone(t *testing.T) {
+	ist := time.FixedZone("IST", 5*60*60+30*60)
+
+	testCases := []struct {
+		time     time.Time
+		preserve bool
+		expected string
+	}{
+		{time.Date(2018, time.June, 22, 7, 27, 57, 123456789, ist), false, "22-06-2018 07:27:57 ["},
+		{time.Date(2018, time.June, 22, 7, 27, 57, 123456789, ist), true, "22-06-2018 07:27:57+05:30 ["},
+		{time.Date(2018, time.June, 22, 7, 27, 57, 123456789, time.UTC), true, "22-06-2018 07:27:57Z ["},
+		{time.Date(2018, time.June, 22, 7, 27, 57, 0, time.UTC), true, "22-06-2018 07:27:57Z ["},
+	}
+
+	for _, tc := range testCases {
+		tf := &TextFormatter{DisableColors: true, TimestampFormat: time.RFC3339Nano, PreserveTimezone: tc.preserve}
+		b, _ := tf.Format(&Entry{Time: tc.time, Data: Fields{}})
+		if !bytes.HasPrefix(b, []byte(tc.expected)) {
+			t.Errorf("timestamp expected for %s (result was %q instead of %q)", tc.time, string(b), tc.expected)
+		}
+	}
+}
+
+func TestTimestampPrecision(t *testing.T) {
+	testCases := []struct {
+		nanos     int
+		precision Precision
+		format    string
+		expected  string
+	}{
+		{123456789, PrecisionSeconds, "", "22-06-2018 07:27:57 ["},
+		{123456789, PrecisionMillis, "", "22-06-2018 07:27:57.123 ["},
+		{123456789, PrecisionNanos, "", "22-06-2018 07:27:57.123456789 ["},
+		{120000000, PrecisionMillis, "", "22-06-2018 07:27:57.120 ["},
+		{0, PrecisionMillis, "", "22-06-2018 07:27:57.000 ["},
+		{123456789, PrecisionNanos, "2006-01-02T15:04:05.000Z07:00", "22-06-2018 07:27:57.123000000 ["},
+	}
+
+	for _, tc := range testCases {
+		tf := &TextFormatter{DisableColors: true, TimestampPrecision: tc.precision, TimestampFormat: tc.format}
+		ts := time.Date(2018, time.June, 22, 7, 27, 57, tc.nanos, time.UTC)
+		b, _ := tf.Format(&Entry{Time: ts, Data: Fields{}})
+		if !bytes.HasPrefix(b, []byte(tc.expected)) {
+			t.Errorf("timestamp expected for precision %d (result was %q instead of %q)", tc.precision, string(b), tc.expected)
+		}
+	}
+
+	tf := &TextFormatter{DisableColors: true, TimestampPrecision: PrecisionMillis, PreserveTimezone: true}
+	ts := time.Date(2018, time.June, 22, 7, 27, 57, 123456789, time.FixedZone("IST", 5*60*60+30*60))
+	b, _ := tf.Format(&Entry{Time: ts, Data: Fields{}})
+	assert.Equal(t, true, bytes.HasPrefix(b, []byte("22-06-2018 07:27:57.123+05:30 [")), string(b))
+}
+
+func TestReportFieldCount(t *testing.T) {
+	testCases := []struct {
+		fields   Fields
+		expected int
+	}{
+		{Fields{}, 0},
+		{Fields{"a": 1}, 1},
+		{Fields{"a": 1, "b": 2, "msg": "clash"}, 3},
+	}
+
+	for _, tc := range testCases {
+		tf := &TextFormatter{ForceColors: true, ReportFieldCount: true}
+		b, _ := tf.Format(&Entry{Data: tc.fields})
+		assert.Contains(t, string(b), fmt.Sprintf("field_count\x1b[0m=%d", tc.expected))
+	}
+
+	tf := &TextFormatter{DisableColors: true, ReportFieldCount: true}
+	b, _ := tf.Format(&Entry{Data: Fields{"a": "x", "b": "y"}})
+	assert.Contains(t, string(b), "] 2 x y")
+}
+
+func TestProcessAndThreadIDOverride(t *testing.T) {
+	pid, tid := 1234, 42
+	tf := &TextFormatter{DisableColors: true, ProcessIDOverride: &pid, ThreadIDOverride: &tid}
+
+	b, _ := tf.Format(&Entry{Level: InfoLevel, Data: Fields{}})
+	assert.Contains(t, string(b), "[info] [pid 1234] [tid 42] ")
+
+	tf = &TextFormatter{DisableColors: true, ThreadIDOverride: &tid}
+	b, _ = tf.Format(&Entry{Level: InfoLevel, Data: Fields{}})
+	assert.Contains(t, string(b), fmt.Sprintf("[info] [pid %d] [tid 42] ", getpid()))
+}
+
+func TestSandboxSafe(t *testing.T) {
+	defer func(host func() (string, error)) { lookupHostname = host }(lookupHostname)
+	defer SetRuntimeInfo(nil)
+	SetRuntimeInfo(panickingRuntimeInfo{})
+	lookupHostname = func() (string, error) { panic("os.Hostname called") }
+
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &TextFormatter{
+		SandboxSafe:     true,
+		ForceColors:     true,
+		ReportHostname:  true,
+		TerminalChecker: func(io.Writer) bool { panic("terminal check called") },
+	}
+
+	assert.NotPanics(t, func() { logger.WithField("foo", "bar").Info("sandboxed") })
+
+	assert.Contains(t, buffer.String(), "[info] ["+detectOS()+"] bar sandboxed")
+	assert.NotContains(t, buffer.String(), "\x1b[")
+	assert.NotContains(t, buffer.String(), "[pid")
+}
+
+func TestRedactKeys(t *testing.T) {
+	entry := &Entry{
+		Message: "login with token=s3cr3t-token done",
+		Data: Fields{
+			"password":      "hunter2",
+			"Authorization": "Bearer abcdef",
+			"user":          "walrus",
+		},
+	}
+
+	for _, tf := range []*TextFormatter{{DisableColors: true}, {ForceColors: true}} {
+		tf.RedactKeys = []string{"PASSWORD", "authorization"}
+		tf.RedactMessagePattern = regexp.MustCompile(`token=\S+`)
+
+		b, _ := tf.Format(entry)
+		out := string(b)
+		assert.NotContains(t, out, "hunter2")
+		assert.NotContains(t, out, "abcdef")
+		assert.NotContains(t, out, "s3cr3t")
+		assert.Contains(t, out, "***")
+		assert.Contains(t, out, "walrus")
+		assert.Contains(t, out, "login with *** done")
+	}
+
+	assert.Equal(t, "hunter2", entry.Data["password"], "entry data must not be modified")
+}
+
+func TestRedactFunc(t *testing.T) {
+	tf := &TextFormatter{
+		ForceColors: true,
+		RedactKeys:  []string{"password"},
+		RedactFunc: func(key string, value interface{}) interface{} {
+			return fmt.Sprintf("<%d chars>", len(value.(string)))
+		},
+	}
+
+	b, _ := tf.Format(&Entry{Data: Fields{"password": "hunter2", "user": "walrus"}})
+	assert.Contains(t, string(b), "password\x1b[0m=\"<7 chars>\"")
+	assert.Contains(t, string(b), "user\x1b[0m=walrus")
+	assert.NotContains(t, string(b), "hunter2")
+}
+
+func TestTerminalDetectionPerOutput(t *testing.T) {
+	tty, file := &bytes.Buffer{}, &bytes.Buffer{}
+	tf := &TextFormatter{TerminalChecker: func(w io.Writer) bool { return w == tty }}
+
+	ttyLogger, fileLogger := New(), New()
+	ttyLogger.Out, fileLogger.Out = tty, file
+	ttyLogger.Formatter, fileLogger.Formatter = tf, tf
+
+	ttyLogger.Info("hello")
+	fileLogger.Info("hello")
+	ttyLogger.Info("hello")
+
+	assert.Contains(t, tty.String(), "\x1b[", "terminal output should be colored")
+	assert.NotContains(t, file.String(), "\x1b[", "file output should not be colored")
+}
+
+func TestReportHostname(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &TextFormatter{DisableColors: true, ReportHostname: true}
+
+	logger.Info("first")
+	logger.Info("second")
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	assert.Equal(t, 2, len(lines))
+	for _, line := range lines {
+		assert.Contains(t, line, "["+detectOS()+"] "+getHostname()+" ")
+	}
+
+	buffer.Reset()
+	logger.Formatter = &TextFormatter{
+		ForceColors:    true,
+		ReportHostname: true,
+		FieldMap:       FieldMap{FieldKeyHostname: "node"},
+	}
+	logger.Info("colored")
+	assert.Contains(t, buffer.String(), "node\x1b[0m=")
+}
+
+func TestAppendNumber(t *testing.T) {
+	values := []interface{}{
+		0, -1, 42, int8(-128), int16(32767), int32(-5), int64(9007199254740993),
+		uint(7), uint8(255), uint16(65535), uint32(4096), uint64(18446744073709551615),
+		float32(0.1), 0.123, 1e21, -2.5e-7,
+	}
+
+	for _, value := range values {
+		var b bytes.Buffer
+		assert.Equal(t, true, appendNumber(&b, value))
+		assert.Equal(t, fmt.Sprint(value), b.String(), "rendering of %#v", value)
+	}
+
+	var b bytes.Buffer
+	assert.Equal(t, false, appendNumber(&b, "42"))
+	assert.Equal(t, 0, b.Len())
+}
+
+func TestTextReportLevelNumber(t *testing.T) {
+	entry := &Entry{Level: ErrorLevel, Data: Fields{}}
+
+	tf := &TextFormatter{DisableColors: true, ReportLevelNumber: true, LevelNumbers: SyslogLevelNumbers}
+	b, _ := tf.Format(entry)
+	assert.Contains(t, string(b), "[error] 3 [pid ")
+
+	tf = &TextFormatter{ForceColors: true, ReportLevelNumber: true}
+	b, _ = tf.Format(entry)
+	assert.Contains(t, string(b), "level_num\x1b[0m=2")
+}
+
+func TestLinkifyURLs(t *testing.T) {
+	link := func(url string) string {
+		return "\x1b]8;;" + url + "\x1b\\" + url + "\x1b]8;;\x1b\\"
+	}
+
+	testCases := []struct {
+		message  string
+		expected string
+	}{
+		{"see https://example.com/a?b=c for details", "see " + link("https://example.com/a?b=c") + " for details"},
+		{"http://example.com", link("http://example.com")},
+		{"docs at https://example.com/docs.", "docs at " + link("https://example.com/docs") + "."},
+		{"(https://example.com/x)", "(" + link("https://example.com/x") + ")"},
+		{"no links here", "no links here"},
+		{link("https://example.com"), link("https://example.com")},
+	}
+
+	tf := &TextFormatter{ForceColors: true, DisableTimestamp: true, LinkifyURLs: true}
+	for _, tc := range testCases {
+		b, _ := tf.Format(&Entry{Message: tc.message, Level: InfoLevel, Data: Fields{}})
+		if !strings.Contains(string(b), "\x1b[0m "+tc.expected) {
+			t.Errorf("hyperlinks expected for %q (result was %q)", tc.message, string(b))
+		}
+	}
+
+	tf = &TextFormatter{DisableColors: true, LinkifyURLs: true}
+	b, _ := tf.Format(&Entry{Message: "https://example.com", Data: Fields{}})
+	assert.NotContains(t, string(b), "\x1b]8;")
+}
+
 func TestDisableLevelTruncation(t *testing.T) {
 	entry := &Entry{
 		Time:    time.Now(),
@@ -162,6 +1004,8 @@ func TestDisableLevelTruncation(t *testing.T) {
 		}
 	}
 
+	checkDisableTruncation(true, TraceLevel)
+	checkDisableTruncation(false, TraceLevel)
 	checkDisableTruncation(true, DebugLevel)
 	checkDisableTruncation(true, InfoLevel)
 	checkDisableTruncation(false, ErrorLevel)
@@ -218,3 +1062,220 @@ func TestTextFormatterFieldMap(t *testing.T) {
 
 // TODO add tests for sorting etc., this requires a parser for the text
 // formatter output.
+
+func TestTimeLocation(t *testing.T) {
+	ts := time.Date(2018, time.June, 22, 7, 27, 57, 0, time.UTC)
+	ist := time.FixedZone("IST", 5*60*60+30*60)
+
+	tf := &TextFormatter{DisableColors: true, TimeLocation: ist, PreserveTimezone: true}
+	entry := &Entry{Time: ts, Data: Fields{}}
+	b, _ := tf.Format(entry)
+	assert.True(t, bytes.HasPrefix(b, []byte("22-06-2018 12:57:57+05:30 [")), string(b))
+	assert.Equal(t, time.UTC, entry.Time.Location(), "entry time must not be modified")
+
+	tf = &TextFormatter{ForceColors: true, FullTimestamp: true, TimeLocation: ist, TimestampFormat: "15:04"}
+	b, _ = tf.Format(&Entry{Time: ts, Data: Fields{}})
+	assert.Contains(t, string(b), "[12:57]")
+
+	tf = &TextFormatter{DisableColors: true, TimeLocation: ist, TimestampFormat: "15:04:05"}
+	b, _ = tf.Format(&Entry{Time: ts, Data: Fields{}})
+	assert.True(t, bytes.HasPrefix(b, []byte("12:57:57 [")), string(b))
+}
+
+func TestNilValues(t *testing.T) {
+	var typedNil *bytes.Buffer
+	var nilError error
+	fields := Fields{"untyped": nil, "typed": typedNil, "err": nilError, "set": "x"}
+
+	testCases := []struct {
+		formatter *TextFormatter
+		expected  []string
+		missing   []string
+	}{
+		{&TextFormatter{ForceColors: true}, []string{"untyped\x1b[0m=\"<nil>\"", "typed\x1b[0m=\"<nil>\"", "err\x1b[0m=\"<nil>\""}, nil},
+		{&TextFormatter{ForceColors: true, NilValueText: "null"}, []string{"untyped\x1b[0m=null", "typed\x1b[0m=null", "err\x1b[0m=null"}, nil},
+		{&TextFormatter{ForceColors: true, OmitNilFields: true}, []string{"set\x1b[0m=x"}, []string{"untyped", "typed", "err\x1b"}},
+		{&TextFormatter{DisableColors: true, NilValueText: "-"}, []string{" - x - - msg"}, []string{"<nil>"}},
+		{&TextFormatter{DisableColors: true, OmitNilFields: true}, []string{"] x "}, []string{"<nil>"}},
+	}
+
+	for _, tc := range testCases {
+		b, err := tc.formatter.Format(&Entry{Data: fields, Message: "msg"})
+		assert.Nil(t, err)
+		for _, expected := range tc.expected {
+			assert.Contains(t, string(b), expected)
+		}
+		for _, missing := range tc.missing {
+			assert.NotContains(t, string(b), missing)
+		}
+	}
+
+	assert.False(t, isNil(""), "empty strings aren't nil")
+	assert.False(t, isNil(0))
+}
+
+func TestFieldColors(t *testing.T) {
+	fields := Fields{"error": "timeout", "latency_ms": 250, "path": "/"}
+	tf := &TextFormatter{ForceColors: true, FieldColors: map[string]int{"error": red, "latency_ms": yellow}}
+
+	b, _ := tf.Format(&Entry{Level: InfoLevel, Data: fields})
+	assert.Contains(t, string(b), "\x1b[31merror\x1b[0m=timeout")
+	assert.Contains(t, string(b), "\x1b[33mlatency_ms\x1b[0m=250")
+	assert.Contains(t, string(b), "\x1b[36mpath\x1b[0m=/")
+
+	tf.ColorValues = true
+	b, _ = tf.Format(&Entry{Level: InfoLevel, Data: fields})
+	assert.Contains(t, string(b), "\x1b[31merror\x1b[0m=\x1b[31mtimeout\x1b[0m")
+	assert.Contains(t, string(b), "\x1b[33mlatency_ms\x1b[0m=\x1b[33m250\x1b[0m")
+	assert.Contains(t, string(b), "\x1b[36mpath\x1b[0m=\x1b[36m/\x1b[0m")
+}
+
+func TestColorScheme(t *testing.T) {
+	fields := Fields{"error": "timeout", "request_id": "abc", "path": "/"}
+	tf := &TextFormatter{
+		ForceColors:     true,
+		FullTimestamp:   true,
+		TimestampFormat: "15:04:05",
+		FieldColors:     map[string]int{"error": yellow, "path": yellow},
+		ColorScheme: &ColorScheme{
+			Levels: map[Level]Color{
+				ErrorLevel: Colors(ColorBold, ColorRed, BgBlack),
+				InfoLevel:  RGBColor(0, 128, 128),
+			},
+			Key:       Color256(244),
+			Timestamp: ColorGray,
+			Fields:    map[string]Color{"request_id": NoColor, "error": ColorMagenta},
+		},
+	}
+	entry := &Entry{Level: ErrorLevel, Message: "msg", Time: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Data: fields}
+
+	b, _ := tf.Format(entry)
+	assert.True(t, strings.HasPrefix(string(b), "\x1b[1;31;40mERRO\x1b[0m\x1b[90m[03:04:05]\x1b[0m msg"), "got %q", string(b))
+	assert.Contains(t, string(b), " \x1b[35merror\x1b[0m=timeout")
+	assert.Contains(t, string(b), " request_id=abc")
+	assert.Contains(t, string(b), " \x1b[33mpath\x1b[0m=/")
+
+	tf.ColorValues = true
+	entry.Level = InfoLevel
+	entry.Data = Fields{"request_id": "abc", "user": "ada"}
+	b, _ = tf.Format(entry)
+	assert.True(t, strings.HasPrefix(string(b), "\x1b[38;2;0;128;128mINFO\x1b[0m"), "got %q", string(b))
+	assert.Contains(t, string(b), " request_id=abc")
+	assert.Contains(t, string(b), " \x1b[38;5;244muser\x1b[0m=\x1b[38;5;244mada\x1b[0m")
+
+	entry.Level = WarnLevel
+	b, _ = tf.Format(entry)
+	assert.True(t, strings.HasPrefix(string(b), "\x1b[33mWARN\x1b[0m"), "levels left out keep their default color, got %q", string(b))
+}
+
+func TestColorFullLine(t *testing.T) {
+	tf := &TextFormatter{ForceColors: true, DisableTimestamp: true, ColorFullLine: true, FieldColors: map[string]int{"path": yellow}}
+	entry := &Entry{Level: WarnLevel, Message: "slow", Data: Fields{"path": "/", "ms": 900}}
+
+	b, _ := tf.Format(entry)
+	assert.Equal(t, "\x1b[33m\x1b[33mWARN\x1b[0;33m"+fmt.Sprintf(" %-44s ", "slow")+
+		" \x1b[33mms\x1b[0;33m=900 \x1b[33mpath\x1b[0;33m=/\x1b[0m\n", string(b))
+
+	tf.ColorScheme = &ColorScheme{Levels: map[Level]Color{WarnLevel: NoColor}}
+	b, _ = tf.Format(entry)
+	assert.True(t, strings.HasPrefix(string(b), "WARN "), "got %q", string(b))
+}
+
+func TestHighlightFatal(t *testing.T) {
+	tf := &TextFormatter{ForceColors: true, DisableTimestamp: true, HighlightFatal: true}
+
+	for _, level := range []Level{FatalLevel, PanicLevel} {
+		b, _ := tf.Format(&Entry{Level: level, Message: "down"})
+		assert.True(t, strings.HasPrefix(string(b), "\x1b[1;37;41m"), "got %q", string(b))
+	}
+	b, _ := tf.Format(&Entry{Level: ErrorLevel, Message: "failed"})
+	assert.True(t, strings.HasPrefix(string(b), "\x1b[31mERRO\x1b[0m"), "got %q", string(b))
+
+	tf.ColorFullLine = true
+	b, _ = tf.Format(&Entry{Level: FatalLevel, Message: "down", Data: Fields{"code": 1}})
+	assert.True(t, strings.HasPrefix(string(b), "\x1b[1;37;41m\x1b[1;37;41mFATA\x1b[0;1;37;41m"), "got %q", string(b))
+	assert.True(t, strings.HasSuffix(string(b), "=1\x1b[0m\n"), "got %q", string(b))
+
+	tf.ColorScheme = &ColorScheme{Levels: map[Level]Color{PanicLevel: ColorMagenta}}
+	b, _ = tf.Format(&Entry{Level: PanicLevel, Message: "down"})
+	assert.True(t, strings.HasPrefix(string(b), "\x1b[35m\x1b[35mPANI"), "the scheme takes precedence, got %q", string(b))
+}
+
+func TestColors(t *testing.T) {
+	assert.Equal(t, Color("1;31;40"), Colors(ColorBold, ColorRed, BgBlack))
+	assert.Equal(t, Color("31"), Colors(NoColor, "", ColorRed))
+	assert.Equal(t, NoColor, Colors())
+	assert.Equal(t, Color("48;2;1;2;3"), RGBBackground(1, 2, 3))
+	assert.Equal(t, Color("48;5;17"), Background256(17))
+}
+
+func TestIndentComplexValues(t *testing.T) {
+	type user struct {
+		Name  string   `json:"name"`
+		Roles []string `json:"roles"`
+	}
+	entry := &Entry{Level: InfoLevel, Message: "request", Data: Fields{
+		"user":   &user{"ada", []string{"admin"}},
+		"empty":  map[string]int{},
+		"status": 200,
+		"err":    errors.New("none"),
+	}}
+
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, DisableRuntimeFields: true, PlainDecorations: true, IndentComplexValues: true}
+	b, _ := tf.Format(entry)
+	assert.Equal(t, "level=info map[] none 200 request \n"+
+		"\tuser={\n"+
+		"\t  \"name\": \"ada\",\n"+
+		"\t  \"roles\": [\n"+
+		"\t    \"admin\"\n"+
+		"\t  ]\n"+
+		"\t}\n", string(b))
+
+	tf = &TextFormatter{ForceColors: true, DisableTimestamp: true, IndentComplexValues: true}
+	b, _ = tf.Format(entry)
+	lines := strings.Split(string(b), "\n")
+	assert.NotContains(t, lines[0], "ada")
+	assert.Equal(t, "\tuser={", lines[1])
+}
+
+func TestFieldOrder(t *testing.T) {
+	defer SetRuntimeInfo(nil)
+	SetRuntimeInfo(stubRuntimeInfo{os: "linux"})
+	pid, tid := 123, 45
+	fields := Fields{"a": 1, "b": 2}
+
+	testCases := []struct {
+		order    []string
+		expected string
+	}{
+		{nil, "[info] [pid 123] [tid 45] [L] 1 2 hello \n"},
+		{[]string{"msg", FieldOrderRest, "process ID", "thread ID", "OS"}, "[info] hello 1 2 [pid 123] [tid 45] [L] \n"},
+		{[]string{"OS", "process ID"}, "[info] [L] [pid 123] 1 2 hello \n"},
+		{[]string{"b", "msg", "thread ID"}, "[info] 2 hello [tid 45] 1 \n"},
+		{[]string{"missing", "b", "b"}, "[info] 2 1 hello \n"},
+		{[]string{}, "[info] 1 2 hello \n"},
+	}
+
+	for _, tc := range testCases {
+		tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, ProcessIDOverride: &pid, ThreadIDOverride: &tid, FieldOrder: tc.order}
+		b, _ := tf.Format(&Entry{Level: InfoLevel, Message: "hello", Data: fields})
+		assert.Equal(t, tc.expected, string(b), "order %v", tc.order)
+	}
+}
+
+func TestDisableRuntimeFields(t *testing.T) {
+	pid, tid := 123, 45
+	entry := &Entry{Level: InfoLevel, Message: "hello", Data: Fields{"a": 1}}
+
+	tf := &TextFormatter{DisableColors: true, DisableTimestamp: true, ProcessIDOverride: &pid, ThreadIDOverride: &tid, DisableRuntimeFields: true}
+	b, _ := tf.Format(entry)
+	assert.Equal(t, "[info] 1 hello \n", string(b))
+
+	tf.FieldOrder = []string{"process ID", "msg"}
+	b, _ = tf.Format(entry)
+	assert.Equal(t, "[info] hello 1 \n", string(b))
+
+	tf = &TextFormatter{ForceColors: true, ProcessIDOverride: &pid, ThreadIDOverride: &tid, CombinePIDTID: true, DisableRuntimeFields: true}
+	b, _ = tf.Format(entry)
+	assert.NotContains(t, string(b), "pid/tid")
+}
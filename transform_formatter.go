@@ -0,0 +1,50 @@
+package logrus
+
+import "fmt"
+
+// TransformFormatter runs Transform on a copy of each entry, e.g. to rename
+// or redact fields, then formats the result with Next:
+//
+//    logger.Formatter = &TransformFormatter{
+//      Transform: func(entry *Entry) error {
+//        if user, ok := entry.Data["user"]; ok {
+//          delete(entry.Data, "user")
+//          entry.Data["user_id"] = user
+//        }
+//        return nil
+//      },
+//      Next: new(TextFormatter),
+//    }
+//
+// Transform gets its own copy of the data, its changes don't leak to the
+// entry being logged nor to the other entries sharing its fields.
+// TransformFormatters can be nested to chain several transforms.
+type TransformFormatter struct {
+	// Transform modifies the entry before it is formatted. The entry isn't
+	// formatted if it returns an error.
+	Transform func(*Entry) error
+
+	// Next formats the transformed entry.
+	Next Formatter
+}
+
+// Format renders a single log entry after transforming a copy of it
+func (f *TransformFormatter) Format(entry *Entry) ([]byte, error) {
+	if f.Next == nil {
+		return nil, fmt.Errorf("No formatter to format the transformed entry")
+	}
+	if f.Transform == nil {
+		return f.Next.Format(entry)
+	}
+
+	transformed := entry.Dup()
+	transformed.Buffer = entry.Buffer
+	if err := f.Transform(transformed); err != nil {
+		return nil, fmt.Errorf("Failed to transform entry, %v", err)
+	}
+	return f.Next.Format(transformed)
+}
+
+func (f *TransformFormatter) reportsEntryID() bool {
+	return reportsEntryID(f.Next)
+}
@@ -0,0 +1,70 @@
+package logrus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func renameField(from, to string) func(*Entry) error {
+	return func(entry *Entry) error {
+		if value, ok := entry.Data[from]; ok {
+			delete(entry.Data, from)
+			entry.Data[to] = value
+		}
+		return nil
+	}
+}
+
+func TestTransformFormatter(t *testing.T) {
+	var buffer bytes.Buffer
+
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &TransformFormatter{
+		Transform: renameField("user", "user_id"),
+		Next:      &TextFormatter{ForceColors: true},
+	}
+
+	entry := logger.WithField("user", 42)
+	entry.Info("hello")
+
+	assert.Contains(t, buffer.String(), "user_id\x1b[0m=42")
+	assert.NotContains(t, buffer.String(), "user\x1b[0m=")
+	assert.Equal(t, Fields{"user": 42}, entry.Data, "entry data must not be modified")
+}
+
+func TestTransformFormatterChain(t *testing.T) {
+	tf := &TransformFormatter{
+		Transform: renameField("b", "c"),
+		Next: &TransformFormatter{
+			Transform: renameField("a", "b"),
+			Next:      &TextFormatter{ForceColors: true},
+		},
+	}
+
+	b, err := tf.Format(WithField("a", 1))
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), "b\x1b[0m=1")
+	assert.NotContains(t, string(b), "c\x1b[0m=")
+}
+
+func TestTransformFormatterErrors(t *testing.T) {
+	tf := &TransformFormatter{
+		Transform: func(*Entry) error { return errors.New("boom") },
+		Next:      new(TextFormatter),
+	}
+	_, err := tf.Format(WithField("a", 1))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "boom")
+
+	tf = &TransformFormatter{Next: &JSONFormatter{}}
+	_, err = tf.Format(WithField("f", func() {}))
+	assert.NotNil(t, err, "errors of the next formatter must be returned")
+
+	tf = &TransformFormatter{Transform: renameField("a", "b")}
+	_, err = tf.Format(WithField("a", 1))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,136 @@
+package logrus
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ValueFormatters maps Go types to the functions rendering the values of
+// that type among the fields of the entries, so that they are printed
+// consistently by the TextFormatter, the JSONFormatter and the
+// LogfmtFormatter rather than with their defaults, e.g. durations as "1.2s"
+// instead of a number of nanoseconds in JSON:
+//
+//    formatter.ValueFormatters = logrus.ValueFormatters{}.
+//      Register(time.Duration(0), logrus.FormatDuration).
+//      Register([]byte(nil), logrus.FormatBytesHex)
+//
+// A type may be an interface, registered with a nil pointer to it such as
+// (*fmt.Stringer)(nil), whose function renders the values implementing it.
+// Functions registered for the exact type of a value take precedence. Only
+// the values of the fields are rendered, not the values nested in them.
+type ValueFormatters map[reflect.Type]func(value interface{}) interface{}
+
+// Register registers format for the type of sample, or for the interface
+// sample points to if it is a nil pointer to an interface. A nil sample,
+// having no type, is ignored. It returns formatters, creating it if it is
+// nil.
+func (formatters ValueFormatters) Register(sample interface{}, format func(value interface{}) interface{}) ValueFormatters {
+	if formatters == nil {
+		formatters = make(ValueFormatters)
+	}
+	t := reflect.TypeOf(sample)
+	if t == nil {
+		return formatters
+	}
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface && reflect.ValueOf(sample).IsNil() {
+		t = t.Elem()
+	}
+	formatters[t] = format
+	return formatters
+}
+
+// StandardValueFormatters returns formatters rendering durations as with
+// FormatDuration, times in RFC 3339, byte slices in hexadecimal and the
+// fmt.Stringer values with their String method.
+func StandardValueFormatters() ValueFormatters {
+	return ValueFormatters{}.
+		Register(time.Duration(0), FormatDuration).
+		Register(time.Time{}, FormatTime(time.RFC3339)).
+		Register([]byte(nil), FormatBytesHex).
+		Register((*fmt.Stringer)(nil), FormatStringer)
+}
+
+// FormatDuration renders a time.Duration as returned by its String method,
+// e.g. "1.2s".
+func FormatDuration(value interface{}) interface{} {
+	return value.(time.Duration).String()
+}
+
+// FormatTime returns the function rendering a time.Time with layout.
+func FormatTime(layout string) func(value interface{}) interface{} {
+	return func(value interface{}) interface{} {
+		return value.(time.Time).Format(layout)
+	}
+}
+
+// FormatBytesHex renders a []byte in hexadecimal.
+func FormatBytesHex(value interface{}) interface{} {
+	return hex.EncodeToString(value.([]byte))
+}
+
+// FormatBytesBase64 renders a []byte in standard base64.
+func FormatBytesBase64(value interface{}) interface{} {
+	return base64.StdEncoding.EncodeToString(value.([]byte))
+}
+
+// FormatStringer renders a fmt.Stringer with its String method, or as
+// "<nil>" for a nil pointer, as fmt does.
+func FormatStringer(value interface{}) interface{} {
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && v.IsNil() {
+		return "<nil>"
+	}
+	return value.(fmt.Stringer).String()
+}
+
+// format returns value rendered by the function registered for its type,
+// if any.
+func (formatters ValueFormatters) format(value interface{}) (interface{}, bool) {
+	if value == nil {
+		return nil, false
+	}
+	t := reflect.TypeOf(value)
+	if format, ok := formatters[t]; ok {
+		return format(value), true
+	}
+	// Among the interfaces implemented, the first by name, for the output
+	// not to depend on the order of the map
+	var interfaceType reflect.Type
+	for registered := range formatters {
+		if registered.Kind() == reflect.Interface && t.Implements(registered) &&
+			(interfaceType == nil || registered.String() < interfaceType.String()) {
+			interfaceType = registered
+		}
+	}
+	if interfaceType == nil {
+		return nil, false
+	}
+	return formatters[interfaceType](value), true
+}
+
+// formatValues returns a copy of data with the values rendered by
+// formatters, or data itself if there are none to render.
+func (formatters ValueFormatters) formatValues(data Fields) Fields {
+	if len(formatters) == 0 {
+		return data
+	}
+	var formatted Fields
+	for k, v := range data {
+		value, ok := formatters.format(v)
+		if !ok {
+			continue
+		}
+		if formatted == nil {
+			formatted = make(Fields, len(data))
+			MergeFields(formatted, data)
+		}
+		formatted[k] = value
+	}
+	if formatted == nil {
+		return data
+	}
+	return formatted
+}
@@ -0,0 +1,87 @@
+package logrus
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type upperStringer string
+
+func (s upperStringer) String() string {
+	return "(" + string(s) + ")"
+}
+
+func TestStandardValueFormatters(t *testing.T) {
+	formatters := StandardValueFormatters()
+	entry := &Entry{Level: InfoLevel, Message: "done", Data: Fields{
+		"took":  1200 * time.Millisecond,
+		"at":    time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		"hash":  []byte{0xca, 0xfe},
+		"ip":    net.IPv4(10, 0, 0, 1),
+		"name":  upperStringer("ada"),
+		"count": 3,
+	}}
+
+	b, err := (&JSONFormatter{DisableTimestamp: true, ValueFormatters: formatters}).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"at":"2020-01-02T03:04:05Z","count":3,"hash":"cafe","ip":"10.0.0.1","level":"info","msg":"done","name":"(ada)","took":"1.2s"}`+"\n", string(b))
+
+	b, err = (&LogfmtFormatter{DisableTimestamp: true, ValueFormatters: formatters}).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "level=info msg=done at=2020-01-02T03:04:05Z count=3 hash=cafe ip=10.0.0.1 name=(ada) took=1.2s\n", string(b))
+
+	b, err = (&TextFormatter{ForceColors: true, DisableTimestamp: true, ValueFormatters: formatters}).Format(entry)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "took\x1b[0m=1.2s")
+	assert.Contains(t, string(b), "hash\x1b[0m=cafe")
+
+	_, isDuration := entry.Data["took"].(time.Duration)
+	assert.True(t, isDuration, "the data of the entry must not be modified")
+}
+
+func TestFormatStringerNilPointer(t *testing.T) {
+	var u *url.URL
+	assert.Equal(t, "<nil>", FormatStringer(u))
+
+	entry := &Entry{Level: InfoLevel, Message: "done", Data: Fields{"url": u}}
+	b, err := (&JSONFormatter{DisableTimestamp: true, ValueFormatters: StandardValueFormatters()}).Format(entry)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"url":"\u003cnil\u003e"`)
+}
+
+func TestValueFormattersPrecedence(t *testing.T) {
+	formatters := ValueFormatters{}.
+		Register((*fmt.Stringer)(nil), FormatStringer).
+		Register(upperStringer(""), func(value interface{}) interface{} { return "exact" }).
+		Register([]byte(nil), FormatBytesBase64)
+
+	value, ok := formatters.format(upperStringer("ada"))
+	assert.True(t, ok)
+	assert.Equal(t, "exact", value)
+
+	value, ok = formatters.format(time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, "1s", value)
+
+	value, _ = formatters.format([]byte("hi"))
+	assert.Equal(t, "aGk=", value)
+
+	_, ok = formatters.format(42)
+	assert.False(t, ok)
+	_, ok = formatters.format(nil)
+	assert.False(t, ok)
+
+	data := Fields{"n": 1}
+	assert.Equal(t, fmt.Sprintf("%p", data), fmt.Sprintf("%p", formatters.formatValues(data)), "data without values to render isn't copied")
+
+	var registered ValueFormatters
+	registered = registered.Register(time.Duration(0), FormatDuration)
+	assert.Len(t, registered, 1)
+	registered = registered.Register(nil, FormatStringer)
+	assert.Len(t, registered, 1, "nil samples are ignored")
+}
@@ -1,29 +1,60 @@
 package logrus
 
 import (
-	"bufio"
+	"bytes"
 	"io"
-	"runtime"
+	"log"
+	"sync"
 )
 
-func (logger *Logger) Writer() *io.PipeWriter {
+// DefaultMaxLineSize is the default size in bytes beyond which the lines
+// written to a LineWriter are split into several entries.
+const DefaultMaxLineSize = 64 * 1024
+
+func (logger *Logger) Writer() io.WriteCloser {
 	return logger.WriterLevel(InfoLevel)
 }
 
-func (logger *Logger) WriterLevel(level Level) *io.PipeWriter {
+func (logger *Logger) WriterLevel(level Level) io.WriteCloser {
 	return NewEntry(logger).WriterLevel(level)
 }
 
-func (entry *Entry) Writer() *io.PipeWriter {
+// StdLogger returns a log.Logger of the standard library logging every line
+// with the logger as an entry of the given level, e.g. for the ErrorLog of an
+// http.Server:
+//
+//    srv := &http.Server{ErrorLog: logger.StdLogger(logrus.ErrorLevel)}
+func (logger *Logger) StdLogger(level Level) *log.Logger {
+	return log.New(logger.WriterLevel(level), "", 0)
+}
+
+func (entry *Entry) Writer() io.WriteCloser {
 	return entry.WriterLevel(InfoLevel)
 }
 
-func (entry *Entry) WriterLevel(level Level) *io.PipeWriter {
-	reader, writer := io.Pipe()
+// WriterLevel returns a writer logging every line written to it as an entry
+// of the given level, with the fields of entry, e.g. to capture the output
+// of a library or of a command:
+//
+//    w := logger.WriterLevel(logrus.WarnLevel)
+//    defer w.Close()
+//    cmd.Stderr = w
+//
+// It is a LineWriter, see NewLineWriter to set its MaxLineSize or close it
+// with an error. It used to be an *io.PipeWriter, logging the lines from
+// another goroutine.
+func (entry *Entry) WriterLevel(level Level) io.WriteCloser {
+	return NewLineWriter(entry, level)
+}
 
+// NewLineWriter returns a LineWriter logging every line written to it as an
+// entry of the given level, with the fields of entry.
+func NewLineWriter(entry *Entry, level Level) *LineWriter {
 	var printFunc func(args ...interface{})
 
 	switch level {
+	case TraceLevel:
+		printFunc = entry.Trace
 	case DebugLevel:
 		printFunc = entry.Debug
 	case InfoLevel:
@@ -40,23 +71,97 @@ func (entry *Entry) WriterLevel(level Level) *io.PipeWriter {
 		printFunc = entry.Print
 	}
 
-	go entry.writerScanner(reader, printFunc)
-	runtime.SetFinalizer(writer, writerFinalizer)
+	return &LineWriter{MaxLineSize: DefaultMaxLineSize, printFunc: printFunc}
+}
+
+// LineWriter is an io.WriteCloser logging every line written to it as an
+// entry, without the trailing newline. Lines written in several parts are
+// buffered until they are complete, lines longer than MaxLineSize are
+// logged in several entries. Lines are logged synchronously by Write, so it
+// can be handed to a log.Logger, e.g. as the ErrorLog of an http.Server. It
+// is safe for concurrent use.
+//
+// The last line is only logged by Close if it doesn't end with a newline.
+type LineWriter struct {
+	// MaxLineSize is the size in bytes of the longest entry logged, longer
+	// lines are split. 0 disables splitting.
+	MaxLineSize int
+
+	printFunc func(args ...interface{})
+
+	mu     sync.Mutex
+	line   []byte
+	closed bool
+	err    error
+}
+
+// Write logs the lines completed by p and buffers what follows the last
+// newline. It fails once the writer is closed.
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, w.err
+	}
 
-	return writer
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.line = append(w.line, p...)
+			break
+		}
+		w.line = append(w.line, p[:i]...)
+		p = p[i+1:]
+		w.splitLine()
+		w.printLine(w.line)
+		w.line = w.line[:0]
+	}
+	w.splitLine()
+	return n, nil
 }
 
-func (entry *Entry) writerScanner(reader *io.PipeReader, printFunc func(args ...interface{})) {
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		printFunc(scanner.Text())
+// splitLine logs the beginning of the buffered line while it is longer than
+// MaxLineSize.
+func (w *LineWriter) splitLine() {
+	if w.MaxLineSize <= 0 {
+		return
 	}
-	if err := scanner.Err(); err != nil {
-		entry.Errorf("Error while reading from Writer: %s", err)
+	for len(w.line) > w.MaxLineSize {
+		w.printLine(w.line[:w.MaxLineSize])
+		w.line = append(w.line[:0], w.line[w.MaxLineSize:]...)
 	}
-	reader.Close()
 }
 
-func writerFinalizer(writer *io.PipeWriter) {
-	writer.Close()
+func (w *LineWriter) printLine(line []byte) {
+	// As bufio.ScanLines, drop the carriage return of Windows line endings
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	w.printFunc(string(line))
+}
+
+// Close logs the buffered partial line, if any. Writing afterwards fails
+// with io.ErrClosedPipe.
+func (w *LineWriter) Close() error {
+	return w.CloseWithError(nil)
+}
+
+// CloseWithError closes the writer as Close does, writing afterwards fails
+// with err, or io.ErrClosedPipe if it is nil.
+func (w *LineWriter) CloseWithError(err error) error {
+	if err == nil {
+		err = io.ErrClosedPipe
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	w.err = err
+	if len(w.line) > 0 {
+		w.printLine(w.line)
+		w.line = nil
+	}
+	return nil
 }
@@ -0,0 +1,97 @@
+package logrus
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newLineWriterLogger() (*Logger, *bytes.Buffer) {
+	var buffer bytes.Buffer
+	logger := New()
+	logger.Out = &buffer
+	logger.Formatter = &LogfmtFormatter{DisableTimestamp: true}
+	return logger, &buffer
+}
+
+func TestLineWriter(t *testing.T) {
+	logger, buffer := newLineWriterLogger()
+	w := NewLineWriter(logger.WithField("source", "lib"), WarnLevel)
+
+	for _, part := range []string{"first li", "ne\nsecond line\r\n\nthi", "rd"} {
+		n, err := w.Write([]byte(part))
+		assert.NoError(t, err)
+		assert.Equal(t, len(part), n)
+	}
+	assert.Equal(t, "level=warning msg=\"first line\" source=lib\n"+
+		"level=warning msg=\"second line\" source=lib\n"+
+		"level=warning msg=\"\" source=lib\n", buffer.String())
+
+	buffer.Reset()
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "level=warning msg=third source=lib\n", buffer.String())
+
+	_, err := w.Write([]byte("closed\n"))
+	assert.Equal(t, io.ErrClosedPipe, err)
+	assert.NoError(t, w.Close())
+}
+
+func TestLineWriterMaxLineSize(t *testing.T) {
+	logger, buffer := newLineWriterLogger()
+	w := NewLineWriter(NewEntry(logger), InfoLevel)
+	w.MaxLineSize = 4
+
+	w.Write([]byte("abcdefghij"))
+	w.Write([]byte("k\nlm\n"))
+	w.Close()
+
+	assert.Equal(t, "level=info msg=abcd\nlevel=info msg=efgh\nlevel=info msg=ijk\nlevel=info msg=lm\n", buffer.String())
+}
+
+func TestLineWriterCloseWithError(t *testing.T) {
+	logger, buffer := newLineWriterLogger()
+	w := NewLineWriter(NewEntry(logger), InfoLevel)
+	failed := errors.New("failed")
+
+	assert.NoError(t, w.CloseWithError(failed))
+	_, err := w.Write([]byte("closed\n"))
+	assert.Equal(t, failed, err)
+	assert.Equal(t, 0, buffer.Len())
+}
+
+func TestLineWriterAsLogOutput(t *testing.T) {
+	logger, buffer := newLineWriterLogger()
+	w := logger.WriterLevel(ErrorLevel)
+	defer w.Close()
+
+	stdlog := log.New(w, "http: ", 0)
+	stdlog.Printf("TLS handshake error from %s", "10.0.0.1")
+	stdlog.Print(strings.Repeat("x", 3) + "\nmultiline")
+
+	assert.Equal(t, "level=error msg=\"http: TLS handshake error from 10.0.0.1\"\n"+
+		"level=error msg=\"http: xxx\"\n"+
+		"level=error msg=multiline\n", buffer.String())
+}
+
+func TestWriterLevel(t *testing.T) {
+	logger, buffer := newLineWriterLogger()
+	w := logger.WithField("source", "lib").WriterLevel(WarnLevel)
+	_, ok := w.(*LineWriter)
+	assert.True(t, ok)
+
+	w.Write([]byte("partial"))
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "level=warning msg=partial source=lib\n", buffer.String())
+}
+
+func TestStdLogger(t *testing.T) {
+	logger, buffer := newLineWriterLogger()
+	logger.StdLogger(WarnLevel).Println("deprecated option")
+
+	assert.Equal(t, "level=warning msg=\"deprecated option\"\n", buffer.String())
+}